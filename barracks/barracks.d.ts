@@ -1,5 +1,5 @@
 import { Evaluation, UnitStats, GeneratedUnit, Ability } from "./types";
-import { ActionDescription, BattleState, DeploymentState } from "./battle";
+import { ActionDescription, BattleState, DeploymentState, Scenario } from "./battle";
 
 declare global {
   namespace Barracks {
@@ -8,6 +8,8 @@ declare global {
     /** Génère une unité visant un coût cible (le rang n'est plus que narratif). */
     function generateUnit(targetCost: number, archetype: string): Promise<GeneratedUnit>;
     function getAvailableAbilities(locale: string): Promise<Ability[]>;
+    /** Scénarios embarqués, à proposer avant le déploiement. */
+    function getScenarios(locale: string): Promise<Scenario[]>;
     /**
      * Démarre une partie. `obstacle` est l'emplacement choisi par le joueur
      * pendant la mise en place ; l'IA place le sien.
@@ -34,11 +36,18 @@ declare global {
      */
     function resumeGame(): Promise<BattleState>;
 
-    /** Ouvre la phase de déploiement alterné (règles : placement tour à tour). */
+    /**
+     * Ouvre la phase de déploiement alterné (règles : placement tour à tour).
+     * `board` choisit un scénario embarqué (cf. `getScenarios`) ou de simples
+     * dimensions ; à défaut, le plateau publié. Un scénario qui déclare ses
+     * obstacles ignore `obstacles`. La partie lancée ensuite par `startGame`
+     * se joue sur ce plateau, avec le terrain et les règles du scénario.
+     */
     function startDeployment(
       playerUnits: UnitStats[],
       aiUnits: UnitStats[],
-      obstacles: { x: number; y: number }[]
+      obstacles: { x: number; y: number }[],
+      board?: { width: number; height: number } | { scenario: string }
     ): Promise<DeploymentState>;
     /**
      * Place l'unité choisie par le joueur ; l'IA répond dans la foulée.
@@ -48,7 +57,14 @@ declare global {
     function deployUnit(unitIndex: number, x: number, y: number): Promise<DeploymentState>;
     function getValidActions(): ActionDescription[];
     function selectAction(index: number): Promise<BattleState>;
+    /**
+     * Reprend la dernière action du joueur, et la réponse de l'IA avec elle.
+     * À appeler quand le joueur a la main.
+     */
+    function undoAction(): Promise<BattleState>;
     function endGame(): void;
+    /** Relevé JSON de la partie en cours, à joindre à un rapport de bogue. */
+    function exportGame(): Promise<string>;
 
     /** Budget d'escouade en points de coût — l'unique monnaie du jeu. */
     const SquadBudget: number;
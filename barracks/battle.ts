@@ -1,5 +1,6 @@
 export type ActionType = "move" | "attack" | "ability";
 export type Difficulty = "easy" | "normal" | "hard";
+export type TerrainType = "difficult" | "light-cover" | "high-ground" | "hazardous";
 
 /**
  * Instantané léger du plateau, capturé par le moteur juste après l'application
@@ -19,11 +20,47 @@ export interface BattleFrame {
     overcharged: boolean;
     defensiveStance: boolean;
     guardianOf: number;
+    overwatch: boolean;
+    counterStrike: boolean;
+    routed: boolean;
   }[];
   actionsLeft: number;
   currentPlayerID: number;
 }
 
+/**
+ * Effet produit par le moteur : la cause de ce que montre l'image suivante
+ * (dégât absorbé par une Posture Défensive, redirigé par un Gardien…).
+ */
+export type BattleEvent =
+  | { type: "turn-started"; playerID: number; actionsLeft: number }
+  | { type: "turn-ended"; playerID: number }
+  | { type: "unit-moved"; unitID: number; fromX: number; fromY: number; toX: number; toY: number }
+  | {
+      type: "damage-dealt";
+      unitID: number;
+      /** Allié protégé par le Gardien qui a pris les coups, -1 sinon. */
+      redirectedFrom: number;
+      damage: number;
+      /** Points retranchés par la Défense de la cible. */
+      armor: number;
+      /** Points annulés par la Posture Défensive. */
+      absorbed: number;
+      dealt: number;
+      health: number;
+    }
+  | { type: "unit-killed"; unitID: number; playerID: number; x: number; y: number }
+  | { type: "status-applied"; unitID: number; status: string; value: number }
+  | { type: "status-expired"; unitID: number; status: string }
+  /** Jet de dés d'une attaque (scénario aux dés) ; damage 0 = attaque manquée. */
+  | { type: "attack-rolled"; unitID: number; targetUnitID: number; nominal: number; damage: number }
+  /** Réaction jouée pendant le tour adverse ; ses effets suivent. */
+  | { type: "unit-reacted"; unitID: number; status: string; triggerUnitID: number }
+  | { type: "control-point-scored"; playerID: number; total: number }
+  | { type: "control-point-stolen"; playerID: number; byPlayerID: number; total: number }
+  /** Test de moral d'une unité d'une escouade ébranlée ; « fled » : elle quitte le plateau. */
+  | { type: "morale-checked"; unitID: number; roll: number; outcome: "passed" | "routed" | "retreated" | "fled" };
+
 export interface ActionDescription {
   index: number;
   type: ActionType;
@@ -32,10 +69,14 @@ export interface ActionDescription {
   targetUnitID: number;
   targetX: number;
   targetY: number;
+  /** Cibles suivantes d'une capacité à plusieurs cibles, dans l'ordre. */
+  choices?: { unitID: number; x: number; y: number }[];
   label: string;
   /** Renseignés uniquement sur les actions déjà jouées (`recentActions`). */
   playerID?: number;
   frame?: BattleFrame;
+  /** Événements survenus depuis l'action précédente, celle-ci comprise. */
+  events?: BattleEvent[];
 }
 
 export interface BattleUnit {
@@ -47,6 +88,7 @@ export interface BattleUnit {
   maxHealth: number;
   range: number;
   power: number;
+  defense: number;
   move: number;
   abilities: string[];
   x: number;
@@ -56,6 +98,47 @@ export interface BattleUnit {
   overcharged: boolean;
   defensiveStance: boolean;
   guardianOf: number;
+  /** Réactions préparées (Tir de Couverture, Riposte). */
+  overwatch: boolean;
+  counterStrike: boolean;
+  /** Chef à abattre (mission assassination). */
+  leader: boolean;
+  /** Unité à escorter jusqu'au bord adverse (mission escort). */
+  escort: boolean;
+  /** En déroute (règle du moral) : l'unité n'agit pas de ce tour. */
+  routed: boolean;
+}
+
+/** Géométrie du plateau joué : dimensions, zone de capture, obstacles. */
+export interface BoardGeometry {
+  width: number;
+  height: number;
+  objectiveZone: { x: number; y: number }[];
+  validObstaclePositions: { x: number; y: number }[];
+  /** Rangées de déploiement, de la plus reculée à la plus avancée. */
+  deploymentRows: { player: number[]; ai: number[] };
+  /** Cases de déploiement, dans l'ordre des rangées. */
+  deploymentPositions: { player: { x: number; y: number }[]; ai: { x: number; y: number }[] };
+}
+
+/** Scénario embarqué : une table de jeu prête à l'emploi. */
+export interface Scenario {
+  id: string;
+  label: string;
+  description: string;
+  board: BoardGeometry;
+  /** Obstacles fixes ; vide = chaque camp pose le sien. */
+  obstacles: { x: number; y: number }[];
+  terrain: { x: number; y: number; type: TerrainType }[];
+  /** Limite de tours ; 0 = limite par défaut. */
+  maxTurns: number;
+  /** Condition de victoire du scénario. */
+  mission: string;
+  /**
+   * Brouillard de guerre : l'état de partie ne contient que les unités
+   * adverses en ligne de vue des unités du joueur.
+   */
+  fogOfWar: boolean;
 }
 
 export interface BattleState {
@@ -68,7 +151,14 @@ export interface BattleState {
   awaitingResume?: boolean;
   units: BattleUnit[];
   obstacles: { x: number; y: number }[];
+  /** Cases typées ; les cases absentes sont dégagées. */
+  terrain: { x: number; y: number; type: TerrainType }[];
+  board: BoardGeometry;
   controlPoints: { player: number; ai: number };
+  /** Condition de victoire : capture, assassination, escort, zone-control, kill-points. */
+  mission: string;
+  /** Coût des unités adverses tuées (mission kill-points). */
+  victoryPoints: { player: number; ai: number };
   currentPlayerID: number;
   humanPlayerID: number;
   actionsLeft: number;
@@ -116,7 +206,18 @@ export interface DeploymentState {
   placed: number;
   aiPositions: { x: number; y: number }[];
   obstacles: { x: number; y: number }[];
+  board: BoardGeometry;
+  /** Scénario choisi, "" pour un plateau sans scénario. */
+  scenario: string;
+  terrain: { x: number; y: number; type: TerrainType }[];
   playerTotal: number;
   aiTotal: number;
   done: boolean;
 }
+
+/**
+ * Dégâts d'un coup de Puissance power sur la cible, sa Défense retranchée :
+ * un coup porté inflige au moins 1 (règle publiée, cf. sim.CombatRules).
+ */
+export const hitDamage = (power: number, target?: BattleUnit): number =>
+  target ? Math.max(power - target.defense, 1) : power;
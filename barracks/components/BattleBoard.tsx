@@ -1,6 +1,6 @@
 import React, { useMemo } from "react";
 import { useTranslation } from "react-i18next";
-import { BattleUnit, ActionDescription, BattleEffect } from "../battle";
+import { BattleUnit, ActionDescription, BattleEffect, hitDamage } from "../battle";
 
 const BOARD_SIZE = 8;
 const COLUMNS = ["A", "B", "C", "D", "E", "F", "G", "H"];
@@ -57,11 +57,14 @@ const ABILITY_FAMILY: Record<string, string> = {
   "00005-command-forward": "rush",
   "00001-energy-trait": "beam",
   "00010-precision-shot": "beam",
+  "00014-life-drain": "beam",
   "00003-suppressing-fire": "wave",
   "00009-sweep": "wave",
   "00002-defensive-stance": "halo",
   "00008-guardian": "halo",
   "00011-overcharge": "halo",
+  "00012-overwatch": "beam",
+  "00013-counter-strike": "impact",
   "00006-devastating-strike": "impact",
   "00004-tactical-retreat": "ghost",
   "00007-feint": "ghost",
@@ -147,8 +150,9 @@ export const BattleBoard: React.FC<BattleBoardProps> = ({
   /** Dégâts prévisualisés sur la cible survolée, avant de cliquer. */
   const previewedDamage = useMemo(() => {
     if (!hoveredAction || hoveredAction.type !== "attack" || !selectedUnit) return null;
-    return { unitID: hoveredAction.targetUnitID, amount: selectedUnit.power };
-  }, [hoveredAction, selectedUnit]);
+    const target = units.find((u) => u.id === hoveredAction.targetUnitID);
+    return { unitID: hoveredAction.targetUnitID, amount: hitDamage(selectedUnit.power, target) };
+  }, [hoveredAction, selectedUnit, units]);
 
   const handleCellClick = (x: number, y: number) => {
     if (isReplaying) return;
@@ -422,6 +426,8 @@ const UnitToken: React.FC<UnitTokenProps> = ({
   if (unit.untargetable) statuses.push("untargetable");
   if (unit.overcharged) statuses.push("overcharged");
   if (unit.defensiveStance) statuses.push("defensiveStance");
+  if (unit.overwatch) statuses.push("overwatch");
+  if (unit.counterStrike) statuses.push("counterStrike");
 
   // La ruée : un décalage vers la cible, absorbé par la transition du jeton.
   const lungeStyle: React.CSSProperties | undefined = lunge
@@ -69,7 +69,15 @@ export const MoveIcon: React.FC<IconProps> = ({ size = 20, strokeWidth = 1.6, co
   </svg>
 );
 
-export type StatKey = "health" | "range" | "power" | "move";
+/** Écu : l'armure retranchée de chaque coup. */
+export const DefenseIcon: React.FC<IconProps> = ({ size = 20, strokeWidth = 1.6, color }) => (
+  <svg {...base(size, strokeWidth, color)}>
+    <path d="M12 3.5 19.5 6v6c0 4.5-3.2 7.5-7.5 8.5-4.3-1-7.5-4-7.5-8.5V6z" />
+    <path d="M12 8v8.5" />
+  </svg>
+);
+
+export type StatKey = "health" | "range" | "power" | "move" | "defense";
 
 export const StatIcon: React.FC<IconProps & { stat: StatKey }> = ({ stat, ...rest }) => {
   switch (stat) {
@@ -77,6 +85,7 @@ export const StatIcon: React.FC<IconProps & { stat: StatKey }> = ({ stat, ...res
     case "range": return <RangeIcon {...rest} />;
     case "power": return <PowerIcon {...rest} />;
     case "move": return <MoveIcon {...rest} />;
+    case "defense": return <DefenseIcon {...rest} />;
   }
 };
 
@@ -14,7 +14,7 @@ interface UnitCardProps {
   className?: string;
 }
 
-const STATS: StatKey[] = ["health", "range", "power", "move"];
+const STATS: StatKey[] = ["health", "range", "power", "move", "defense"];
 
 /* =============================================================================
    La carte d'unité — l'objet identitaire du produit.
@@ -69,7 +69,7 @@ export const UnitCard: React.FC<UnitCardProps> = ({ unit, variant = "full", clas
           <div key={stat} className="unit-card__stat">
             <StatIcon stat={stat} size={variant === "preview" ? 14 : 20} color="var(--text-dim)" />
 
-            <div className="unit-card__stat-num">{unit[stat]}</div>
+            <div className="unit-card__stat-num">{unit[stat] ?? 0}</div>
             <div className="unit-card__stat-label">{t(`stats.${stat}` as never)}</div>
           </div>
         ))}
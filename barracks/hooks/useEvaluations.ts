@@ -14,7 +14,7 @@ export const useEvaluations = (units: Unit[]): Record<string, Evaluation> => {
   // La signature ne dépend que de ce qui influe sur le coût : inutile de
   // réévaluer parce qu'une illustration a changé.
   const signature = units
-    .map((u) => `${u.id}:${u.health}/${u.range}/${u.power}/${u.move}/${u.abilities?.join("+") ?? ""}`)
+    .map((u) => `${u.id}:${u.health}/${u.range}/${u.power}/${u.move}/${u.defense ?? 0}/${u.abilities?.join("+") ?? ""}`)
     .join("|");
 
   useEffect(() => {
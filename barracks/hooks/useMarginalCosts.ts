@@ -2,7 +2,7 @@ import { useEffect, useState } from "react";
 import { Unit } from "../types";
 import { StatKey } from "../components/Icons";
 
-const STATS: StatKey[] = ["health", "range", "power", "move"];
+const STATS: StatKey[] = ["health", "range", "power", "move", "defense"];
 
 /**
  * Coût du cran suivant, caractéristique par caractéristique.
@@ -18,7 +18,7 @@ export const useMarginalCosts = (
 ): Partial<Record<StatKey, number>> => {
   const [deltas, setDeltas] = useState<Partial<Record<StatKey, number>>>({});
 
-  const signature = `${unit.health}/${unit.range}/${unit.power}/${unit.move}/${unit.abilities?.join("+") ?? ""}`;
+  const signature = `${unit.health}/${unit.range}/${unit.power}/${unit.move}/${unit.defense ?? 0}/${unit.abilities?.join("+") ?? ""}`;
 
   useEffect(() => {
     if (currentCost === undefined) return;
@@ -28,7 +28,7 @@ export const useMarginalCosts = (
       const entries = await Promise.all(
         STATS.map(async (stat) => {
           try {
-            const probe = await Barracks.evaluateUnit({ ...unit, [stat]: unit[stat] + 1 });
+            const probe = await Barracks.evaluateUnit({ ...unit, [stat]: (unit[stat] ?? 0) + 1 });
             return [stat, probe.cost - currentCost] as const;
           } catch {
             return [stat, undefined] as const;
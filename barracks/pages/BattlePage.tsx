@@ -2,7 +2,7 @@ import React, { useEffect, useMemo, useRef, useState } from "react";
 import { useNavigate } from "react-router";
 import { useTranslation } from "react-i18next";
 import { Squad, Unit } from "../types";
-import { ActionDescription, BattleState, DeploymentState, Difficulty } from "../battle";
+import { ActionDescription, BattleState, DeploymentState, Difficulty, hitDamage } from "../battle";
 import { BattleBoard, cellName } from "../components/BattleBoard";
 import { ReplayLogEntry, useBattleReplay } from "../hooks/useBattleReplay";
 import { useAbilities } from "../hooks/useAbilities";
@@ -203,6 +203,7 @@ export const BattlePage: React.FC<BattlePageProps> = ({ squads, units }) => {
       range: u.range,
       move: u.move,
       power: u.power,
+      defense: u.defense ?? 0,
       abilities: u.abilities,
       name: u.name,
       imageUrl: u.imageUrl ?? "",
@@ -247,6 +248,7 @@ export const BattlePage: React.FC<BattlePageProps> = ({ squads, units }) => {
       range: u.range,
       move: u.move,
       power: u.power,
+      defense: u.defense ?? 0,
       abilities: u.abilities,
       name: u.name,
       imageUrl: u.imageUrl ?? "",
@@ -566,6 +568,7 @@ export const BattlePage: React.FC<BattlePageProps> = ({ squads, units }) => {
                       <span className="deploy__unit-name">{unit.name}</span>
                       <span className="deploy__unit-stats num">
                         {unit.health} · {unit.range} · {unit.power} · {unit.move}
+                        {unit.defense ? ` · ${unit.defense}` : ""}
                       </span>
                     </span>
                     {done && <span className="deploy__unit-check">✓</span>}
@@ -885,7 +888,7 @@ export const BattlePage: React.FC<BattlePageProps> = ({ squads, units }) => {
               </div>
 
               <div className="row row--2">
-                {(["range", "power", "move"] as const).map((stat) => (
+                {(["range", "power", "move", "defense"] as const).map((stat) => (
                   <div key={stat} className="unit-tile__stat stat-chip">
                     <StatIcon stat={stat} size={11} strokeWidth={2} color="var(--text-faint)" />
                     <span>{selectedUnit[stat]}</span>
@@ -982,13 +985,22 @@ export const BattlePage: React.FC<BattlePageProps> = ({ squads, units }) => {
                       {action.type === "move"
                         ? t("battle.moveTo", { cell: cellName(action.targetX, action.targetY) })
                         : action.type === "ability"
-                        ? abilityName(action.abilityID)
+                        ? action.choices?.length
+                          ? `${abilityName(action.abilityID)} · ${[
+                              { x: action.targetX, y: action.targetY },
+                              ...action.choices,
+                            ]
+                              .map((c) => cellName(c.x, c.y))
+                              .join(", ")}`
+                          : abilityName(action.abilityID)
                         : action.label}
                     </span>
 
                     {/* Le coût du coup, annoncé avant d'être porté */}
                     {action.type === "attack" && selectedUnit && (
-                      <span className="num action-btn__damage">−{selectedUnit.power}</span>
+                      <span className="num action-btn__damage">
+                        −{hitDamage(selectedUnit.power, display.find((u) => u.id === action.targetUnitID))}
+                      </span>
                     )}
                   </button>
                 ))}
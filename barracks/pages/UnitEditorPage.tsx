@@ -28,9 +28,11 @@ interface UnitEditorPageProps {
   onSave: (unit: Unit) => void;
 }
 
-const STATS: StatKey[] = ["health", "range", "power", "move"];
+const STATS: StatKey[] = ["health", "range", "power", "move", "defense"];
 
 const MAX_STAT = 10;
+/** Plancher d'une caractéristique : seule l'armure peut valoir 0. */
+const minStat = (stat: StatKey) => (stat === "defense" ? 0 : 1);
 
 const MAX_ABILITIES = 3;
 
@@ -112,7 +114,7 @@ export const UnitEditorPage: React.FC<UnitEditorPageProps> = ({ units, onSave })
   const costMax = maxUnitCost();
   const overBudget = (evaluation?.cost ?? 0) > costMax;
   const setStat = (stat: StatKey, value: number) => {
-    setFormData((prev) => ({ ...prev, [stat]: Math.max(1, Math.min(MAX_STAT, value)) }));
+    setFormData((prev) => ({ ...prev, [stat]: Math.max(minStat(stat), Math.min(MAX_STAT, value)) }));
   };
 
   const removeAbility = (abilityId: string) => {
@@ -140,6 +142,7 @@ export const UnitEditorPage: React.FC<UnitEditorPageProps> = ({ units, onSave })
         move: generated.move,
         range: generated.range,
         power: generated.power,
+        defense: generated.defense,
         imageUrl: imageForArchetype(generated.archetype),
         abilities: generated.abilities,
       }));
@@ -259,7 +262,7 @@ export const UnitEditorPage: React.FC<UnitEditorPageProps> = ({ units, onSave })
               <div className="hint">{t("unitEditor.nextNotchHint")}</div>
             </div>
             {STATS.map((stat) => {
-              const value = formData[stat];
+              const value = formData[stat] ?? 0;
               const delta = marginal[stat];
 
               return (
@@ -275,7 +278,7 @@ export const UnitEditorPage: React.FC<UnitEditorPageProps> = ({ units, onSave })
                       type="button"
                       className="stepbtn stepbtn--compact"
                       aria-label={t("unitEditor.decrease")}
-                      disabled={value <= 1}
+                      disabled={value <= minStat(stat)}
                       onClick={() => setStat(stat, value - 1)}
 >
                       <MinusIcon />
@@ -23,7 +23,7 @@ interface UnitsPageProps {
 
 type SortMode = "cost" | "name";
 
-const STATS: StatKey[] = ["health", "range", "power", "move"];
+const STATS: StatKey[] = ["health", "range", "power", "move", "defense"];
 
 /* =============================================================================
    Liste des unités.
@@ -171,7 +171,7 @@ export const UnitsPage: React.FC<UnitsPageProps> = ({ units, onDeleteUnit }) =>
                   <div key={stat} className="unit-tile__stat" title={t(`stats.${stat}` as never)}>
                     <StatIcon stat={stat} size={10} strokeWidth={2} color="var(--text-faint)" />
 
-                    <span>{unit[stat]}</span>
+                    <span>{unit[stat] ?? 0}</span>
                   </div>
                 ))}
               </div>
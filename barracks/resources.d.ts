@@ -65,6 +65,7 @@ interface Resources {
       "units": "units"
     },
     "stats": {
+      "defense": "Defense",
       "health": "Health",
       "move": "Move",
       "power": "Power",
@@ -20,6 +20,8 @@ export interface UnitStats {
   range: number;
   move: number;
   power: number;
+  /** Armure ; absente = 0 (unités enregistrées avant la Défense). */
+  defense?: number;
   abilities: string[]
 }
 
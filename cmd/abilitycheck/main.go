@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+	"github.com/bornholm/escarmouche/pkg/sim"
+)
+
+var (
+	packDir = ""
+)
+
+func init() {
+	flag.StringVar(&packDir, "dir", packDir, "ability directory to check instead of the embedded catalogue")
+}
+
+func main() {
+	flag.Parse()
+
+	// Capacités codées dans le moteur : les autres sont déclarées ou
+	// scriptées dans leur fichier.
+	implemented := sim.NativeAbilities()
+
+	var problems []core.AbilityProblem
+	if packDir != "" {
+		problems = core.CheckAbilityPack(os.DirFS(packDir), implemented)
+	} else {
+		problems = core.CheckAbilities(implemented)
+	}
+
+	if len(problems) == 0 {
+		fmt.Println("No problem found.")
+		return
+	}
+
+	for _, problem := range problems {
+		fmt.Printf("- %s\n", problem)
+	}
+	fmt.Printf("%d problem(s) found.\n", len(problems))
+
+	os.Exit(1)
+}
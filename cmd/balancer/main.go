@@ -8,19 +8,49 @@ import (
 
 	"github.com/bornholm/escarmouche/pkg/balancing"
 	"github.com/bornholm/escarmouche/pkg/core"
+	"github.com/bornholm/escarmouche/pkg/scenario"
+	"github.com/bornholm/escarmouche/pkg/sim"
 	"github.com/pkg/errors"
 )
 
 var (
-	populationSize = 100
-	mutationRate   = 0.1
-	maxGenerations = 1000
+	populationSize    = 100
+	mutationRate      = 0.1
+	maxGenerations    = 1000
+	archiveDir        = ""
+	boardWidth        = 0
+	boardHeight       = 0
+	squadBudget       = 0.0
+	scenarioRef       = ""
+	hitOn             = 0
+	rollDamage        = false
+	expectimax        = false
+	compareCombat     = false
+	moraleBreak       = 0.0
+	moralePassOn      = 0
+	compareMorale     = false
+	alternating       = false
+	compareActivation = false
 )
 
 func init() {
 	flag.IntVar(&populationSize, "population-size", populationSize, "population size")
 	flag.Float64Var(&mutationRate, "mutation-rate", mutationRate, "mutation rate")
 	flag.IntVar(&maxGenerations, "max-generations", maxGenerations, "maximum number of generations")
+	flag.StringVar(&archiveDir, "archive-dir", archiveDir, "directory where to archive the records of timed out games")
+	flag.IntVar(&boardWidth, "board-width", boardWidth, "board width, 0 for the published board")
+	flag.IntVar(&boardHeight, "board-height", boardHeight, "board height, 0 for the published board")
+	flag.Float64Var(&squadBudget, "squad-budget", squadBudget, "squad budget, 0 for the published budget")
+	flag.StringVar(&scenarioRef, "scenario", scenarioRef, "embedded scenario id or scenario file path, overrides the board flags")
+	flag.IntVar(&hitOn, "hit-on", hitOn, "minimum d6 roll for an attack to hit, 0 for attacks that always hit")
+	flag.BoolVar(&rollDamage, "roll-damage", rollDamage, "roll the damage of attacks that hit")
+	flag.BoolVar(&expectimax, "expectimax", expectimax, "let the AI weigh the outcomes of dice rolls")
+	flag.BoolVar(&compareCombat, "compare-combat", compareCombat, "compare the fitness of the default costs without and with dice, then exit")
+	flag.Float64Var(&moraleBreak, "morale-break", moraleBreak, "share of its starting cost a squad must lose before taking morale checks, 0 for no morale")
+	flag.IntVar(&moralePassOn, "morale-pass-on", moralePassOn, "minimum d6 roll to pass a morale check, 0 for 4+")
+	flag.BoolVar(&compareMorale, "compare-morale", compareMorale, "compare the fitness, timeout rate and game length of the default costs without and with morale, then exit")
+	flag.BoolVar(&alternating, "alternating", alternating, "let players alternate unit activations instead of spending a shared action budget")
+	flag.BoolVar(&compareActivation, "compare-activation", compareActivation, "compare the fitness, timeout rate and game length of the default costs under the published rule and with alternating activation, then exit")
 }
 
 func main() {
@@ -29,21 +59,129 @@ func main() {
 	fmt.Println("Escarmouche Balancing System")
 	fmt.Println("============================")
 
+	board := sim.NewBoardLayout(boardWidth, boardHeight)
+	if err := board.Validate(); err != nil {
+		log.Fatalf("Invalid board: %+v", errors.WithStack(err))
+	}
+
+	options := []balancing.EvaluatorOption{
+		balancing.WithPopulationSize(populationSize),
+		balancing.WithMutationRate(mutationRate),
+		balancing.WithMaxGenerations(maxGenerations),
+		balancing.WithArchiveDir(archiveDir),
+		balancing.WithBoardLayout(board),
+		balancing.WithSquadBudget(squadBudget),
+	}
+
+	if scenarioRef != "" {
+		sc, err := scenario.Resolve(scenarioRef)
+		if err != nil {
+			log.Fatalf("Invalid scenario: %+v", errors.WithStack(err))
+		}
+		board = sc.Layout()
+		options = append(options, balancing.WithScenario(sc))
+		fmt.Printf("Scenario: %s (%s)\n", sc.ID, sc.Label)
+	}
+
+	combat := sim.CombatRules{HitOn: hitOn, RollDamage: rollDamage}
+	if err := combat.Validate(); err != nil {
+		log.Fatalf("Invalid combat rules: %+v", errors.WithStack(err))
+	}
+
+	options = append(options, balancing.WithExpectimax(expectimax))
+	if combat.IsRandom() {
+		options = append(options, balancing.WithCombatRules(combat))
+		fmt.Printf("Combat: hit on %d+, roll damage: %v\n", combat.HitOn, combat.RollDamage)
+	}
+
+	morale := sim.MoraleRules{BreakPoint: moraleBreak, PassOn: moralePassOn}
+	if err := morale.Validate(); err != nil {
+		log.Fatalf("Invalid morale rules: %+v", errors.WithStack(err))
+	}
+
+	if morale.Enabled() {
+		options = append(options, balancing.WithMoraleRules(morale))
+		fmt.Printf("Morale: break at %.0f%% of the starting cost, pass on %d+\n", morale.BreakPoint*100, morale.Roll())
+	}
+
+	if alternating {
+		options = append(options, balancing.WithActionRules(sim.ActionRules{Alternating: true}))
+		fmt.Printf("Actions: alternating activation\n")
+	}
+
 	// Create context with timeout
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	if compareCombat {
+		if !combat.IsRandom() {
+			log.Fatalf("-compare-combat requires -hit-on or -roll-damage")
+		}
+
+		comparison, err := balancing.CompareCombatRules(ctx, core.DefaultCosts, combat, options...)
+		if err != nil {
+			log.Fatalf("Comparison failed: %+v", errors.WithStack(err))
+		}
+
+		fmt.Printf("Default costs fitness:\n")
+		fmt.Printf("  Without dice: %.4f\n", comparison.Deterministic)
+		fmt.Printf("  With dice:    %.4f\n", comparison.Random)
+		fmt.Printf("  Delta:        %+.4f\n", comparison.Delta())
+		return
+	}
+
+	if compareMorale {
+		if !morale.Enabled() {
+			log.Fatalf("-compare-morale requires -morale-break")
+		}
+
+		comparison, err := balancing.CompareMoraleRules(ctx, core.DefaultCosts, morale, options...)
+		if err != nil {
+			log.Fatalf("Comparison failed: %+v", errors.WithStack(err))
+		}
+
+		fmt.Printf("Default costs:\n")
+		fmt.Printf("                 fitness  timeouts  turns\n")
+		for _, row := range []struct {
+			label       string
+			measurement balancing.Measurement
+		}{
+			{"Without morale:", comparison.Without},
+			{"With morale:   ", comparison.With},
+		} {
+			fmt.Printf("  %s %.4f  %7.1f%%  %5.1f\n", row.label, row.measurement.Fitness, row.measurement.TimeoutRate*100, row.measurement.AverageTurns)
+		}
+		return
+	}
+
+	if compareActivation {
+		comparison, err := balancing.CompareActivation(ctx, core.DefaultCosts, options...)
+		if err != nil {
+			log.Fatalf("Comparison failed: %+v", errors.WithStack(err))
+		}
+
+		fmt.Printf("Default costs:\n")
+		fmt.Printf("                         fitness  timeouts  turns\n")
+		for _, row := range []struct {
+			label       string
+			measurement balancing.Measurement
+		}{
+			{"Published rule:        ", comparison.Published},
+			{"Alternating activation:", comparison.Alternating},
+		} {
+			fmt.Printf("  %s %.4f  %7.1f%%  %5.1f\n", row.label, row.measurement.Fitness, row.measurement.TimeoutRate*100, row.measurement.AverageTurns)
+		}
+		return
+	}
+
 	// Create evaluator with custom settings
-	evaluator := balancing.NewEvaluator(
-		balancing.WithPopulationSize(populationSize),
-		balancing.WithMutationRate(mutationRate),
-		balancing.WithMaxGenerations(maxGenerations),
-	)
+	evaluator := balancing.NewEvaluator(options...)
 
 	fmt.Printf("Starting with:\n")
 	fmt.Printf("- Population size: %d\n", populationSize)
 	fmt.Printf("- Mutation rate: %v%%\n", mutationRate*100)
 	fmt.Printf("- Max generations: %d\n", maxGenerations)
+	fmt.Printf("- Board: %dx%d\n", board.Width, board.Height)
 	fmt.Println()
 	fmt.Printf("Default costs for comparison:\n")
 	printCosts(core.DefaultCosts)
@@ -108,6 +246,7 @@ func printCosts(costs core.Costs) {
 	fmt.Printf("  RangeFactor:    %.3f (exponent: %.3f)\n", costs.RangeFactor, costs.RangeExponent)
 	fmt.Printf("  MoveFactor:     %.3f (exponent: %.3f)\n", costs.MoveFactor, costs.MoveExponent)
 	fmt.Printf("  PowerFactor:   %.3f (exponent: %.3f)\n", costs.PowerFactor, costs.PowerExponent)
+	fmt.Printf("  DefenseFactor:  %.3f (exponent: %.3f)\n", costs.DefenseFactor, costs.DefenseExponent)
 	fmt.Printf("  MaxTotal:       %.1f\n", costs.MaxTotal)
 }
 
@@ -126,6 +265,8 @@ func compareCosts(defaultCosts, optimizedCosts core.Costs) {
 		{"MoveExponent", defaultCosts.MoveExponent, optimizedCosts.MoveExponent},
 		{"PowerFactor", defaultCosts.PowerFactor, optimizedCosts.PowerFactor},
 		{"PowerExponent", defaultCosts.PowerExponent, optimizedCosts.PowerExponent},
+		{"DefenseFactor", defaultCosts.DefenseFactor, optimizedCosts.DefenseFactor},
+		{"DefenseExponent", defaultCosts.DefenseExponent, optimizedCosts.DefenseExponent},
 		{"MaxTotal", defaultCosts.MaxTotal, optimizedCosts.MaxTotal},
 	}
 
@@ -8,6 +8,7 @@ require (
 )
 
 require (
+	github.com/dop251/goja v0.0.0-20250630131328-58d95d85e994
 	github.com/goccy/go-yaml v1.18.0
 	github.com/pkg/errors v0.9.1
 	go.yaml.in/yaml/v3 v3.0.4
@@ -16,7 +17,6 @@ require (
 require (
 	github.com/cristalhq/istty v0.1.0 // indirect
 	github.com/dlclark/regexp2 v1.11.4 // indirect
-	github.com/dop251/goja v0.0.0-20250630131328-58d95d85e994 // indirect
 	github.com/go-sourcemap/sourcemap v2.1.3+incompatible // indirect
 	github.com/google/pprof v0.0.0-20230207041349-798e818bf904 // indirect
 	golang.org/x/text v0.3.8 // indirect
@@ -8,7 +8,7 @@ LANGUAGE=${LANGUAGE:-fr-FR}
 LATEST_VERSION=${LATEST_VERSION:-0.0.0}
 
 DATA="{ \"latestVersion\": \"${LATEST_VERSION}\", \"language\": \"${LANGUAGE}\", \"abilities\": [] }"
-ABILITIES=$(ls pkg/core/abilities/*)
+ABILITIES=$(ls pkg/core/abilities/*.yml)
 
 for ability in $ABILITIES; do
   ability_id=$(basename "$ability" .yml)
@@ -6,12 +6,14 @@ import (
 	"log"
 	"math"
 	"math/rand/v2"
+	"os"
 	"runtime"
 	"slices"
 	"sync"
 
 	"github.com/bornholm/escarmouche/pkg/core"
 	"github.com/bornholm/escarmouche/pkg/gen"
+	"github.com/bornholm/escarmouche/pkg/scenario"
 	"github.com/bornholm/escarmouche/pkg/sim"
 	"github.com/pkg/errors"
 )
@@ -43,6 +45,28 @@ type Evaluator struct {
 	tournamentSize       int
 	maxGenerations       int
 	convergenceThreshold float64
+	// archiveDir : répertoire où consigner le relevé des parties qui
+	// atteignent la limite de tours. Vide = pas d'archivage.
+	archiveDir string
+	// board / squadBudget : plateau et budget d'escouade des parties
+	// simulées. Valeur zéro = réglage publié.
+	board       sim.BoardLayout
+	squadBudget float64
+	// scenario : table de jeu des parties simulées (cf. WithScenario). nil =
+	// plateau board, règles publiées.
+	scenario *scenario.Scenario
+	// combat : règles de combat des parties simulées (cf. WithCombatRules).
+	// nil = celles du scénario, sans dés par défaut.
+	combat *sim.CombatRules
+	// morale : règle du moral des parties simulées (cf. WithMoraleRules).
+	// nil = celle du scénario, sans moral par défaut.
+	morale *sim.MoraleRules
+	// actions : économie d'actions des parties simulées (cf.
+	// WithActionRules). nil = celle du scénario, règle publiée par défaut.
+	actions *sim.ActionRules
+	// expectimax : l'IA des parties simulées raisonne sur les issues des
+	// jets de dés (cf. sim.ExpectimaxStrategy).
+	expectimax bool
 }
 
 // EvaluatorOption allows customization of the evaluator
@@ -69,6 +93,73 @@ func WithMaxGenerations(max int) EvaluatorOption {
 	}
 }
 
+// WithArchiveDir archive le relevé (cf. sim.GameRecord) de chaque partie
+// départagée par la limite de tours — celles que le fitness pénalise, et
+// qu'il est utile de rejouer pour comprendre l'attentisme.
+func WithArchiveDir(dir string) EvaluatorOption {
+	return func(e *Evaluator) {
+		e.archiveDir = dir
+	}
+}
+
+// WithBoardLayout fait jouer les tournois sur un autre plateau — un grand
+// plateau appelle en général un budget d'escouade plus élevé (cf.
+// WithSquadBudget).
+func WithBoardLayout(layout sim.BoardLayout) EvaluatorOption {
+	return func(e *Evaluator) {
+		e.board = layout
+	}
+}
+
+// WithScenario fait jouer les tournois sur un scénario : plateau, obstacles,
+// terrain, règles et limite de tours. Remplace WithBoardLayout.
+func WithScenario(s scenario.Scenario) EvaluatorOption {
+	return func(e *Evaluator) {
+		e.scenario = &s
+	}
+}
+
+// WithCombatRules fait tirer les attaques des parties simulées aux dés :
+// comparer les coûts obtenus avec et sans dés mesure ce que le hasard
+// change au modèle de coût (cf. CompareCombatRules).
+func WithCombatRules(rules sim.CombatRules) EvaluatorOption {
+	return func(e *Evaluator) {
+		e.combat = &rules
+	}
+}
+
+// WithMoraleRules fait jouer les parties simulées avec la règle du moral :
+// une escouade ébranlée peut fuir avant d'être éliminée, ce qui raccourcit
+// les parties ou les fait traîner (cf. CompareMoraleRules).
+func WithMoraleRules(rules sim.MoraleRules) EvaluatorOption {
+	return func(e *Evaluator) {
+		e.morale = &rules
+	}
+}
+
+// WithActionRules fait jouer les parties simulées avec une autre économie
+// d'actions, l'activation alternée par exemple (cf. CompareActivation).
+func WithActionRules(rules sim.ActionRules) EvaluatorOption {
+	return func(e *Evaluator) {
+		e.actions = &rules
+	}
+}
+
+// WithExpectimax fait jouer les parties simulées par une IA qui pèse les
+// issues des jets de dés plutôt que de supposer les dégâts moyens.
+func WithExpectimax(enabled bool) EvaluatorOption {
+	return func(e *Evaluator) {
+		e.expectimax = enabled
+	}
+}
+
+// WithSquadBudget change le budget des escouades générées pour les tournois.
+func WithSquadBudget(budget float64) EvaluatorOption {
+	return func(e *Evaluator) {
+		e.squadBudget = budget
+	}
+}
+
 func (e *Evaluator) Next(ctx context.Context) (*Stats, error) {
 	// Initialize population if this is the first generation
 	if e.generation == 0 {
@@ -113,14 +204,16 @@ func (e *Evaluator) randomCosts() core.Costs {
 	min := 0.5
 	max := 4 - min
 	return core.Costs{
-		HealthFactor:  min + rand.Float64()*max,
-		RangeFactor:   min + rand.Float64()*max,
-		RangeExponent: min + rand.Float64()*max,
-		MoveFactor:    min + rand.Float64()*max,
-		MoveExponent:  min + rand.Float64()*max,
-		PowerFactor:   min + rand.Float64()*max,
-		PowerExponent: min + rand.Float64()*max,
-		MaxTotal:      30,
+		HealthFactor:    min + rand.Float64()*max,
+		RangeFactor:     min + rand.Float64()*max,
+		RangeExponent:   min + rand.Float64()*max,
+		MoveFactor:      min + rand.Float64()*max,
+		MoveExponent:    min + rand.Float64()*max,
+		PowerFactor:     min + rand.Float64()*max,
+		PowerExponent:   min + rand.Float64()*max,
+		DefenseFactor:   min + rand.Float64()*max,
+		DefenseExponent: min + rand.Float64()*max,
+		MaxTotal:        30,
 	}
 }
 
@@ -151,6 +244,8 @@ type TournamentResult struct {
 	Fitness        float64
 	SquadResults   []SquadResult
 	SquadArchetype []string
+	// AverageTurns : durée moyenne des parties, en tours.
+	AverageTurns float64
 }
 
 // SquadResult contains statistics for a single squad in the tournament
@@ -175,6 +270,14 @@ type FitnessConfig struct {
 	// myope sous-évalue mobilité et capacités de tempo.
 	SearchDepth  int
 	SearchBudget int
+	// Board : plateau des parties simulées. Valeur zéro = plateau publié.
+	Board sim.BoardLayout
+	// GameOptions : réglages supplémentaires des parties simulées (obstacles,
+	// terrain, règles d'un scénario…). Board et MaxSimSteps priment.
+	GameOptions []sim.OptionFunc
+	// Expectimax : l'IA pèse les issues des jets de dés (cf.
+	// sim.ExpectimaxStrategy).
+	Expectimax bool
 }
 
 // DefaultFitnessConfig returns sensible default configuration
@@ -191,12 +294,125 @@ func DefaultFitnessConfig() FitnessConfig {
 
 // EvaluateCosts expose l'évaluation de fitness pour un jeu de coûts donné —
 // utile pour mesurer les coûts par défaut ou un candidat hors de la boucle
-// évolutionnaire.
-func EvaluateCosts(ctx context.Context, costs core.Costs) (float64, error) {
-	e := NewEvaluator()
+// évolutionnaire. Les options fixent la table de jeu (plateau, scénario,
+// règles de combat…).
+func EvaluateCosts(ctx context.Context, costs core.Costs, options ...EvaluatorOption) (float64, error) {
+	e := NewEvaluator(options...)
 	return e.evaluateFitness(ctx, costs)
 }
 
+// Measurement : fitness d'un jeu de coûts et allure des parties qui l'ont
+// mesurée.
+type Measurement struct {
+	Fitness float64
+	// TimeoutRate : part des parties départagées par la limite de tours.
+	TimeoutRate float64
+	// AverageTurns : durée moyenne des parties, en tours.
+	AverageTurns float64
+}
+
+// MeasureCosts mesure un jeu de coûts comme EvaluateCosts, avec le taux de
+// parties arrivées à la limite de tours et leur durée moyenne.
+func MeasureCosts(ctx context.Context, costs core.Costs, options ...EvaluatorOption) (*Measurement, error) {
+	e := NewEvaluator(options...)
+	return e.measure(ctx, costs)
+}
+
+// comparedConfig : une des tables de jeu d'une comparaison (cf.
+// compareConfigs).
+type comparedConfig struct {
+	// label nomme la table dans les erreurs.
+	label  string
+	option EvaluatorOption
+}
+
+// compareConfigs mesure le même jeu de coûts sur chaque table de jeu : les
+// options communes, complétées de celle de la table. Les mesures suivent
+// l'ordre des tables.
+func compareConfigs(ctx context.Context, costs core.Costs, options []EvaluatorOption, configs ...comparedConfig) ([]Measurement, error) {
+	measurements := make([]Measurement, 0, len(configs))
+	for _, config := range configs {
+		measurement, err := MeasureCosts(ctx, costs, append(slices.Clone(options), config.option)...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to evaluate %s", config.label)
+		}
+		measurements = append(measurements, *measurement)
+	}
+
+	return measurements, nil
+}
+
+// CombatComparison : fitness d'un même jeu de coûts sans dés et avec.
+type CombatComparison struct {
+	Deterministic float64
+	Random        float64
+}
+
+// Delta renvoie la variation de fitness due aux dés : négative, le hasard
+// déséquilibre des coûts réglés pour un combat sans dés.
+func (c CombatComparison) Delta() float64 {
+	return c.Random - c.Deterministic
+}
+
+// CompareCombatRules mesure le même jeu de coûts sans dés puis avec les
+// règles de combat données, sur la même table de jeu (cf. options).
+func CompareCombatRules(ctx context.Context, costs core.Costs, rules sim.CombatRules, options ...EvaluatorOption) (*CombatComparison, error) {
+	measurements, err := compareConfigs(ctx, costs, options,
+		comparedConfig{label: "deterministic combat", option: WithCombatRules(sim.CombatRules{})},
+		comparedConfig{label: "random combat", option: WithCombatRules(rules)},
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &CombatComparison{Deterministic: measurements[0].Fitness, Random: measurements[1].Fitness}, nil
+}
+
+// MoraleComparison : mesure d'un même jeu de coûts sans moral et avec.
+type MoraleComparison struct {
+	Without Measurement
+	With    Measurement
+}
+
+// CompareMoraleRules mesure le même jeu de coûts sans moral puis avec la
+// règle donnée, sur la même table de jeu (cf. options) : l'effet du moral
+// se lit sur la durée des parties et leur taux de limite de tours.
+func CompareMoraleRules(ctx context.Context, costs core.Costs, rules sim.MoraleRules, options ...EvaluatorOption) (*MoraleComparison, error) {
+	measurements, err := compareConfigs(ctx, costs, options,
+		comparedConfig{label: "games without morale", option: WithMoraleRules(sim.MoraleRules{})},
+		comparedConfig{label: "games with morale", option: WithMoraleRules(rules)},
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &MoraleComparison{Without: measurements[0], With: measurements[1]}, nil
+}
+
+// ActivationComparison : mesure d'un même jeu de coûts sous la règle
+// publiée et en activation alternée.
+type ActivationComparison struct {
+	Published   Measurement
+	Alternating Measurement
+}
+
+// CompareActivation mesure le même jeu de coûts sous la règle publiée puis
+// en activation alternée (cf. sim.ActionRules), sur la même table de jeu
+// (cf. options). Des coûts réglés pour le budget d'actions partagé
+// surpayent ou sous-payent ce qui change de valeur quand chaque unité agit
+// à son tour — la mobilité d'abord.
+func CompareActivation(ctx context.Context, costs core.Costs, options ...EvaluatorOption) (*ActivationComparison, error) {
+	measurements, err := compareConfigs(ctx, costs, options,
+		comparedConfig{label: "games under the published rule", option: WithActionRules(sim.ActionRules{})},
+		comparedConfig{label: "games with alternating activation", option: WithActionRules(sim.ActionRules{Alternating: true})},
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &ActivationComparison{Published: measurements[0], Alternating: measurements[1]}, nil
+}
+
 // evaluateFitness mesure la qualité d'équilibrage d'un jeu de coûts.
 //
 // Trois composantes :
@@ -210,24 +426,57 @@ func EvaluateCosts(ctx context.Context, costs core.Costs) (float64, error) {
 // Le score est moyenné sur plusieurs tournois indépendants pour amortir le
 // bruit d'échantillonnage.
 func (e *Evaluator) evaluateFitness(ctx context.Context, costs core.Costs) (float64, error) {
+	measurement, err := e.measure(ctx, costs)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return measurement.Fitness, nil
+}
+
+// measure joue les tournois de evaluateFitness et en relève aussi le taux
+// de limite de tours et la durée moyenne des parties.
+func (e *Evaluator) measure(ctx context.Context, costs core.Costs) (*Measurement, error) {
 	config := DefaultFitnessConfig()
+	config.Board = e.board
+	if e.scenario != nil {
+		config.Board = e.scenario.Layout()
+		config.GameOptions = e.scenario.Options()
+		if e.scenario.MaxTurns > 0 {
+			config.MaxSimSteps = int(e.scenario.MaxTurns)
+		}
+	}
+	if e.squadBudget > 0 {
+		config.SquadBudget = e.squadBudget
+	}
+	if e.combat != nil {
+		config.GameOptions = append(config.GameOptions, sim.WithCombatRules(*e.combat))
+	}
+	if e.morale != nil {
+		config.GameOptions = append(config.GameOptions, sim.WithMoraleRules(*e.morale))
+	}
+	if e.actions != nil {
+		config.GameOptions = append(config.GameOptions, sim.WithActionRules(*e.actions))
+	}
+	config.Expectimax = e.expectimax
+	// Les pertes et le départage se comptent au barème mesuré.
+	config.GameOptions = append(config.GameOptions, sim.WithCosts(costs))
 
-	total := 0.0
+	total := Measurement{}
 	for rep := 0; rep < config.Repetitions; rep++ {
 		select {
 		case <-ctx.Done():
-			return 0, ctx.Err()
+			return nil, ctx.Err()
 		default:
 		}
 
 		squads, labels, err := e.generateTournamentSquads(ctx, costs, config)
 		if err != nil {
-			return 0, errors.Wrap(err, "failed to generate tournament squads")
+			return nil, errors.Wrap(err, "failed to generate tournament squads")
 		}
 
 		result, err := e.runTournament(ctx, squads, labels, config)
 		if err != nil {
-			return 0, errors.Wrap(err, "failed to run tournament")
+			return nil, errors.Wrap(err, "failed to run tournament")
 		}
 
 		timeoutRate := 0.0
@@ -239,16 +488,23 @@ func (e *Evaluator) evaluateFitness(ctx context.Context, costs core.Costs) (floa
 		if fitness < 0 {
 			fitness = 0
 		}
-		total += fitness
+		total.Fitness += fitness
+		total.TimeoutRate += timeoutRate
+		total.AverageTurns += result.AverageTurns
 	}
 
-	fitness := total / float64(config.Repetitions)
+	repetitions := float64(config.Repetitions)
+	measurement := &Measurement{
+		Fitness:      total.Fitness / repetitions,
+		TimeoutRate:  total.TimeoutRate / repetitions,
+		AverageTurns: total.AverageTurns / repetitions,
+	}
 
 	if log.Default() != nil {
-		log.Printf("Fitness evaluation completed: fitness=%.6f", fitness)
+		log.Printf("Fitness evaluation completed: fitness=%.6f", measurement.Fitness)
 	}
 
-	return fitness, nil
+	return measurement, nil
 }
 
 // generateTournamentSquads compose le plateau du tournoi : une escouade
@@ -308,6 +564,7 @@ type gameResult struct {
 	winnerIndex int
 	completed   bool
 	timedOut    bool
+	turns       uint
 }
 
 // runTournament executes a round-robin tournament between all squads
@@ -350,6 +607,7 @@ func (e *Evaluator) runTournament(ctx context.Context, squads [][]sim.Unit, labe
 	wins := make([]int, numSquads)
 	totalGames := 0
 	timedOut := 0
+	turns := uint(0)
 
 	go func() {
 		wg.Wait()
@@ -360,6 +618,7 @@ func (e *Evaluator) runTournament(ctx context.Context, squads [][]sim.Unit, labe
 		if result.completed {
 			wins[result.winnerIndex]++
 			totalGames++
+			turns += result.turns
 			if result.timedOut {
 				timedOut++
 			}
@@ -407,6 +666,7 @@ func (e *Evaluator) runTournament(ctx context.Context, squads [][]sim.Unit, labe
 		WinShares:      winShares,
 		TotalGames:     int64(totalGames),
 		TimedOutGames:  int64(timedOut),
+		AverageTurns:   float64(turns) / float64(totalGames),
 		HHI:            hhi,
 		ArchetypeSkew:  skew,
 		SquadResults:   squadResults,
@@ -428,7 +688,7 @@ func (e *Evaluator) tournamentWorker(ctx context.Context, wg *sync.WaitGroup, sq
 		default:
 		}
 
-		winner, timedOut, err := e.runSingleGame(ctx, squads[job.squad1Index], squads[job.squad2Index], config)
+		winner, turns, timedOut, err := e.runSingleGame(ctx, squads[job.squad1Index], squads[job.squad2Index], config)
 		if err != nil {
 			// Log error but continue with tournament
 			if log.Default() != nil {
@@ -447,34 +707,73 @@ func (e *Evaluator) tournamentWorker(ctx context.Context, wg *sync.WaitGroup, sq
 			winnerIndex: winnerIndex,
 			completed:   true,
 			timedOut:    timedOut,
+			turns:       turns,
 		}
 	}
 }
 
 // runSingleGame executes a single simulation between two squads.
-// Le booléen retourné signale une partie départagée par la limite de tours :
-// c'est le symptôme d'attentisme que le fitness pénalise.
-func (e *Evaluator) runSingleGame(ctx context.Context, squad1, squad2 []sim.Unit, config FitnessConfig) (sim.PlayerID, bool, error) {
+// Elle renvoie aussi la durée de la partie, en tours. Le booléen retourné
+// signale une partie départagée par la limite de tours : c'est le symptôme
+// d'attentisme que le fitness pénalise.
+func (e *Evaluator) runSingleGame(ctx context.Context, squad1, squad2 []sim.Unit, config FitnessConfig) (sim.PlayerID, uint, bool, error) {
 	strategy := sim.SearchStrategy(config.SearchDepth, config.SearchBudget)
-	game := sim.NewGame(squad1, squad2,
+	if config.Expectimax {
+		strategy = sim.ExpectimaxStrategy(config.SearchDepth, config.SearchBudget)
+	}
+	opts := append(slices.Clone(config.GameOptions),
 		sim.WithPlayerStrategy(sim.PlayerOne, strategy),
 		sim.WithPlayerStrategy(sim.PlayerTwo, strategy),
 		sim.WithMaxTurns(uint(config.MaxSimSteps)),
+		sim.WithBoardLayout(config.Board),
 	)
+	game := sim.NewGame(squad1, squad2, opts...)
+	if err := game.Validate(); err != nil {
+		return -1, 0, false, errors.WithStack(err)
+	}
 
 	for step := range game.Run() {
 		select {
 		case <-ctx.Done():
-			return -1, false, ctx.Err()
+			return -1, 0, false, ctx.Err()
 		default:
 			if step.IsOver {
-				timedOut := step.Turn >= uint(config.MaxSimSteps)
-				return step.Winner, timedOut, nil
+				// Played plutôt que step.Turn : en activation alternée, un
+				// tour n'est qu'une activation (cf. sim.Game.Played).
+				turns := game.Played()
+				timedOut := turns >= uint(config.MaxSimSteps)
+				if timedOut {
+					if err := e.archiveGame(game); err != nil {
+						return -1, 0, false, errors.WithStack(err)
+					}
+				}
+				return step.Winner, turns, timedOut, nil
 			}
 		}
 	}
 
-	return sim.GetWinnerOnTimeout(game.State()), true, nil
+	return sim.GetWinnerOnTimeout(game.State()), uint(config.MaxSimSteps), true, nil
+}
+
+// archiveGame consigne le relevé de la partie dans archiveDir, s'il est
+// défini.
+func (e *Evaluator) archiveGame(game *sim.Game) error {
+	if e.archiveDir == "" {
+		return nil
+	}
+
+	file, err := os.CreateTemp(e.archiveDir, "timeout-*.json")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	defer file.Close()
+
+	if err := game.Record().Write(file); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
 }
 
 // calculateOptimalWorkers determines the optimal number of workers for the tournament
@@ -629,6 +928,12 @@ func (e *Evaluator) crossover(parent1, parent2 Individual) (Individual, Individu
 	if rand.Float64() < 0.5 {
 		child1.Costs.PowerExponent, child2.Costs.PowerExponent = child2.Costs.PowerExponent, child1.Costs.PowerExponent
 	}
+	if rand.Float64() < 0.5 {
+		child1.Costs.DefenseFactor, child2.Costs.DefenseFactor = child2.Costs.DefenseFactor, child1.Costs.DefenseFactor
+	}
+	if rand.Float64() < 0.5 {
+		child1.Costs.DefenseExponent, child2.Costs.DefenseExponent = child2.Costs.DefenseExponent, child1.Costs.DefenseExponent
+	}
 	return child1, child2
 }
 
@@ -671,6 +976,14 @@ func (e *Evaluator) mutate(individual Individual) Individual {
 		mutated.Costs.PowerExponent += (rand.Float64()-0.5) * 0.1 * adaptiveFactor
 		mutated.Costs.PowerExponent = math.Max(1.0, math.Min(2.0, mutated.Costs.PowerExponent))
 	}
+	if rand.Float64() < e.mutationRate {
+		mutated.Costs.DefenseFactor += (rand.Float64()-0.5) * 0.4 * adaptiveFactor
+		mutated.Costs.DefenseFactor = math.Max(0.1, math.Min(10.0, mutated.Costs.DefenseFactor))
+	}
+	if rand.Float64() < e.mutationRate {
+		mutated.Costs.DefenseExponent += (rand.Float64()-0.5) * 0.1 * adaptiveFactor
+		mutated.Costs.DefenseExponent = math.Max(1.0, math.Min(2.5, mutated.Costs.DefenseExponent))
+	}
 
 	return mutated
 }
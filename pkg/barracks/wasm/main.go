@@ -7,37 +7,28 @@ import (
 	"fmt"
 	"math/rand"
 	"slices"
+	"strings"
 	"sync"
 	"syscall/js"
 
 	"github.com/bornholm/escarmouche/pkg/core"
 	"github.com/bornholm/escarmouche/pkg/gen"
+	"github.com/bornholm/escarmouche/pkg/scenario"
 	"github.com/bornholm/escarmouche/pkg/sim"
 	"github.com/pkg/errors"
 )
 
 func main() {
-	// Zone de capture et emplacements d'obstacle valides, pour que le front
-	// n'ait pas à dupliquer la géométrie du plateau.
-	objectiveZone := make([]any, 0, len(sim.ObjectiveZone))
-	for _, pos := range sim.ObjectiveZone {
-		objectiveZone = append(objectiveZone, map[string]any{"x": pos.X, "y": pos.Y})
-	}
-
-	obstaclePositions := make([]any, 0)
-	for x := 0; x < sim.BoardSize; x++ {
-		for y := 0; y < sim.BoardSize; y++ {
-			if sim.IsValidObstaclePosition(sim.Position{X: x, Y: y}) {
-				obstaclePositions = append(obstaclePositions, map[string]any{"x": x, "y": y})
-			}
-		}
-	}
+	// Géométrie du plateau publié ; les états de partie et de déploiement
+	// portent celle du plateau effectivement joué.
+	defaultBoard := serializeBoard(sim.DefaultBoardLayout)
 
 	js.Global().Set("Barracks", map[string]any{
 		"evaluateUnit":           js.FuncOf(evaluateUnit),
 		"generateSquad":          js.FuncOf(generateSquad),
 		"generateUnit":           js.FuncOf(generateUnit),
 		"getAvailableAbilities":  js.FuncOf(getAvailableAbilities),
+		"getScenarios":           js.FuncOf(getScenarios),
 		"startDeployment":        js.FuncOf(startDeployment),
 		"deployUnit":             js.FuncOf(deployUnit),
 		"startGame":              js.FuncOf(startGame),
@@ -45,13 +36,15 @@ func main() {
 		"resumeGame":             js.FuncOf(resumeGame),
 		"getValidActions":        js.FuncOf(getValidActionsJS),
 		"selectAction":           js.FuncOf(selectAction),
+		"undoAction":             js.FuncOf(undoAction),
 		"endGame":                js.FuncOf(endGame),
+		"exportGame":             js.FuncOf(exportGame),
 		"MaxSquadSize":           js.ValueOf(gen.DefaultMaxSquadSize),
 		"SquadBudget":            js.ValueOf(gen.DefaultSquadBudget),
 		"MaxUnitCost":            js.ValueOf(core.DefaultCosts.MaxTotal),
 		"ControlPointsToWin":     js.ValueOf(sim.ControlPointsToWin),
-		"ObjectiveZone":          js.ValueOf(objectiveZone),
-		"ValidObstaclePositions": js.ValueOf(obstaclePositions),
+		"ObjectiveZone":          js.ValueOf(defaultBoard["objectiveZone"]),
+		"ValidObstaclePositions": js.ValueOf(defaultBoard["validObstaclePositions"]),
 	})
 
 	select {}
@@ -61,12 +54,7 @@ func main() {
 
 func evaluateUnit(this js.Value, args []js.Value) any {
 	return withPromise(func() (map[string]any, error) {
-		stats := core.Stats{
-			Health: args[0].Get("health").Int(),
-			Range:  args[0].Get("range").Int(),
-			Move:   args[0].Get("move").Int(),
-			Power:  args[0].Get("power").Int(),
-		}
+		stats := parseStats(args[0])
 
 		abilities := []core.Ability{}
 
@@ -78,7 +66,11 @@ func evaluateUnit(this js.Value, args []js.Value) any {
 				ids = append(ids, jsAbilities.Index(i).String())
 			}
 
-			abilities = core.Abilities(ids...)
+			selected, err := core.LookupAbilities(ids...)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			abilities = selected
 		}
 
 		evaluation, err := core.Evaluate(stats, abilities, core.DefaultCosts)
@@ -107,6 +99,7 @@ func generateSquad(this js.Value, args []js.Value) any {
 					"move":      u.Stats.Move,
 					"range":     u.Stats.Range,
 					"power":     u.Stats.Power,
+					"defense":   u.Stats.Defense,
 					"cost":      u.TotalCost,
 					"rank":      u.Rank.String(),
 					"archetype": u.Archetype.Name,
@@ -145,6 +138,7 @@ func generateUnit(this js.Value, args []js.Value) any {
 			"move":      unit.Stats.Move,
 			"range":     unit.Stats.Range,
 			"power":     unit.Stats.Power,
+			"defense":   unit.Stats.Defense,
 			"cost":      unit.TotalCost,
 			"rank":      unit.Rank.String(),
 			"archetype": unit.Archetype.Name,
@@ -200,6 +194,41 @@ func getAvailableAbilities(this js.Value, args []js.Value) any {
 	})
 }
 
+// getScenarios liste les scénarios embarqués, plateau compris, pour que le
+// front propose le choix de la table avant le déploiement. La Caserne oppose
+// le joueur à une seule IA : les scénarios à plus de deux joueurs n'y sont
+// pas proposés.
+func getScenarios(this js.Value, args []js.Value) any {
+	return withPromise(func() ([]any, error) {
+		language := core.Language(args[0].String())
+
+		core.SetLanguage(language)
+
+		scenarios := scenario.All()
+
+		jsScenarios := make([]any, 0, len(scenarios))
+
+		for _, sc := range scenarios {
+			if sc.Players() > 2 {
+				continue
+			}
+			jsScenarios = append(jsScenarios, map[string]any{
+				"id":          sc.ID,
+				"label":       sc.Label.String(),
+				"description": sc.Description.String(),
+				"board":       serializeBoard(sc.Layout()),
+				"obstacles":   serializePositions(sc.ObstaclePositions()),
+				"terrain":     serializeTerrain(sc.TerrainCells()),
+				"maxTurns":    int(sc.MaxTurns),
+				"mission":     sc.MissionName(),
+				"fogOfWar":    sc.FogOfWar,
+			})
+		}
+
+		return jsScenarios, nil
+	})
+}
+
 // ── Battle mode ─────────────────────────────────────────────────────────────
 
 type originalUnitData struct {
@@ -220,6 +249,11 @@ type gameSession struct {
 	validActions  []sim.Action
 	originalUnits map[sim.UnitID]originalUnitData
 	currentTurn   uint
+	// undoCh porte une demande de retour en arrière pendant que le joueur
+	// choisit son action ; takingBack la transmet à la boucle de jeu, seule
+	// habilitée à déplacer la partie dans son historique.
+	undoCh     chan struct{}
+	takingBack bool
 	// La partie est construite par startGame mais N'EST PAS lancée : le front
 	// doit d'abord afficher le plateau de départ. C'est beginBattle qui
 	// démarre la boucle. Sans cette séparation, une IA tirée en premier jouait
@@ -227,6 +261,8 @@ type gameSession struct {
 	// plateau déjà entamé et avait l'impression d'un coup d'avance volé.
 	run     func()
 	started bool
+	// game : partie en cours, conservée pour pouvoir en exporter le relevé.
+	game *sim.Game
 }
 
 var (
@@ -248,6 +284,16 @@ type deploymentSession struct {
 	playerPos []*sim.Position
 	aiPos     []sim.Position
 	obstacles map[string]bool
+	// board : plateau choisi pour la partie, repris par startGame.
+	board sim.BoardLayout
+	// scenario : scénario choisi, le cas échéant. Ses terrain, règles et
+	// limite de tours sont repris par startGame.
+	scenario *scenario.Scenario
+	// seed : graine de la partie. Elle tire l'obstacle de l'IA puis, reprise
+	// par startGame, tous les tirages de la partie : la même graine rejoue la
+	// même mise en place et la même partie.
+	seed int64
+	rand *rand.Rand
 }
 
 func (d *deploymentSession) placedCount() int {
@@ -275,7 +321,22 @@ func (d *deploymentSession) orderedPlayerPositions() []sim.Position {
 
 var currentDeployment *deploymentSession
 
-func parseUnits(jsUnits js.Value) ([]sim.Unit, []originalUnitData) {
+// parseStats lit les caractéristiques d'une unité du front. La Défense est
+// facultative : les unités enregistrées avant elle n'en ont pas.
+func parseStats(u js.Value) core.Stats {
+	stats := core.Stats{
+		Health: u.Get("health").Int(),
+		Range:  u.Get("range").Int(),
+		Move:   u.Get("move").Int(),
+		Power:  u.Get("power").Int(),
+	}
+	if defense := u.Get("defense"); defense.Type() == js.TypeNumber {
+		stats.Defense = defense.Int()
+	}
+	return stats
+}
+
+func parseUnits(jsUnits js.Value) ([]sim.Unit, []originalUnitData, error) {
 	n := jsUnits.Length()
 	units := make([]sim.Unit, 0, n)
 	originals := make([]originalUnitData, 0, n)
@@ -288,14 +349,13 @@ func parseUnits(jsUnits js.Value) ([]sim.Unit, []originalUnitData) {
 				abilityIDs = append(abilityIDs, jsAbs.Index(j).String())
 			}
 		}
+		abilities, err := core.LookupAbilities(abilityIDs...)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "unit %d", i)
+		}
 		units = append(units, sim.Unit{
-			Stats: core.Stats{
-				Health: u.Get("health").Int(),
-				Range:  u.Get("range").Int(),
-				Move:   u.Get("move").Int(),
-				Power:  u.Get("power").Int(),
-			},
-			Abilities: core.Abilities(abilityIDs...),
+			Stats:     parseStats(u),
+			Abilities: abilities,
 		})
 		originals = append(originals, originalUnitData{
 			Name:     u.Get("name").String(),
@@ -303,27 +363,24 @@ func parseUnits(jsUnits js.Value) ([]sim.Unit, []originalUnitData) {
 		})
 	}
 
-	return units, originals
+	return units, originals, nil
 }
 
 // suggestAIObstacle choisit l'emplacement d'obstacle de l'IA : une case valide
 // de sa moitié de plateau, proche de l'axe central pour gêner l'approche
 // adverse, et distincte de celle du joueur.
-func suggestAIObstacle(taken map[string]bool) (sim.Position, bool) {
+func suggestAIObstacle(r *rand.Rand, board sim.BoardLayout, taken map[string]bool) (sim.Position, bool) {
 	candidates := make([]sim.Position, 0)
-	for x := 0; x < sim.BoardSize; x++ {
-		for y := sim.BoardSize / 2; y < sim.BoardSize; y++ {
-			pos := sim.Position{X: x, Y: y}
-			if !sim.IsValidObstaclePosition(pos) || taken[pos.String()] {
-				continue
-			}
-			candidates = append(candidates, pos)
+	for _, pos := range board.ObstaclePositions() {
+		if pos.Y < board.Height/2 || taken[pos.String()] {
+			continue
 		}
+		candidates = append(candidates, pos)
 	}
 	if len(candidates) == 0 {
 		return sim.Position{}, false
 	}
-	return candidates[rand.Intn(len(candidates))], true
+	return candidates[r.Intn(len(candidates))], true
 }
 
 func serializeDeployment() map[string]any {
@@ -348,8 +405,8 @@ func serializeDeployment() map[string]any {
 	}
 
 	obstacles := make([]any, 0, len(d.obstacles))
-	for x := 0; x < sim.BoardSize; x++ {
-		for y := 0; y < sim.BoardSize; y++ {
+	for x := 0; x < d.board.Width; x++ {
+		for y := 0; y < d.board.Height; y++ {
 			pos := sim.Position{X: x, Y: y}
 			if d.obstacles[pos.String()] {
 				obstacles = append(obstacles, map[string]any{"x": x, "y": y})
@@ -357,7 +414,18 @@ func serializeDeployment() map[string]any {
 		}
 	}
 
+	terrain := []any{}
+	scenarioID := ""
+	if d.scenario != nil {
+		terrain = serializeTerrain(d.scenario.TerrainCells())
+		scenarioID = d.scenario.ID
+	}
+
 	return map[string]any{
+		"board":           serializeBoard(d.board),
+		"scenario":        scenarioID,
+		"seed":            d.seed,
+		"terrain":         terrain,
 		"playerPositions": playerSlots,
 		"aiPositions":     toJS(d.aiPos),
 		"obstacles":       obstacles,
@@ -368,20 +436,62 @@ func serializeDeployment() map[string]any {
 	}
 }
 
-// startDeployment ouvre la phase de placement : unités des deux camps et
-// obstacles déjà posés.
+// startDeployment ouvre la phase de placement : unités des deux camps,
+// obstacles déjà posés et, en option, le plateau — un scénario embarqué
+// ({scenario: id}) ou de simples dimensions ({width, height}) — et la graine
+// de la partie ({seed}), tirée au hasard à défaut.
 func startDeployment(this js.Value, args []js.Value) any {
 	return withPromise(func() (map[string]any, error) {
-		playerUnits, _ := parseUnits(args[0])
-		aiUnits, _ := parseUnits(args[1])
+		playerUnits, _, err := parseUnits(args[0])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		aiUnits, _, err := parseUnits(args[1])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		board := sim.DefaultBoardLayout
+		var selected *scenario.Scenario
+		seed := rand.Int63()
+		if len(args) > 3 && args[3].Type() == js.TypeObject {
+			if jsSeed := args[3].Get("seed"); jsSeed.Type() == js.TypeNumber {
+				seed = int64(jsSeed.Int())
+			}
+			if id := args[3].Get("scenario"); id.Type() == js.TypeString {
+				sc, exists := scenario.Get(id.String())
+				if !exists {
+					return nil, errors.Errorf("unknown scenario '%s'", id.String())
+				}
+				if sc.Players() > 2 {
+					return nil, errors.Errorf("scenario '%s' needs %d players", sc.ID, sc.Players())
+				}
+				selected = &sc
+				board = sc.Layout()
+			} else {
+				board = sim.NewBoardLayout(args[3].Get("width").Int(), args[3].Get("height").Int())
+				if err := board.Validate(); err != nil {
+					return nil, errors.WithStack(err)
+				}
+			}
+		}
 
+		// Un scénario qui déclare ses obstacles fixe la table : ni le joueur
+		// ni l'IA n'en posent.
 		obstacles := map[string]bool{}
-		if len(args) > 2 && args[2].Truthy() {
+		fixedObstacles := selected != nil && len(selected.Obstacles) > 0
+		if fixedObstacles {
+			for _, pos := range selected.ObstaclePositions() {
+				obstacles[pos.String()] = true
+			}
+		}
+
+		if !fixedObstacles && len(args) > 2 && args[2].Truthy() {
 			jsObs := args[2]
 			for i := 0; i < jsObs.Length(); i++ {
 				o := jsObs.Index(i)
 				pos := sim.Position{X: o.Get("x").Int(), Y: o.Get("y").Int()}
-				if sim.IsValidObstaclePosition(pos) {
+				if board.IsValidObstaclePosition(pos) {
 					obstacles[pos.String()] = true
 				}
 			}
@@ -391,9 +501,11 @@ func startDeployment(this js.Value, args []js.Value) any {
 		// veulent les règles — et non au lancement de la bataille. Sans cela le
 		// joueur déployait ses unités sans voir un obstacle qui existait déjà,
 		// et le découvrait au premier tour.
-		aiObstacle, ok := suggestAIObstacle(obstacles)
-		if ok {
-			obstacles[aiObstacle.String()] = true
+		r := rand.New(rand.NewSource(seed))
+		if !fixedObstacles {
+			if aiObstacle, ok := suggestAIObstacle(r, board, obstacles); ok {
+				obstacles[aiObstacle.String()] = true
+			}
 		}
 
 		currentDeployment = &deploymentSession{
@@ -402,6 +514,10 @@ func startDeployment(this js.Value, args []js.Value) any {
 			playerPos:   make([]*sim.Position, len(playerUnits)),
 			aiPos:       []sim.Position{},
 			obstacles:   obstacles,
+			board:       board,
+			scenario:    selected,
+			seed:        seed,
+			rand:        r,
 		}
 
 		return serializeDeployment(), nil
@@ -429,7 +545,7 @@ func deployUnit(this js.Value, args []js.Value) any {
 		}
 
 		pos := sim.Position{X: args[1].Int(), Y: args[2].Int()}
-		if !sim.IsValidDeploymentPosition(sim.PlayerOne, pos, d.obstacles) {
+		if !d.board.IsValidDeploymentPosition(sim.PlayerOne, pos, d.obstacles) {
 			return nil, errors.New("invalid deployment position")
 		}
 
@@ -461,7 +577,7 @@ func deployUnit(this js.Value, args []js.Value) any {
 			}
 
 			next := d.aiUnits[len(d.aiPos)]
-			if aiPos, ok := sim.SuggestDeployment(next, sim.PlayerTwo, occupied, d.obstacles, enemies); ok {
+			if aiPos, ok := d.board.SuggestDeployment(next, sim.PlayerTwo, occupied, d.obstacles, enemies); ok {
 				d.aiPos = append(d.aiPos, aiPos)
 			}
 		}
@@ -482,6 +598,7 @@ func startGame(this js.Value, args []js.Value) any {
 			actionCh:       make(chan int),
 			doneCh:         make(chan struct{}),
 			resumeCh:       make(chan struct{}),
+			undoCh:         make(chan struct{}),
 			originalUnits:  map[sim.UnitID]originalUnitData{},
 		}
 		currentSession = session
@@ -493,21 +610,20 @@ func startGame(this js.Value, args []js.Value) any {
 		playerUnits := make([]sim.Unit, 0, n)
 		for i := 0; i < n; i++ {
 			u := jsUnits.Index(i)
-			stats := core.Stats{
-				Health: u.Get("health").Int(),
-				Range:  u.Get("range").Int(),
-				Move:   u.Get("move").Int(),
-				Power:  u.Get("power").Int(),
-			}
+			stats := parseStats(u)
 			abilityIDs := []string{}
 			if jsAbs := u.Get("abilities"); jsAbs.Truthy() {
 				for j := 0; j < jsAbs.Length(); j++ {
 					abilityIDs = append(abilityIDs, jsAbs.Index(j).String())
 				}
 			}
+			abilities, err := core.LookupAbilities(abilityIDs...)
+			if err != nil {
+				return nil, errors.Wrapf(err, "unit %d", i)
+			}
 			playerUnits = append(playerUnits, sim.Unit{
 				Stats:     stats,
-				Abilities: core.Abilities(abilityIDs...),
+				Abilities: abilities,
 			})
 			session.originalUnits[sim.UnitID(i)] = originalUnitData{
 				Name:     u.Get("name").String(),
@@ -520,7 +636,10 @@ func startGame(this js.Value, args []js.Value) any {
 		// on retombe sur une génération aléatoire.
 		var aiUnits []sim.Unit
 		if len(args) > 4 && args[4].Truthy() && args[4].Length() > 0 {
-			parsed, originals := parseUnits(args[4])
+			parsed, originals, err := parseUnits(args[4])
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
 			aiUnits = parsed
 			for i, orig := range originals {
 				session.originalUnits[sim.UnitID(len(playerUnits)+i)] = orig
@@ -532,7 +651,7 @@ func startGame(this js.Value, args []js.Value) any {
 			}
 			for _, u := range aiSquad {
 				aiUnits = append(aiUnits, sim.Unit{
-					Stats:     core.Stats{Health: u.Stats.Health, Range: u.Stats.Range, Move: u.Stats.Move, Power: u.Stats.Power},
+					Stats:     u.Stats,
 					Abilities: u.Abilities,
 				})
 			}
@@ -560,12 +679,18 @@ func startGame(this js.Value, args []js.Value) any {
 			aiBudget = aiBudget * 2 / 5
 		}
 
+		// Le plateau a été choisi à l'ouverture du déploiement.
+		board := sim.DefaultBoardLayout
+		if currentDeployment != nil {
+			board = currentDeployment.board
+		}
+
 		// Obstacle posé par le joueur pendant la mise en place ; l'IA pose le
 		// sien sur sa moitié de plateau, en biais devant la zone centrale.
 		obstacles := []sim.Position{}
 		if len(args) > 2 && args[2].Type() == js.TypeObject {
 			pos := sim.Position{X: args[2].Get("x").Int(), Y: args[2].Get("y").Int()}
-			if sim.IsValidObstaclePosition(pos) {
+			if board.IsValidObstaclePosition(pos) {
 				obstacles = append(obstacles, pos)
 			}
 		}
@@ -573,8 +698,8 @@ func startGame(this js.Value, args []js.Value) any {
 		// l'IA) : on les reprend tels quels, sans en générer de nouveaux.
 		if currentDeployment != nil && len(currentDeployment.obstacles) > 0 {
 			obstacles = obstacles[:0]
-			for x := 0; x < sim.BoardSize; x++ {
-				for y := 0; y < sim.BoardSize; y++ {
+			for x := 0; x < board.Width; x++ {
+				for y := 0; y < board.Height; y++ {
 					pos := sim.Position{X: x, Y: y}
 					if currentDeployment.obstacles[pos.String()] {
 						obstacles = append(obstacles, pos)
@@ -611,15 +736,39 @@ func startGame(this js.Value, args []js.Value) any {
 					return validActions[idx]
 				}
 				return nil
+			case <-session.undoCh:
+				session.takingBack = true
+				return nil
 			case <-session.doneCh:
 				return nil
 			}
 		})
 
-		gameOptions := []sim.OptionFunc{
+		// Le scénario fournit terrain, règles et limite de tours ; plateau et
+		// obstacles, fixés pendant la mise en place, sont repris ci-dessous.
+		gameOptions := []sim.OptionFunc{}
+		if currentDeployment != nil && currentDeployment.scenario != nil {
+			gameOptions = append(gameOptions, currentDeployment.scenario.Options()...)
+		}
+
+		gameOptions = append(gameOptions,
 			sim.WithPlayerStrategy(sim.PlayerOne, humanStrategy),
-			sim.WithPlayerStrategy(sim.PlayerTwo, sim.SearchStrategy(aiDepth, aiBudget)),
+			// Les réactions du joueur se jouent d'elles-mêmes : il les a
+			// préparées en posant le statut, la partie ne l'interrompt pas
+			// pendant le tour de l'IA.
+			sim.WithPlayerReaction(sim.PlayerOne, sim.AlwaysReact),
+			// Sans dés, ExpectimaxStrategy joue comme SearchStrategy ; avec
+			// (scénario aux dés), elle pèse chaque issue des attaques. Au
+			// brouillard de guerre, BeliefStrategy lui rend les unités du
+			// joueur qu'elle ne voit plus ; sans, elle ne change rien.
+			sim.WithPlayerStrategy(sim.PlayerTwo, sim.BeliefStrategy(sim.ExpectimaxStrategy(aiDepth, aiBudget))),
 			sim.WithObstacles(obstacles...),
+			sim.WithBoardLayout(board),
+		)
+
+		// Les tirages de la partie prolongent ceux de la mise en place.
+		if currentDeployment != nil {
+			gameOptions = append(gameOptions, sim.WithRand(currentDeployment.rand))
 		}
 
 		// Positions décidées pendant la phase de déploiement alterné.
@@ -633,6 +782,10 @@ func startGame(this js.Value, args []js.Value) any {
 		}
 
 		game := sim.NewGame(playerUnits, aiUnits, gameOptions...)
+		if err := game.Validate(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		session.game = game
 
 		session.run = func() {
 			for step := range game.Run() {
@@ -644,21 +797,38 @@ func startGame(this js.Value, args []js.Value) any {
 				default:
 				}
 
+				// Le joueur reprend son coup : la partie revient avant sa
+				// dernière action et lui redonne la main, sans rien rejouer.
+				if session.takingBack {
+					session.takingBack = false
+					takeBack(game, session.humanPlayerID)
+					session.currentTurn = game.Turn()
+					recentSteps = nil
+					continue
+				}
+
 				// On capture l'action AVANT le test de fin de partie : le coup
 				// fatal doit être rejouable, sinon la partie se termine sur un
-				// plateau qui a sauté.
+				// plateau qui a sauté. Au brouillard de guerre, le joueur ne
+				// voit de l'action de l'IA que ce que sa vue en montre : un
+				// coup joué et resté hors de vue n'est pas rejoué.
+				view := game.View(session.humanPlayerID)
 				if step.Action != nil {
-					desc := describeAction(-1, step.Action, session)
-					desc["playerID"] = int(step.Player)
-					desc["frame"] = serializeFrame(game.State())
-					recentSteps = append(recentSteps, desc)
+					events := view.VisibleEvents(step.Events)
+					if actor, ok := actingUnit(step.Action); !ok || !view.IsHidden(actor) || len(events) > 0 {
+						desc := describeAction(-1, step.Action, session)
+						desc["playerID"] = int(step.Player)
+						desc["frame"] = serializeFrame(view)
+						desc["events"] = serializeEvents(events)
+						recentSteps = append(recentSteps, desc)
+					}
 				}
 
 				// Action du joueur : on rend la main IMMÉDIATEMENT pour qu'elle
 				// s'anime, avant d'engager la réflexion de l'IA. La partie
 				// reprend quand le front signale la fin de l'animation.
 				if step.Action != nil && step.Player == session.humanPlayerID && !step.IsOver {
-					jsState := serializeState(game.State(), nil, session, false, -1, int(step.Turn), recentSteps)
+					jsState := serializeState(view, nil, session, false, -1, int(step.Turn), recentSteps)
 					jsState["awaitingResume"] = true
 					jsState["started"] = true
 					recentSteps = nil
@@ -676,6 +846,7 @@ func startGame(this js.Value, args []js.Value) any {
 					}
 				}
 
+				// La partie finie, tout le plateau se dévoile.
 				if step.IsOver {
 					jsState := serializeState(game.State(), nil, session, true, int(step.Winner), int(step.Turn), recentSteps)
 					select {
@@ -689,7 +860,7 @@ func startGame(this js.Value, args []js.Value) any {
 
 		// On rend le plateau de DÉPART, avant le moindre coup : le joueur voit
 		// son déploiement, sait qui ouvre la partie, puis appelle beginBattle.
-		initial := serializeState(game.State(), nil, session, false, -1, 0, nil)
+		initial := serializeState(game.View(session.humanPlayerID), nil, session, false, -1, 0, nil)
 		initial["started"] = false
 		return initial, nil
 	})
@@ -797,6 +968,46 @@ func resumeGame(this js.Value, args []js.Value) any {
 	})
 }
 
+// undoAction reprend la dernière action du joueur — et avec elle la réponse
+// de l'IA. La promesse rend l'état où le joueur choisit de nouveau.
+func undoAction(this js.Value, args []js.Value) any {
+	return withPromise(func() (map[string]any, error) {
+		sessionMu.Lock()
+		session := currentSession
+		sessionMu.Unlock()
+
+		if session == nil {
+			return nil, errors.New("no active game session")
+		}
+
+		select {
+		case session.undoCh <- struct{}{}:
+		case <-session.doneCh:
+			return nil, errors.New("game ended")
+		}
+
+		select {
+		case nextState := <-session.pendingStateCh:
+			nextState["started"] = true
+			return nextState, nil
+		case <-session.doneCh:
+			return nil, errors.New("game ended")
+		}
+	})
+}
+
+// takeBack ramène la partie juste avant la dernière action jouée par le
+// joueur donné. La demande de retour en arrière, enregistrée comme un tour
+// passé, est annulée au passage.
+func takeBack(game *sim.Game, playerID sim.PlayerID) {
+	history := game.History()
+	for game.Undo() {
+		if action := history[game.Step()]; action.Player == playerID && action.Type != "" {
+			return
+		}
+	}
+}
+
 func endGame(this js.Value, args []js.Value) any {
 	sessionMu.Lock()
 	defer sessionMu.Unlock()
@@ -808,12 +1019,33 @@ func endGame(this js.Value, args []js.Value) any {
 	return nil
 }
 
+// exportGame rend le relevé JSON de la partie en cours (cf. sim.GameRecord),
+// à joindre à un rapport de bogue : sim.Replay la rejoue à l'identique.
+func exportGame(this js.Value, args []js.Value) any {
+	return withPromise(func() (string, error) {
+		sessionMu.Lock()
+		session := currentSession
+		sessionMu.Unlock()
+
+		if session == nil || session.game == nil {
+			return "", errors.New("no active game session")
+		}
+
+		var buff strings.Builder
+		if err := session.game.Record().Write(&buff); err != nil {
+			return "", errors.WithStack(err)
+		}
+
+		return buff.String(), nil
+	})
+}
+
 // ── Serialization ────────────────────────────────────────────────────────────
 
 func serializeState(state sim.GameState, validActions []sim.Action, session *gameSession, isOver bool, winner int, turn int, recentSteps []map[string]any) map[string]any {
-	units := make([]any, 0, len(state.Units))
-	for _, unit := range state.Units {
-		pos := state.Positions[unit.ID]
+	units := make([]any, 0, state.UnitCount())
+	for unit := range state.Units() {
+		pos := state.PositionOf(unit.ID)
 		health := state.Get(unit.ID, sim.CounterHealth, 0)
 
 		orig := session.originalUnits[unit.ID]
@@ -832,6 +1064,7 @@ func serializeState(state sim.GameState, validActions []sim.Action, session *gam
 			"maxHealth":    unit.Stats.Health,
 			"range":        unit.Stats.Range,
 			"power":        unit.Stats.Power,
+			"defense":      unit.Stats.Defense,
 			"move":         unit.Stats.Move,
 			"abilities":    abilities,
 			"x":            pos.X,
@@ -842,6 +1075,11 @@ func serializeState(state sim.GameState, validActions []sim.Action, session *gam
 				state.Get(unit.ID, sim.CounterOverchargeLock, 0) > 0,
 			"defensiveStance": state.Get(unit.ID, sim.CounterDefensiveStance, 0) > 0,
 			"guardianOf":      state.Get(unit.ID, sim.CounterGuardianOf, -1),
+			"overwatch":       state.Get(unit.ID, sim.CounterOverwatch, 0) > 0,
+			"counterStrike":   state.Get(unit.ID, sim.CounterCounterStrike, 0) > 0,
+			"leader":          state.Get(unit.ID, sim.CounterLeader, 0) > 0,
+			"escort":          state.Get(unit.ID, sim.CounterEscort, 0) > 0,
+			"routed":          state.Get(unit.ID, sim.CounterRouted, 0) > 0,
 		})
 	}
 
@@ -855,16 +1093,31 @@ func serializeState(state sim.GameState, validActions []sim.Action, session *gam
 		recentActionsAny = append(recentActionsAny, a)
 	}
 
-	obstacles := make([]any, 0, len(state.Obstacles))
-	for x := 0; x < sim.BoardSize; x++ {
-		for y := 0; y < sim.BoardSize; y++ {
+	obstacles := make([]any, 0)
+	for x := 0; x < state.Layout.Width; x++ {
+		for y := 0; y < state.Layout.Height; y++ {
 			pos := sim.Position{X: x, Y: y}
-			if state.Obstacles[pos.String()] {
+			if state.HasObstacle(pos) {
 				obstacles = append(obstacles, map[string]any{"x": x, "y": y})
 			}
 		}
 	}
 
+	terrain := make([]any, 0)
+	for x := 0; x < state.Layout.Width; x++ {
+		for y := 0; y < state.Layout.Height; y++ {
+			pos := sim.Position{X: x, Y: y}
+			if t := state.TerrainAt(pos); t != sim.TerrainOpen {
+				terrain = append(terrain, map[string]any{"x": x, "y": y, "type": string(t)})
+			}
+		}
+	}
+
+	mission := "capture"
+	if state.Victory != nil {
+		mission = state.Victory.Name()
+	}
+
 	return map[string]any{
 		"units":           units,
 		"currentPlayerID": int(state.CurrentPlayerID),
@@ -876,22 +1129,84 @@ func serializeState(state sim.GameState, validActions []sim.Action, session *gam
 		"validActions":    validActionsJS,
 		"recentActions":   recentActionsAny,
 		"obstacles":       obstacles,
+		"terrain":         terrain,
+		"board":           serializeBoard(state.Layout),
 		"controlPoints": map[string]any{
 			"player": state.ControlPoints[session.humanPlayerID],
 			"ai":     state.ControlPoints[getOpponent(session.humanPlayerID)],
 		},
+		"mission": mission,
+		// Points de victoire : coût des unités tuées chez l'adversaire.
+		"victoryPoints": map[string]any{
+			"player": state.Losses[getOpponent(session.humanPlayerID)],
+			"ai":     state.Losses[session.humanPlayerID],
+		},
 	}
 }
 
+// serializeBoard décrit la géométrie du plateau — dimensions, zone de
+// capture, emplacements d'obstacle valides — pour que le front n'ait pas à la
+// dupliquer.
+func serializeBoard(board sim.BoardLayout) map[string]any {
+	toJS := func(positions []sim.Position) []any {
+		out := make([]any, 0, len(positions))
+		for _, p := range positions {
+			out = append(out, map[string]any{"x": p.X, "y": p.Y})
+		}
+		return out
+	}
+
+	rows := func(playerID sim.PlayerID) []any {
+		out := make([]any, 0)
+		for _, y := range board.DeploymentRows(playerID) {
+			out = append(out, y)
+		}
+		return out
+	}
+
+	return map[string]any{
+		"width":                  board.Width,
+		"height":                 board.Height,
+		"objectiveZone":          toJS(board.ObjectivePositions()),
+		"validObstaclePositions": toJS(board.ObstaclePositions()),
+		"deploymentRows": map[string]any{
+			"player": rows(sim.PlayerOne),
+			"ai":     rows(sim.PlayerTwo),
+		},
+		"deploymentPositions": map[string]any{
+			"player": toJS(board.DeploymentPositions(sim.PlayerOne)),
+			"ai":     toJS(board.DeploymentPositions(sim.PlayerTwo)),
+		},
+	}
+}
+
+// serializePositions convertit une liste de cases pour le front.
+func serializePositions(positions []sim.Position) []any {
+	out := make([]any, 0, len(positions))
+	for _, p := range positions {
+		out = append(out, map[string]any{"x": p.X, "y": p.Y})
+	}
+	return out
+}
+
+// serializeTerrain convertit des cases typées, au format de serializeState.
+func serializeTerrain(cells []sim.TerrainCell) []any {
+	out := make([]any, 0, len(cells))
+	for _, c := range cells {
+		out = append(out, map[string]any{"x": c.Position.X, "y": c.Position.Y, "type": string(c.Type)})
+	}
+	return out
+}
+
 // serializeFrame produit un instantané léger du plateau : juste ce qu'il faut
 // pour rejouer une action à l'écran (positions, santé, statuts). Les actions
 // valides et les métadonnées de partie en sont volontairement absentes — une
 // image de rejeu n'est pas un état jouable.
 func serializeFrame(state sim.GameState) map[string]any {
-	units := make([]any, 0, len(state.Units))
+	units := make([]any, 0, state.UnitCount())
 
-	for _, unit := range state.Units {
-		pos := state.Positions[unit.ID]
+	for unit := range state.Units() {
+		pos := state.PositionOf(unit.ID)
 		units = append(units, map[string]any{
 			"id":           int(unit.ID),
 			"x":            pos.X,
@@ -903,6 +1218,11 @@ func serializeFrame(state sim.GameState) map[string]any {
 				state.Get(unit.ID, sim.CounterOverchargeLock, 0) > 0,
 			"defensiveStance": state.Get(unit.ID, sim.CounterDefensiveStance, 0) > 0,
 			"guardianOf":      state.Get(unit.ID, sim.CounterGuardianOf, -1),
+			"overwatch":       state.Get(unit.ID, sim.CounterOverwatch, 0) > 0,
+			"counterStrike":   state.Get(unit.ID, sim.CounterCounterStrike, 0) > 0,
+			"leader":          state.Get(unit.ID, sim.CounterLeader, 0) > 0,
+			"escort":          state.Get(unit.ID, sim.CounterEscort, 0) > 0,
+			"routed":          state.Get(unit.ID, sim.CounterRouted, 0) > 0,
 		})
 	}
 
@@ -915,6 +1235,68 @@ func serializeFrame(state sim.GameState) map[string]any {
 	}
 }
 
+// serializeEvents décrit les événements d'un pas de jeu : ce qu'a réellement
+// produit l'action (dégâts absorbés ou redirigés, éliminations, statuts…),
+// que l'image seule ne dit pas.
+func serializeEvents(events []sim.Event) []any {
+	serialized := make([]any, 0, len(events))
+	for _, event := range events {
+		desc := map[string]any{"type": string(event.Type())}
+		switch e := event.(type) {
+		case sim.TurnStarted:
+			desc["playerID"] = int(e.Player)
+			desc["actionsLeft"] = e.ActionsLeft
+		case sim.TurnEnded:
+			desc["playerID"] = int(e.Player)
+		case sim.UnitMoved:
+			desc["unitID"] = int(e.Unit)
+			desc["fromX"], desc["fromY"] = e.From.X, e.From.Y
+			desc["toX"], desc["toY"] = e.To.X, e.To.Y
+		case sim.DamageDealt:
+			desc["unitID"] = int(e.Target)
+			desc["redirectedFrom"] = int(e.RedirectedFrom)
+			desc["damage"] = e.Damage
+			desc["armor"] = e.Armor
+			desc["absorbed"] = e.Absorbed
+			desc["dealt"] = e.Dealt
+			desc["health"] = e.Health
+		case sim.UnitKilled:
+			desc["unitID"] = int(e.Unit)
+			desc["playerID"] = int(e.Owner)
+			desc["x"], desc["y"] = e.Position.X, e.Position.Y
+		case sim.StatusApplied:
+			desc["unitID"] = int(e.Unit)
+			desc["status"] = e.Status
+			desc["value"] = e.Value
+		case sim.StatusExpired:
+			desc["unitID"] = int(e.Unit)
+			desc["status"] = e.Status
+		case sim.ControlPointScored:
+			desc["playerID"] = int(e.Player)
+			desc["total"] = e.Total
+		case sim.AttackRolled:
+			desc["unitID"] = int(e.Attacker)
+			desc["targetUnitID"] = int(e.Target)
+			desc["nominal"] = e.Nominal
+			desc["damage"] = e.Damage
+		case sim.UnitReacted:
+			desc["unitID"] = int(e.Unit)
+			desc["status"] = e.Status
+			desc["triggerUnitID"] = int(e.Trigger)
+		case sim.ControlPointStolen:
+			desc["playerID"] = int(e.Player)
+			desc["byPlayerID"] = int(e.By)
+			desc["total"] = e.Total
+		case sim.MoraleChecked:
+			desc["unitID"] = int(e.Unit)
+			desc["roll"] = e.Roll
+			desc["outcome"] = string(e.Outcome)
+		}
+		serialized = append(serialized, desc)
+	}
+	return serialized
+}
+
 func describeAction(index int, action sim.Action, session *gameSession) map[string]any {
 	desc := map[string]any{
 		"index":        index,
@@ -952,6 +1334,17 @@ func describeAction(index int, action sim.Action, session *gameSession) map[stri
 			desc["targetUnitID"] = int(d.TargetUnitID)
 			desc["targetX"] = d.TargetX
 			desc["targetY"] = d.TargetY
+			if len(d.Choices) > 0 {
+				choices := make([]any, 0, len(d.Choices))
+				for _, choice := range d.Choices {
+					choices = append(choices, map[string]any{
+						"unitID": int(choice.UnitID),
+						"x":      choice.X,
+						"y":      choice.Y,
+					})
+				}
+				desc["choices"] = choices
+			}
 			srcName := unitName(d.SourceUnitID, session)
 			desc["label"] = fmt.Sprintf("%s : %s", srcName, a.ID())
 		}
@@ -960,6 +1353,21 @@ func describeAction(index int, action sim.Action, session *gameSession) map[stri
 	return desc
 }
 
+// actingUnit renvoie l'unité qui joue l'action.
+func actingUnit(action sim.Action) (sim.UnitID, bool) {
+	switch a := action.(type) {
+	case *sim.MoveAction:
+		return a.UnitID(), true
+	case *sim.AttackAction:
+		return a.UnitID(), true
+	case *sim.AbilityAction:
+		if d := a.Description(); d != nil {
+			return d.SourceUnitID, true
+		}
+	}
+	return -1, false
+}
+
 func getOpponent(playerID sim.PlayerID) sim.PlayerID {
 	if playerID == sim.PlayerOne {
 		return sim.PlayerTwo
@@ -0,0 +1,143 @@
+package campaign
+
+import (
+	"math"
+	"slices"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+	"github.com/pkg/errors"
+)
+
+/* =============================================================================
+   Avancement.
+
+   L'expérience s'achète au barème de coût du jeu : un point d'expérience
+   vaut un point de coût. Le prix d'un avancement est ce qu'il ajoute au
+   coût de l'unité selon core.Evaluate — recalculé sur l'unité telle qu'elle
+   est, blessures comprises : le troisième cran de puissance coûte plus que
+   le premier, comme au recrutement.
+
+   Une unité ne dépasse jamais le plafond de coût du barème
+   (core.Costs.MaxTotal) : un vétéran reste une unité qu'on aurait pu
+   recruter.
+   ========================================================================== */
+
+type Stat string
+
+const (
+	StatHealth  Stat = "health"
+	StatRange   Stat = "range"
+	StatMove    Stat = "move"
+	StatPower   Stat = "power"
+	StatDefense Stat = "defense"
+)
+
+var Stats = []Stat{StatHealth, StatRange, StatMove, StatPower, StatDefense}
+
+// of renvoie la caractéristique dans les stats données.
+func (s Stat) of(stats *core.Stats) *int {
+	switch s {
+	case StatHealth:
+		return &stats.Health
+	case StatRange:
+		return &stats.Range
+	case StatMove:
+		return &stats.Move
+	case StatPower:
+		return &stats.Power
+	case StatDefense:
+		return &stats.Defense
+	default:
+		return nil
+	}
+}
+
+func ParseStat(str string) (Stat, error) {
+	for _, s := range Stats {
+		if string(s) == str {
+			return s, nil
+		}
+	}
+	return "", errors.Errorf("unknown stat '%s'", str)
+}
+
+// Advance : un avancement, soit un point de caractéristique, soit une
+// capacité du catalogue.
+type Advance struct {
+	Stat    Stat
+	Ability string
+}
+
+// Price renvoie le prix en expérience de l'avancement pour l'unité.
+func (v *Veteran) Price(advance Advance) (int, error) {
+	stats, abilities := v.Stats, v.Abilities
+
+	switch {
+	case advance.Stat != "" && advance.Ability != "":
+		return 0, errors.New("an advance buys either a stat or an ability")
+	case advance.Stat != "":
+		value := advance.Stat.of(&stats)
+		if value == nil {
+			return 0, errors.Errorf("unknown stat '%s'", advance.Stat)
+		}
+		*value++
+	case advance.Ability != "":
+		if slices.Contains(abilities, advance.Ability) {
+			return 0, errors.Errorf("unit '%s' already has ability '%s'", v.Name, advance.Ability)
+		}
+		abilities = append(slices.Clone(abilities), advance.Ability)
+	default:
+		return 0, errors.New("empty advance")
+	}
+
+	current, err := v.evaluate(v.Stats, v.Abilities)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	next, err := v.evaluate(stats, abilities)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if next.Cost > core.DefaultCosts.MaxTotal {
+		return 0, errors.Errorf("unit '%s' would cost %.0f, over the %.0f cap", v.Name, next.Cost, core.DefaultCosts.MaxTotal)
+	}
+
+	return int(math.Max(next.Cost-current.Cost, 0)), nil
+}
+
+// Advance achète un avancement pour une unité avec son expérience et
+// renvoie son prix.
+func (c *Campaign) Advance(warband string, unit string, advance Advance) (int, error) {
+	w, err := c.Warband(warband)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	v, err := w.Veteran(unit)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if v.Dead {
+		return 0, errors.Errorf("unit '%s' is dead", v.Name)
+	}
+
+	price, err := v.Price(advance)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if price > v.Experience {
+		return 0, errors.Errorf("unit '%s' has %d experience, advance costs %d", v.Name, v.Experience, price)
+	}
+
+	if advance.Stat != "" {
+		*advance.Stat.of(&v.Stats)++
+	} else {
+		v.Abilities = append(v.Abilities, advance.Ability)
+	}
+	v.Experience -= price
+
+	if err := v.refresh(); err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	return price, nil
+}
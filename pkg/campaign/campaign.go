@@ -0,0 +1,374 @@
+package campaign
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+	"github.com/bornholm/escarmouche/pkg/sim"
+	"github.com/pkg/errors"
+)
+
+/* =============================================================================
+   Campagne.
+
+   Une partie de sim part toujours d'unités neuves. La campagne tient le
+   registre des bandes d'un groupe de joueurs d'une partie à l'autre :
+   chaque unité y garde ses caractéristiques, ses capacités, son expérience
+   et ses blessures.
+
+   Le déroulé d'une partie de campagne :
+     - Muster lève les bandes qui s'affrontent et rend leurs escouades, à
+       passer à sim.NewMultiplayerGame (une bande par joueur, dans l'ordre) ;
+     - la partie se joue, on en garde les GameStep ;
+     - Debrief en tire le bilan (éliminations, survie, tenue des zones de
+       capture), distribue l'expérience et jette les blessures des unités
+       tombées (cf. debrief.go) ;
+     - entre deux parties, Advance dépense l'expérience d'une unité en
+       caractéristiques ou en capacités (cf. advance.go).
+
+   La campagne s'enregistre en JSON (cf. Save). Les jets de blessure sont
+   tirés d'une graine et d'un compteur enregistrés avec elle : une campagne
+   rechargée tire les mêmes jets qu'une campagne restée en mémoire.
+   ========================================================================== */
+
+type Campaign struct {
+	Name string `json:"name"`
+	// Seed : graine des jets de blessure (cf. dice).
+	Seed int64 `json:"seed"`
+	// Rolls : nombre de jets déjà tirés.
+	Rolls    uint64     `json:"rolls"`
+	Rules    Rules      `json:"rules"`
+	Warbands []*Warband `json:"warbands"`
+	// Games : bilan de chaque partie jouée, dans l'ordre (cf. Debrief).
+	Games []*Report `json:"games"`
+}
+
+// Rules : barème d'expérience de la campagne.
+type Rules struct {
+	// Survival : expérience d'une unité encore debout en fin de partie.
+	Survival int `json:"survival"`
+	// Kill : expérience par unité ennemie éliminée.
+	Kill int `json:"kill"`
+	// Hold : expérience par fin de tour passée dans une zone de capture que
+	// son joueur a marquée.
+	Hold int `json:"hold"`
+}
+
+// DefaultRules : une élimination vaut deux parties de survie. Un point
+// d'expérience s'échange contre un point de coût (cf. Advance) : une partie
+// bien menée paie un cran de santé.
+var DefaultRules = Rules{
+	Survival: 1,
+	Kill:     2,
+	Hold:     1,
+}
+
+type Warband struct {
+	Name  string     `json:"name"`
+	Units []*Veteran `json:"units"`
+}
+
+// Veteran : unité de campagne.
+type Veteran struct {
+	Name      string     `json:"name"`
+	Stats     core.Stats `json:"stats"`
+	Abilities []string   `json:"abilities"`
+	// Cost et Rank : coût et rang de l'unité selon core.Evaluate,
+	// recalculés à chaque changement.
+	Cost float64 `json:"cost"`
+	Rank string  `json:"rank"`
+	// Experience : expérience à dépenser ; Earned : expérience gagnée depuis
+	// l'enrôlement.
+	Experience int `json:"experience"`
+	Earned     int `json:"earned"`
+	Games      int `json:"games"`
+	Kills      int `json:"kills"`
+	Holds      int `json:"holds"`
+	// Injuries : blessures reçues, dans l'ordre.
+	Injuries []Injury `json:"injuries,omitempty"`
+	// Recovering : l'unité manque la prochaine partie de sa bande.
+	Recovering bool `json:"recovering,omitempty"`
+	// Dead : l'unité est tombée pour de bon. Elle reste au registre, pour
+	// l'histoire.
+	Dead bool `json:"dead,omitempty"`
+}
+
+type OptionFunc func(c *Campaign)
+
+// WithRules remplace le barème d'expérience.
+func WithRules(rules Rules) OptionFunc {
+	return func(c *Campaign) {
+		c.Rules = rules
+	}
+}
+
+func New(name string, seed int64, funcs ...OptionFunc) *Campaign {
+	c := &Campaign{
+		Name:     name,
+		Seed:     seed,
+		Rules:    DefaultRules,
+		Warbands: make([]*Warband, 0),
+		Games:    make([]*Report, 0),
+	}
+	for _, fn := range funcs {
+		fn(c)
+	}
+	return c
+}
+
+// Warband renvoie la bande du nom donné.
+func (c *Campaign) Warband(name string) (*Warband, error) {
+	for _, w := range c.Warbands {
+		if w.Name == name {
+			return w, nil
+		}
+	}
+	return nil, errors.Errorf("could not find warband '%s'", name)
+}
+
+// Veteran renvoie l'unité du nom donné.
+func (w *Warband) Veteran(name string) (*Veteran, error) {
+	for _, v := range w.Units {
+		if v.Name == name {
+			return v, nil
+		}
+	}
+	return nil, errors.Errorf("could not find unit '%s' in warband '%s'", name, w.Name)
+}
+
+// Recruit enrôle une unité dans une bande, créée au besoin.
+func (c *Campaign) Recruit(warband string, name string, stats core.Stats, abilities ...string) (*Veteran, error) {
+	if warband == "" || name == "" {
+		return nil, errors.New("a unit needs a warband and a name")
+	}
+
+	w, err := c.Warband(warband)
+	if err != nil {
+		w = &Warband{Name: warband, Units: make([]*Veteran, 0)}
+		c.Warbands = append(c.Warbands, w)
+	}
+	if _, err := w.Veteran(name); err == nil {
+		return nil, errors.Errorf("warband '%s' already has a unit named '%s'", warband, name)
+	}
+
+	veteran := &Veteran{
+		Name:      name,
+		Stats:     stats,
+		Abilities: append([]string{}, abilities...),
+	}
+	if err := veteran.validate(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := veteran.refresh(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if veteran.Cost > core.DefaultCosts.MaxTotal {
+		return nil, errors.Errorf("unit '%s' costs %.0f, over the %.0f cap", name, veteran.Cost, core.DefaultCosts.MaxTotal)
+	}
+
+	w.Units = append(w.Units, veteran)
+
+	return veteran, nil
+}
+
+// Fit indique si l'unité peut prendre part à la prochaine partie.
+func (v *Veteran) Fit() bool {
+	return !v.Dead && !v.Recovering
+}
+
+// Unit renvoie l'unité de jeu du vétéran.
+func (v *Veteran) Unit() (sim.Unit, error) {
+	abilities, err := core.LookupAbilities(v.Abilities...)
+	if err != nil {
+		return sim.Unit{}, errors.Wrapf(err, "unit '%s'", v.Name)
+	}
+	return sim.Unit{Stats: v.Stats, Abilities: abilities}, nil
+}
+
+// validate vérifie les caractéristiques et les capacités de l'unité.
+func (v *Veteran) validate() error {
+	for _, value := range []int{v.Stats.Health, v.Stats.Range, v.Stats.Move, v.Stats.Power} {
+		if value < 1 {
+			return errors.Errorf("unit '%s': stats must be at least 1, got %+v", v.Name, v.Stats)
+		}
+	}
+	if v.Stats.Defense < 0 {
+		return errors.Errorf("unit '%s': defense must be at least 0, got %d", v.Name, v.Stats.Defense)
+	}
+	for i, id := range v.Abilities {
+		if slices.Contains(v.Abilities[:i], id) {
+			return errors.Errorf("unit '%s' has ability '%s' twice", v.Name, id)
+		}
+	}
+	if _, err := core.LookupAbilities(v.Abilities...); err != nil {
+		return errors.Wrapf(err, "unit '%s'", v.Name)
+	}
+	return nil
+}
+
+// refresh recalcule coût et rang de l'unité.
+func (v *Veteran) refresh() error {
+	evaluation, err := v.evaluate(v.Stats, v.Abilities)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	v.Cost, v.Rank = evaluation.Cost, evaluation.Rank.String()
+	return nil
+}
+
+func (v *Veteran) evaluate(stats core.Stats, ids []string) (*core.Evaluation, error) {
+	abilities, err := core.LookupAbilities(ids...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unit '%s'", v.Name)
+	}
+	evaluation, err := core.Evaluate(stats, abilities, core.DefaultCosts)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return evaluation, nil
+}
+
+// Side : bande engagée dans une partie. Units nomme les unités alignées,
+// dans l'ordre ; vide, la bande aligne toutes ses unités valides (cf. Fit).
+type Side struct {
+	Warband string
+	Units   []string
+}
+
+// Battle : bandes levées pour une partie, une par joueur (cf. Muster).
+type Battle struct {
+	// Warbands : bande de chaque joueur, indexée par PlayerID.
+	Warbands []string
+	// Veterans : unités alignées par chaque joueur, dans l'ordre de son
+	// escouade.
+	Veterans [][]*Veteran
+	squads   [][]sim.Unit
+}
+
+// Squads renvoie les escouades de la partie, à passer à
+// sim.NewMultiplayerGame.
+func (b *Battle) Squads() [][]sim.Unit {
+	return b.squads
+}
+
+// Muster lève les bandes d'une partie : le premier camp joue PlayerOne, le
+// second PlayerTwo, etc.
+func (c *Campaign) Muster(sides ...Side) (*Battle, error) {
+	if len(sides) < 2 || len(sides) > sim.MaxPlayers {
+		return nil, errors.Errorf("a battle needs 2 to %d warbands, got %d", sim.MaxPlayers, len(sides))
+	}
+
+	battle := &Battle{
+		Warbands: make([]string, 0, len(sides)),
+		Veterans: make([][]*Veteran, 0, len(sides)),
+		squads:   make([][]sim.Unit, 0, len(sides)),
+	}
+
+	for _, side := range sides {
+		if slices.Contains(battle.Warbands, side.Warband) {
+			return nil, errors.Errorf("warband '%s' cannot fight itself", side.Warband)
+		}
+
+		w, err := c.Warband(side.Warband)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		veterans := make([]*Veteran, 0)
+		if len(side.Units) == 0 {
+			for _, v := range w.Units {
+				if v.Fit() {
+					veterans = append(veterans, v)
+				}
+			}
+		}
+		for _, name := range side.Units {
+			v, err := w.Veteran(name)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			if slices.Contains(veterans, v) {
+				return nil, errors.Errorf("unit '%s' of warband '%s' is mustered twice", name, w.Name)
+			}
+			if !v.Fit() {
+				return nil, errors.Errorf("unit '%s' of warband '%s' cannot fight", name, w.Name)
+			}
+			veterans = append(veterans, v)
+		}
+		if len(veterans) == 0 {
+			return nil, errors.Errorf("warband '%s' has no unit fit to fight", w.Name)
+		}
+
+		squad := make([]sim.Unit, 0, len(veterans))
+		for _, v := range veterans {
+			unit, err := v.Unit()
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			squad = append(squad, unit)
+		}
+
+		battle.Warbands = append(battle.Warbands, w.Name)
+		battle.Veterans = append(battle.Veterans, veterans)
+		battle.squads = append(battle.squads, squad)
+	}
+
+	return battle, nil
+}
+
+// Write sérialise la campagne en JSON.
+func (c *Campaign) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(c); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// Read lit une campagne produite par Campaign.Write.
+func Read(r io.Reader) (*Campaign, error) {
+	c := &Campaign{}
+	if err := json.NewDecoder(r).Decode(c); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, w := range c.Warbands {
+		for _, v := range w.Units {
+			if err := v.validate(); err != nil {
+				return nil, errors.Wrapf(err, "warband '%s'", w.Name)
+			}
+		}
+	}
+	return c, nil
+}
+
+// Save enregistre la campagne dans un fichier.
+func (c *Campaign) Save(path string) error {
+	var buff bytes.Buffer
+	if err := c.Write(&buff); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.WriteFile(path, buff.Bytes(), 0o644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// Load lit une campagne enregistrée par Save.
+func Load(path string) (*Campaign, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	c, err := Read(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "campaign '%s'", path)
+	}
+	return c, nil
+}
@@ -0,0 +1,356 @@
+package campaign
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+	"github.com/bornholm/escarmouche/pkg/sim"
+)
+
+func newTestCampaign(t *testing.T) *Campaign {
+	c := New("Les Marches", 7)
+
+	recruits := []struct {
+		Warband, Name string
+		Stats         core.Stats
+		Abilities     []string
+	}{
+		{"Loups", "Brise-Fer", core.Stats{Health: 3, Range: 1, Move: 2, Power: 2}, nil},
+		{"Loups", "Fine-Lame", core.Stats{Health: 2, Range: 3, Move: 1, Power: 2}, nil},
+		{"Loups", "Vieux-Roc", core.Stats{Health: 4, Range: 1, Move: 1, Power: 1}, []string{"00002-defensive-stance"}},
+		{"Corbeaux", "Bec-Noir", core.Stats{Health: 3, Range: 2, Move: 2, Power: 1}, nil},
+		{"Corbeaux", "Plume", core.Stats{Health: 2, Range: 3, Move: 1, Power: 2}, nil},
+		{"Corbeaux", "Serre", core.Stats{Health: 3, Range: 1, Move: 2, Power: 2}, nil},
+	}
+	for _, r := range recruits {
+		if _, err := c.Recruit(r.Warband, r.Name, r.Stats, r.Abilities...); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+
+	return c
+}
+
+func playBattle(t *testing.T, c *Campaign, seed int64, funcs ...sim.OptionFunc) (*Report, *sim.Game, []sim.GameStep) {
+	battle, err := c.Muster(Side{Warband: "Loups"}, Side{Warband: "Corbeaux"})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	game := sim.NewMultiplayerGame(battle.Squads(), append([]sim.OptionFunc{
+		sim.WithSeed(seed),
+		sim.WithBoardLayout(sim.BoardLayout{Width: 8, Height: 8}),
+		sim.WithPlayerStrategy(sim.PlayerOne, sim.SearchStrategy(2, 300)),
+		sim.WithPlayerStrategy(sim.PlayerTwo, sim.SearchStrategy(2, 300)),
+		sim.WithMaxTurns(40),
+	}, funcs...)...)
+
+	steps := make([]sim.GameStep, 0)
+	for step := range game.Run() {
+		steps = append(steps, step)
+	}
+
+	report, err := c.Debrief(battle, game, steps)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	return report, game, steps
+}
+
+func TestDebrief(t *testing.T) {
+	c := newTestCampaign(t)
+
+	for seed := range int64(3) {
+		before := map[string]Veteran{}
+		for _, w := range c.Warbands {
+			for _, v := range w.Units {
+				before[w.Name+"/"+v.Name] = *v
+			}
+		}
+
+		report, game, steps := playBattle(t, c, seed)
+
+		if e, g := int(seed)+1, report.Game; e != g {
+			t.Errorf("report.Game: expected %d, got %d", e, g)
+		}
+
+		// Une attaque qui tue sa cible revient toujours à l'attaquant.
+		kills, attackKills, scored := 0, 0, 0
+		for _, step := range steps {
+			attack, _ := step.Action.(*sim.AttackAction)
+			for _, event := range step.Events {
+				switch e := event.(type) {
+				case sim.UnitKilled:
+					kills++
+					if attack != nil && attack.TargetID() == e.Unit {
+						attackKills++
+					}
+				case sim.ControlPointScored:
+					scored++
+				}
+			}
+		}
+
+		credited, fallen, holds := 0, 0, 0
+		for _, unit := range report.Units {
+			credited += unit.Kills
+			holds += unit.Holds
+			if !unit.Survived {
+				fallen++
+				if unit.Injury == nil {
+					t.Errorf("game %d: %s fell without an injury roll", report.Game, unit.Unit)
+				}
+			} else if unit.Injury != nil {
+				t.Errorf("game %d: %s survived but was injured", report.Game, unit.Unit)
+			}
+
+			experience := c.Rules.Kill*unit.Kills + c.Rules.Hold*unit.Holds
+			if unit.Survived {
+				experience += c.Rules.Survival
+			}
+			if e, g := experience, unit.Experience; e != g {
+				t.Errorf("game %d: %s: experience: expected %d, got %d", report.Game, unit.Unit, e, g)
+			}
+
+			w, _ := c.Warband(unit.Warband)
+			v, _ := w.Veteran(unit.Unit)
+			previous := before[unit.Warband+"/"+unit.Unit]
+			if e, g := previous.Experience+unit.Experience, v.Experience; e != g {
+				t.Errorf("game %d: %s: banked experience: expected %d, got %d", report.Game, unit.Unit, e, g)
+			}
+			if e, g := previous.Games+1, v.Games; e != g {
+				t.Errorf("game %d: %s: games: expected %d, got %d", report.Game, unit.Unit, e, g)
+			}
+		}
+
+		if e, g := kills, fallen; e != g {
+			t.Errorf("game %d: fallen units: expected %d, got %d", report.Game, e, g)
+		}
+		if credited < attackKills || credited > kills {
+			t.Errorf("game %d: credited kills: expected %d to %d, got %d", report.Game, attackKills, kills, credited)
+		}
+		if scored > 0 && holds == 0 {
+			t.Errorf("game %d: %d control points scored but no unit held a zone", report.Game, scored)
+		}
+
+		if winner := game.Record().Result.Winner; winner >= 0 {
+			if e, g := []string{report.Warbands[winner]}, report.Winners; !reflect.DeepEqual(e, g) {
+				t.Errorf("game %d: winners: expected %v, got %v", report.Game, e, g)
+			}
+		}
+	}
+
+	// Les morts et les convalescents ne sont pas levés.
+	battle, err := c.Muster(Side{Warband: "Loups"}, Side{Warband: "Corbeaux"})
+	if err == nil {
+		for _, veterans := range battle.Veterans {
+			for _, v := range veterans {
+				if !v.Fit() {
+					t.Errorf("%s is mustered but cannot fight", v.Name)
+				}
+			}
+		}
+	}
+
+	if e, g := 3, len(c.Games); e != g {
+		t.Errorf("len(Games): expected %d, got %d", e, g)
+	}
+}
+
+// En activation alternée, la zone est marquée en fin de manche, après la
+// fin de tour du dernier joueur activé : la tenue doit tout de même compter.
+func TestDebriefAlternating(t *testing.T) {
+	c := newTestCampaign(t)
+
+	pass := sim.StrategyFunc(func(sim.GameState, sim.PlayerID) sim.Action { return nil })
+
+	// Deux Loups tiennent la zone centrale ; personne ne bouge, et la partie
+	// s'arrête après une manche.
+	report, _, steps := playBattle(t, c, 1,
+		sim.WithAlternatingActivation(),
+		sim.WithDeployment(map[sim.PlayerID][]sim.Position{
+			sim.PlayerOne: {{X: 3, Y: 3}, {X: 4, Y: 4}, {X: 0, Y: 0}},
+			sim.PlayerTwo: {{X: 0, Y: 7}, {X: 3, Y: 7}, {X: 7, Y: 7}},
+		}),
+		sim.WithObstacles(sim.Position{X: 7, Y: 0}, sim.Position{X: 7, Y: 3}),
+		sim.WithPlayerStrategy(sim.PlayerOne, pass),
+		sim.WithPlayerStrategy(sim.PlayerTwo, pass),
+		sim.WithMaxTurns(2),
+	)
+
+	scored := 0
+	for _, step := range steps {
+		for _, event := range step.Events {
+			if _, ok := event.(sim.ControlPointScored); ok {
+				scored++
+			}
+		}
+	}
+	if e, g := 1, scored; e != g {
+		t.Fatalf("control points scored: expected %d, got %d", e, g)
+	}
+
+	holds := map[string]int{}
+	for _, unit := range report.Units {
+		if unit.Holds > 0 {
+			holds[unit.Unit] = unit.Holds
+		}
+	}
+	if e, g := (map[string]int{"Brise-Fer": 1, "Fine-Lame": 1}), holds; !reflect.DeepEqual(e, g) {
+		t.Errorf("holds: expected %v, got %v", e, g)
+	}
+}
+
+func TestMuster(t *testing.T) {
+	c := newTestCampaign(t)
+
+	battle, err := c.Muster(Side{Warband: "Loups", Units: []string{"Vieux-Roc", "Brise-Fer"}}, Side{Warband: "Corbeaux"})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if e, g := 2, len(battle.Squads()[0]); e != g {
+		t.Fatalf("len(squad): expected %d, got %d", e, g)
+	}
+	if e, g := 4, battle.Squads()[0][0].Stats.Health; e != g {
+		t.Errorf("first unit health: expected %d, got %d", e, g)
+	}
+
+	w, _ := c.Warband("Loups")
+	v, _ := w.Veteran("Fine-Lame")
+	v.Recovering = true
+
+	if _, err := c.Muster(Side{Warband: "Loups", Units: []string{"Fine-Lame"}}, Side{Warband: "Corbeaux"}); err == nil {
+		t.Error("expected a recovering unit to be kept out")
+	}
+	if _, err := c.Muster(Side{Warband: "Loups"}, Side{Warband: "Loups"}); err == nil {
+		t.Error("expected a warband fighting itself to be rejected")
+	}
+	if _, err := c.Muster(Side{Warband: "Loups"}); err == nil {
+		t.Error("expected a single warband to be rejected")
+	}
+
+	// Fine-Lame manque la partie : elle est de nouveau valide ensuite.
+	report, _, _ := playBattle(t, c, 1)
+	for _, unit := range report.Units {
+		if unit.Unit == "Fine-Lame" {
+			t.Fatal("expected Fine-Lame to sit the game out")
+		}
+	}
+	if v.Recovering {
+		t.Error("expected Fine-Lame to have recovered")
+	}
+}
+
+func TestAdvance(t *testing.T) {
+	c := New("Avancement", 1)
+
+	v, err := c.Recruit("Loups", "Brise-Fer", core.Stats{Health: 2, Range: 1, Move: 1, Power: 2})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	v.Experience = 12
+
+	type testCase struct {
+		Advance Advance
+		Stats   core.Stats
+		Ability []string
+	}
+
+	testCases := []testCase{
+		{Advance: Advance{Stat: StatPower}, Stats: core.Stats{Health: 2, Range: 1, Move: 1, Power: 3}},
+		{Advance: Advance{Stat: StatHealth}, Stats: core.Stats{Health: 3, Range: 1, Move: 1, Power: 3}},
+		{Advance: Advance{Ability: "00000-charge"}, Stats: core.Stats{Health: 3, Range: 1, Move: 1, Power: 3}, Ability: []string{"00000-charge"}},
+		{Advance: Advance{Stat: StatDefense}, Stats: core.Stats{Health: 3, Range: 1, Move: 1, Power: 3, Defense: 1}, Ability: []string{"00000-charge"}},
+	}
+
+	for _, tc := range testCases {
+		previous, err := core.Evaluate(v.Stats, core.Abilities(v.Abilities...), core.DefaultCosts)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		next, err := core.Evaluate(tc.Stats, core.Abilities(tc.Ability...), core.DefaultCosts)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+
+		experience := v.Experience
+		price, err := c.Advance("Loups", "Brise-Fer", tc.Advance)
+		if err != nil {
+			t.Fatalf("%+v: %+v", tc.Advance, err)
+		}
+
+		if e, g := int(next.Cost-previous.Cost), price; e != g {
+			t.Errorf("%+v: price: expected %d, got %d", tc.Advance, e, g)
+		}
+		if e, g := experience-price, v.Experience; e != g {
+			t.Errorf("%+v: experience: expected %d, got %d", tc.Advance, e, g)
+		}
+		if e, g := tc.Stats, v.Stats; e != g {
+			t.Errorf("%+v: stats: expected %+v, got %+v", tc.Advance, e, g)
+		}
+		if e, g := next.Cost, v.Cost; e != g {
+			t.Errorf("%+v: cost: expected %v, got %v", tc.Advance, e, g)
+		}
+	}
+
+	v.Experience = 100
+	failures := []Advance{
+		{},
+		{Stat: "luck"},
+		{Ability: "00000-charge"},
+		{Ability: "unknown"},
+		{Stat: StatMove, Ability: "00007-feint"},
+	}
+	for _, advance := range failures {
+		if _, err := c.Advance("Loups", "Brise-Fer", advance); err == nil {
+			t.Errorf("%+v: expected an error", advance)
+		}
+	}
+
+	// Le plafond de coût du barème ne se dépasse pas.
+	for {
+		if _, err := c.Advance("Loups", "Brise-Fer", Advance{Stat: StatRange}); err != nil {
+			break
+		}
+	}
+	if v.Cost > core.DefaultCosts.MaxTotal {
+		t.Errorf("cost %v over the %v cap", v.Cost, core.DefaultCosts.MaxTotal)
+	}
+
+	v.Experience = 0
+	if _, err := c.Advance("Loups", "Brise-Fer", Advance{Stat: StatHealth}); err == nil {
+		t.Error("expected an advance without experience to be rejected")
+	}
+}
+
+func TestSave(t *testing.T) {
+	c := newTestCampaign(t)
+	playBattle(t, c, 2)
+
+	path := filepath.Join(t.TempDir(), "campaign.json")
+	if err := c.Save(path); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if !reflect.DeepEqual(c, loaded) {
+		var e, g bytes.Buffer
+		c.Write(&e)
+		loaded.Write(&g)
+		t.Fatalf("loaded campaign: expected\n%s\ngot\n%s", e.String(), g.String())
+	}
+
+	// Une campagne rechargée tire les mêmes blessures.
+	for range 10 {
+		if e, g := c.roll(6), loaded.roll(6); e != g {
+			t.Fatalf("roll: expected %d, got %d", e, g)
+		}
+	}
+}
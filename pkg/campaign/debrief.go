@@ -0,0 +1,320 @@
+package campaign
+
+import (
+	"slices"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+	"github.com/bornholm/escarmouche/pkg/sim"
+	"github.com/pkg/errors"
+)
+
+/* =============================================================================
+   Bilan de partie.
+
+   Debrief relit les GameStep d'une partie terminée, événement par
+   événement :
+     - une élimination revient à l'unité qui agissait : celle de l'action du
+       GameStep, ou celle dont la réaction a suivi (cf. sim.UnitReacted).
+       Les événements qui précèdent le début du tour (fin du tour précédent,
+       terrain dangereux compris) n'ont pas d'auteur ;
+     - une zone est tenue par les unités du joueur qui s'y trouvent quand il
+       marque en fin de tour (cf. sim.ControlPointScored) ; les positions
+       sont suivies au fil des déplacements (cf. sim.UnitMoved) ;
+     - une unité survit si elle est encore sur le plateau en fin de partie.
+       Celle qui a fui (cf. sim.MoraleChecked) n'y est plus, mais n'est pas
+       tombée : elle ne gagne pas l'expérience de survie et ne jette pas de
+       blessure.
+
+   Chaque unité tombée jette ensuite sur la table des blessures (cf.
+   injure).
+   ========================================================================== */
+
+// Report : bilan d'une partie de campagne.
+type Report struct {
+	// Game : numéro de la partie dans la campagne, à partir de 1.
+	Game     int      `json:"game"`
+	Warbands []string `json:"warbands"`
+	// Winners : bandes de l'équipe victorieuse. Vide = partie nulle.
+	Winners []string     `json:"winners,omitempty"`
+	Turn    uint         `json:"turn"`
+	Units   []UnitReport `json:"units"`
+}
+
+type UnitReport struct {
+	Warband  string `json:"warband"`
+	Unit     string `json:"unit"`
+	Kills    int    `json:"kills"`
+	Holds    int    `json:"holds"`
+	Survived bool   `json:"survived"`
+	// Fled : l'unité a fui le plateau sur un test de moral raté.
+	Fled       bool `json:"fled,omitempty"`
+	Experience int  `json:"experience"`
+	// Injury : jet de blessure d'une unité tombée.
+	Injury *Injury `json:"injury,omitempty"`
+}
+
+type InjuryKind string
+
+const (
+	// InjuryDeath : l'unité ne se relève pas.
+	InjuryDeath InjuryKind = "death"
+	// InjuryLastingWound : une caractéristique perd un point, pour de bon.
+	InjuryLastingWound InjuryKind = "lasting-wound"
+	// InjuryShaken : l'unité manque la prochaine partie de sa bande.
+	InjuryShaken InjuryKind = "shaken"
+	// InjuryRecovered : plus de peur que de mal.
+	InjuryRecovered InjuryKind = "recovered"
+)
+
+type Injury struct {
+	Game int        `json:"game"`
+	Kind InjuryKind `json:"kind"`
+	// Stat : caractéristique touchée par une blessure durable.
+	Stat Stat `json:"stat,omitempty"`
+}
+
+// Debrief tire le bilan d'une partie terminée, jouée avec les escouades de
+// la bataille : expérience, blessures, parties manquées. steps : les
+// GameStep de la partie, tous, dans l'ordre.
+func (c *Campaign) Debrief(battle *Battle, game *sim.Game, steps []sim.GameStep) (*Report, error) {
+	record := game.Record()
+	if record.Result == nil {
+		return nil, errors.New("the game is not over")
+	}
+
+	setup := record.Setup
+
+	// Les unités du relevé suivent l'ordre des escouades (cf.
+	// sim.GameSetup).
+	veterans := make(map[sim.UnitID]*Veteran, len(setup.Units))
+	reports := make(map[sim.UnitID]*UnitReport, len(setup.Units))
+	owners := make(map[sim.UnitID]sim.PlayerID, len(setup.Units))
+	positions := make(map[sim.UnitID]sim.Position, len(setup.Units))
+	fielded := make([]int, len(battle.Veterans))
+	units := make([]UnitReport, 0, len(setup.Units))
+	for _, u := range setup.Units {
+		if int(u.Owner) >= len(battle.Veterans) || fielded[u.Owner] >= len(battle.Veterans[u.Owner]) {
+			return nil, errors.Errorf("unit %d of player %d is not in the battle", u.ID, u.Owner)
+		}
+		v := battle.Veterans[u.Owner][fielded[u.Owner]]
+		units = append(units, UnitReport{Warband: battle.Warbands[u.Owner], Unit: v.Name})
+		veterans[u.ID] = v
+		fielded[u.Owner]++
+		owners[u.ID], positions[u.ID] = u.Owner, u.Position
+	}
+	for playerID, count := range fielded {
+		if count != len(battle.Veterans[playerID]) {
+			return nil, errors.Errorf("player %d fielded %d units, battle says %d", playerID, count, len(battle.Veterans[playerID]))
+		}
+	}
+	for i, u := range setup.Units {
+		reports[u.ID] = &units[i]
+	}
+
+	state := game.State()
+
+	// held : unités du joueur dans une zone de capture sans ennemi.
+	held := func(playerID sim.PlayerID) []sim.UnitID {
+		holders := make([]sim.UnitID, 0)
+		for _, zone := range setup.Board.ObjectiveZones {
+			inside, contested := make([]sim.UnitID, 0), false
+			for _, u := range setup.Units {
+				pos, alive := positions[u.ID]
+				if !alive || !zone.Contains(pos) {
+					continue
+				}
+				switch owner := owners[u.ID]; {
+				case owner == playerID:
+					inside = append(inside, u.ID)
+				case !state.Allied(owner, playerID):
+					contested = true
+				}
+			}
+			if !contested {
+				holders = append(holders, inside...)
+			}
+		}
+		return holders
+	}
+
+	// credited : joueurs dont la tenue a déjà été comptée depuis le dernier
+	// début de tour.
+	credited := map[sim.PlayerID]bool{}
+	for _, step := range steps {
+		// L'action du joueur suit le début de son tour : ce qui le précède
+		// relève du tour précédent.
+		actor := actingUnit(step.Action)
+		for _, event := range step.Events {
+			if _, ok := event.(sim.TurnStarted); ok {
+				actor = -1
+				break
+			}
+		}
+
+		for _, event := range step.Events {
+			switch e := event.(type) {
+			case sim.TurnStarted:
+				actor = actingUnit(step.Action)
+				clear(credited)
+			case sim.UnitReacted:
+				actor = e.Unit
+			case sim.UnitMoved:
+				positions[e.Unit] = e.To
+			case sim.MoraleChecked:
+				if e.Outcome == sim.MoraleFled {
+					delete(positions, e.Unit)
+					reports[e.Unit].Fled = true
+				}
+			case sim.UnitKilled:
+				delete(positions, e.Unit)
+				if killer, exists := reports[actor]; exists && !state.Allied(owners[actor], e.Owner) {
+					killer.Kills++
+				}
+			case sim.ControlPointScored:
+				// Un marqueur par zone tenue : la tenue est comptée au
+				// premier, une fois par tour. Le marquage ne suit pas
+				// forcément la fin du tour du joueur : en activation
+				// alternée, tous marquent en fin de manche.
+				if !credited[e.Player] {
+					for _, unitID := range held(e.Player) {
+						reports[unitID].Holds++
+					}
+					credited[e.Player] = true
+				}
+			}
+		}
+	}
+
+	report := &Report{
+		Game:     len(c.Games) + 1,
+		Warbands: append([]string{}, battle.Warbands...),
+		Turn:     record.Result.Turn,
+		Units:    units,
+	}
+	if winner := record.Result.Winner; winner >= 0 {
+		for playerID, warband := range battle.Warbands {
+			if state.Allied(sim.PlayerID(playerID), winner) {
+				report.Winners = append(report.Winners, warband)
+			}
+		}
+	}
+
+	for _, u := range setup.Units {
+		unit, v := reports[u.ID], veterans[u.ID]
+
+		unit.Survived = state.Unit(u.ID) != nil
+		unit.Experience = c.Rules.Kill*unit.Kills + c.Rules.Hold*unit.Holds
+		if unit.Survived {
+			unit.Experience += c.Rules.Survival
+		}
+
+		v.Games++
+		v.Kills += unit.Kills
+		v.Holds += unit.Holds
+		v.Experience += unit.Experience
+		v.Earned += unit.Experience
+
+		if !unit.Survived && !unit.Fled {
+			injury, err := c.injure(v, report.Game)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			unit.Injury = injury
+		}
+	}
+
+	// Une unité convalescente qui a laissé passer une partie de sa bande
+	// est de nouveau valide.
+	for playerID, name := range battle.Warbands {
+		w, err := c.Warband(name)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		for _, v := range w.Units {
+			if v.Recovering && !slices.Contains(battle.Veterans[playerID], v) {
+				v.Recovering = false
+			}
+		}
+	}
+
+	c.Games = append(c.Games, report)
+
+	return report, nil
+}
+
+// actingUnit renvoie l'unité qui joue l'action, -1 sans action.
+func actingUnit(action sim.Action) sim.UnitID {
+	switch a := action.(type) {
+	case *sim.MoveAction:
+		return a.UnitID()
+	case *sim.AttackAction:
+		return a.UnitID()
+	case *sim.AbilityAction:
+		if d := a.Description(); d != nil {
+			return d.SourceUnitID
+		}
+	}
+	return -1
+}
+
+// injure jette la blessure d'une unité tombée pendant la partie donnée, sur
+// un dé à six faces :
+//   - 1 : l'unité meurt ;
+//   - 2 : blessure durable, une caractéristique au-dessus de 1 ou l'armure
+//     (tirée au sort) perd un point ; faute de caractéristique à entamer,
+//     l'unité est seulement ébranlée ;
+//   - 3 : ébranlée, elle manque la prochaine partie de sa bande ;
+//   - 4 à 6 : elle s'en remet.
+func (c *Campaign) injure(v *Veteran, game int) (*Injury, error) {
+	injury := &Injury{Game: game}
+
+	switch roll := c.roll(6); {
+	case roll == 1:
+		injury.Kind = InjuryDeath
+		v.Dead = true
+	case roll == 2 && len(woundable(v.Stats)) > 0:
+		stats := woundable(v.Stats)
+		injury.Kind, injury.Stat = InjuryLastingWound, stats[c.roll(len(stats))-1]
+		*injury.Stat.of(&v.Stats) -= 1
+		if err := v.refresh(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	case roll <= 3:
+		injury.Kind = InjuryShaken
+		v.Recovering = true
+	default:
+		injury.Kind = InjuryRecovered
+		return injury, nil
+	}
+
+	v.Injuries = append(v.Injuries, *injury)
+
+	return injury, nil
+}
+
+// woundable renvoie les caractéristiques qu'une blessure peut entamer : la
+// Défense jusqu'à 0, les autres jusqu'à 1.
+func woundable(stats core.Stats) []Stat {
+	candidates := make([]Stat, 0, len(Stats))
+	for _, stat := range Stats {
+		floor := 1
+		if stat == StatDefense {
+			floor = 0
+		}
+		if *stat.of(&stats) > floor {
+			candidates = append(candidates, stat)
+		}
+	}
+	return candidates
+}
+
+// roll tire un dé à n faces. Comme les dés de sim, le n-ième jet ne dépend
+// que de la graine et de n (splitmix64).
+func (c *Campaign) roll(n int) int {
+	x := uint64(c.Seed) + (c.Rolls+1)*0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	x ^= x >> 31
+	c.Rolls++
+	return int(x%uint64(n)) + 1
+}
@@ -0,0 +1,20 @@
+// Drain de Vie : capacité scriptée, exemple de script de capacité (cf.
+// pkg/sim/ability_script.go pour la façade state).
+
+// Une action par ennemi adjacent.
+function getValidActions(state, unit) {
+  return state.reachableEnemies(unit.id, 1).map(function(id) {
+    return { target: id }
+  })
+}
+
+// La cible perd 1 point de Santé, le lanceur en regagne 1 jusqu'à sa Santé
+// de départ.
+function apply(state, action) {
+  state.applyDamage(action.target, 1)
+
+  var self = state.unit(action.unit)
+  if (self.health < self.stats.health) {
+    state.inc(action.unit, "health", 1)
+  }
+}
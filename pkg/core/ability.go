@@ -3,10 +3,13 @@ package core
 import (
 	"embed"
 	"io/fs"
+	"maps"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 
+	"github.com/dop251/goja"
 	"github.com/pkg/errors"
 	"go.yaml.in/yaml/v3"
 )
@@ -16,9 +19,16 @@ type Ability struct {
 	Label       Text    `yaml:"label"`
 	Description Text    `yaml:"description"`
 	Cost        float64 `yaml:"cost"`
+	// Targeting et Effects décrivent une capacité déclarative (cf.
+	// Targeting). Absents, la capacité est codée dans le moteur.
+	Targeting *Targeting `yaml:"targeting"`
+	Effects   []Effect   `yaml:"effects"`
+	// Script : source JavaScript de la capacité, lue dans le fichier .js
+	// voisin du YAML s'il existe (cf. sim.AbilityRegistry.RegisterScript).
+	Script string `yaml:"-"`
 }
 
-//go:embed abilities/*.yml
+//go:embed abilities
 var abilitiesFS embed.FS
 
 var (
@@ -28,26 +38,49 @@ var (
 
 func loadAbilities() {
 	loadOnce.Do(func() {
-		abilities = map[string]Ability{}
-
-		files, err := fs.Glob(abilitiesFS, "abilities/*.yml")
+		pack, err := fs.Sub(abilitiesFS, "abilities")
 		if err != nil {
 			panic(errors.Wrap(err, "could not find abilities"))
 		}
 
-		for _, f := range files {
-			ability, err := parseAbilityFile(f)
-			if err != nil {
-				panic(errors.Wrapf(err, "could not parse ability file '%s'", f))
-			}
-
-			abilities[ability.ID] = ability
+		// Le catalogue embarqué fait partie du programme : un fichier
+		// illisible est une erreur de construction, pas un défaut à signaler.
+		var problems []AbilityProblem
+		abilities, problems = loadAbilityPack(pack)
+		if len(problems) > 0 {
+			panic(errors.Wrap(problems[0], "could not load abilities"))
 		}
 	})
 }
 
-func parseAbilityFile(path string) (Ability, error) {
-	f, err := abilitiesFS.Open(path)
+// loadAbilityPack lit les capacités d'un répertoire : un fichier YAML par
+// capacité, éventuellement accompagné de son script. Un fichier illisible
+// est écarté et signalé, sans empêcher la lecture des autres (cf.
+// CheckAbilityPack).
+func loadAbilityPack(fsys fs.FS) (map[string]Ability, []AbilityProblem) {
+	pack := map[string]Ability{}
+	problems := []AbilityProblem{}
+
+	files, err := fs.Glob(fsys, "*.yml")
+	if err != nil {
+		return pack, append(problems, AbilityProblem{Err: errors.WithStack(err)})
+	}
+
+	for _, f := range files {
+		ability, err := parseAbilityFile(fsys, f)
+		if err != nil {
+			problems = append(problems, AbilityProblem{AbilityID: fileID(f), Err: err})
+			continue
+		}
+
+		pack[ability.ID] = ability
+	}
+
+	return pack, problems
+}
+
+func parseAbilityFile(fsys fs.FS, path string) (Ability, error) {
+	f, err := fsys.Open(path)
 	if err != nil {
 		return Ability{}, errors.WithStack(err)
 	}
@@ -62,33 +95,103 @@ func parseAbilityFile(path string) (Ability, error) {
 		return Ability{}, errors.WithStack(err)
 	}
 
-	ability.ID = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	ability.ID = fileID(path)
+
+	script, err := fs.ReadFile(fsys, strings.TrimSuffix(path, filepath.Ext(path))+".js")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Ability{}, errors.WithStack(err)
+	}
+	ability.Script = string(script)
+
+	if err := ability.validateEffects(); err != nil {
+		return Ability{}, errors.Wrapf(err, "invalid ability '%s'", ability.ID)
+	}
+
+	if err := ability.validateScript(); err != nil {
+		return Ability{}, errors.Wrapf(err, "invalid script of ability '%s'", ability.ID)
+	}
 
 	return ability, nil
 }
 
-func Abilities(ids ...string) []Ability {
+// validateScript vérifie que le script se compile, s'exécute et définit les
+// deux fonctions attendues par le moteur (cf. sim.AbilityRegistry.RegisterScript).
+func (a Ability) validateScript() error {
+	if a.Script == "" {
+		return nil
+	}
+
+	program, err := CompileScript(a.ID+".js", a.Script)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	vm := goja.New()
+	guard, err := NewScriptGuard(vm)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err := guard.Run(func() (goja.Value, error) { return vm.RunProgram(program) }); err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, name := range []string{"getValidActions", "apply"} {
+		if _, ok := goja.AssertFunction(vm.Get(name)); !ok {
+			return errors.Errorf("missing '%s' function", name)
+		}
+	}
+
+	return nil
+}
+
+func fileID(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// LookupAbilities renvoie les capacités demandées, dans l'ordre, ou une
+// erreur nommant les identifiants inconnus.
+func LookupAbilities(ids ...string) ([]Ability, error) {
 	loadAbilities()
 
 	selected := make([]Ability, 0, len(ids))
+	unknown := make([]string, 0)
 	for _, id := range ids {
 		ability, exists := abilities[id]
 		if !exists {
-			panic(errors.Errorf("could not find ability '%s'", id))
+			unknown = append(unknown, id)
+			continue
 		}
 
 		selected = append(selected, ability)
 	}
 
+	if len(unknown) > 0 {
+		return nil, errors.Errorf("could not find abilities '%s'", strings.Join(unknown, "', '"))
+	}
+
+	return selected, nil
+}
+
+// Abilities renvoie les capacités demandées et panique sur un identifiant
+// inconnu : à réserver aux identifiants écrits dans le code (archétypes,
+// tests). Une saisie passe par LookupAbilities.
+func Abilities(ids ...string) []Ability {
+	selected, err := LookupAbilities(ids...)
+	if err != nil {
+		panic(errors.WithStack(err))
+	}
+
 	return selected
 }
 
+// AllAbilities renvoie les capacités du catalogue, triées par identifiant.
 func AllAbilities() []Ability {
 	loadAbilities()
 
-	selected := make([]Ability, 0)
-	for _, ability := range abilities {
-		selected = append(selected, ability)
+	selected := make([]Ability, 0, len(abilities))
+	for _, id := range slices.Sorted(maps.Keys(abilities)) {
+		selected = append(selected, abilities[id])
 	}
 
 	return selected
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// AbilityProblem : défaut d'une capacité du catalogue. AbilityID est vide
+// pour un défaut qui ne concerne pas une capacité en particulier.
+type AbilityProblem struct {
+	AbilityID string
+	Err       error
+}
+
+func (p AbilityProblem) Error() string {
+	if p.AbilityID == "" {
+		return p.Err.Error()
+	}
+	return fmt.Sprintf("%s: %s", p.AbilityID, p.Err)
+}
+
+// Languages : langues dans lesquelles chaque capacité doit être rédigée.
+var Languages = []Language{LanguageFR, LanguageEN, LanguageES}
+
+// CheckAbilities vérifie le catalogue embarqué, cf. CheckAbilityPack. Ses
+// fichiers ont déjà été lus sans erreur (cf. loadAbilities).
+func CheckAbilities(implemented []string) []AbilityProblem {
+	loadAbilities()
+
+	return checkAbilities(abilities, implemented)
+}
+
+// CheckAbilityPack vérifie un répertoire de capacités et renvoie tous ses
+// défauts à la fois :
+//   - fichier illisible, effets incohérents ou script invalide ;
+//   - traduction manquante du nom ou de la description (cf. Languages) ;
+//   - coût nul, négatif ou non fini ;
+//   - numéro de capacité partagé par deux fichiers ;
+//   - capacité sans implémentation : ni déclarée (cf. Targeting), ni
+//     scriptée, ni parmi implemented, les capacités codées dans le moteur ;
+//   - implémentation du moteur sans fichier de capacité.
+func CheckAbilityPack(fsys fs.FS, implemented []string) []AbilityProblem {
+	pack, problems := loadAbilityPack(fsys)
+	return append(problems, checkAbilities(pack, implemented)...)
+}
+
+func checkAbilities(pack map[string]Ability, implemented []string) []AbilityProblem {
+	problems := []AbilityProblem{}
+
+	report := func(id string, format string, args ...any) {
+		problems = append(problems, AbilityProblem{AbilityID: id, Err: errors.Errorf(format, args...)})
+	}
+
+	ids := slices.Sorted(maps.Keys(pack))
+
+	numbers := map[string]string{}
+
+	for _, id := range ids {
+		ability := pack[id]
+
+		for _, lang := range Languages {
+			if strings.TrimSpace(ability.Label[lang]) == "" {
+				report(id, "missing %s label", lang)
+			}
+			if strings.TrimSpace(ability.Description[lang]) == "" {
+				report(id, "missing %s description", lang)
+			}
+		}
+
+		if ability.Cost <= 0 || math.IsInf(ability.Cost, 0) || math.IsNaN(ability.Cost) {
+			report(id, "invalid cost %v", ability.Cost)
+		}
+
+		// Les identifiants sont numérotés (« 00003-suppressing-fire ») : deux
+		// fichiers ne partagent pas un numéro.
+		if number, _, found := strings.Cut(id, "-"); found {
+			if other, exists := numbers[number]; exists {
+				report(id, "duplicate ability number %s, already used by '%s'", number, other)
+			} else {
+				numbers[number] = id
+			}
+		}
+
+		if ability.Targeting == nil && ability.Script == "" && !slices.Contains(implemented, id) {
+			report(id, "no implementation: neither declared, scripted nor implemented by the engine")
+		}
+	}
+
+	for _, id := range implemented {
+		if _, exists := pack[id]; !exists {
+			report(id, "implemented by the engine but missing from the catalogue")
+		}
+	}
+
+	return problems
+}
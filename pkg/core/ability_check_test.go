@@ -0,0 +1,130 @@
+package core
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const checkedAbility = `
+label: { fr-FR: Balayage, en-EN: Sweep, es-ES: Barrido }
+description: { fr-FR: Balayage, en-EN: Sweep, es-ES: Barrido }
+cost: 4
+`
+
+const checkedScript = `
+function getValidActions(state, unit) { return [] }
+function apply(state, action) {}
+`
+
+func TestCheckAbilityPack(t *testing.T) {
+	type testCase struct {
+		Files       map[string]string
+		Implemented []string
+		// ExpectedAbility et ExpectedError : capacité et extrait du défaut
+		// attendu, seul signalé. Vides, aucun défaut n'est attendu.
+		ExpectedAbility string
+		ExpectedError   string
+	}
+
+	testCases := map[string]testCase{
+		"valid pack": {
+			Files: map[string]string{
+				"00001-declared.yml": checkedAbility + "targeting: { type: self }\neffects:\n  - { type: damage, amount: 1, area: 1 }\n",
+				"00002-scripted.yml": checkedAbility,
+				"00002-scripted.js":  checkedScript,
+				"00003-native.yml":   checkedAbility,
+			},
+			Implemented: []string{"00003-native"},
+		},
+		"malformed yaml": {
+			Files: map[string]string{
+				"00001-malformed.yml": "label: [fr-FR: Balayage\n",
+			},
+			ExpectedAbility: "00001-malformed",
+			ExpectedError:   "yaml",
+		},
+		"duplicate numeric prefix": {
+			Files: map[string]string{
+				"00001-first.yml":  checkedAbility,
+				"00001-second.yml": checkedAbility,
+			},
+			Implemented:     []string{"00001-first", "00001-second"},
+			ExpectedAbility: "00001-second",
+			ExpectedError:   "duplicate ability number 00001",
+		},
+		"unknown effect": {
+			Files: map[string]string{
+				"00001-unknown-effect.yml": checkedAbility + "targeting: { type: enemy }\neffects:\n  - { type: teleport }\n",
+			},
+			ExpectedAbility: "00001-unknown-effect",
+			ExpectedError:   "unknown effect type 'teleport'",
+		},
+		"unknown targeting": {
+			Files: map[string]string{
+				"00001-unknown-targeting.yml": checkedAbility + "targeting: { type: everyone }\neffects:\n  - { type: damage, amount: 1 }\n",
+			},
+			ExpectedAbility: "00001-unknown-targeting",
+			ExpectedError:   "unknown targeting type 'everyone'",
+		},
+		"script syntax error": {
+			Files: map[string]string{
+				"00001-bad-script.yml": checkedAbility,
+				"00001-bad-script.js":  "function getValidActions(state, unit) {",
+			},
+			ExpectedAbility: "00001-bad-script",
+			ExpectedError:   "invalid script",
+		},
+		"script without apply": {
+			Files: map[string]string{
+				"00001-bad-script.yml": checkedAbility,
+				"00001-bad-script.js":  "function getValidActions(state, unit) { return [] }",
+			},
+			ExpectedAbility: "00001-bad-script",
+			ExpectedError:   "missing 'apply' function",
+		},
+		"endless script": {
+			Files: map[string]string{
+				"00001-bad-script.yml": checkedAbility,
+				"00001-bad-script.js":  "for (;;) {}",
+			},
+			ExpectedAbility: "00001-bad-script",
+			ExpectedError:   "step budget",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			fsys := fstest.MapFS{}
+			for path, content := range tc.Files {
+				fsys[path] = &fstest.MapFile{Data: []byte(content)}
+			}
+
+			problems := CheckAbilityPack(fsys, tc.Implemented)
+
+			if tc.ExpectedError == "" {
+				for _, problem := range problems {
+					t.Errorf("unexpected problem: %v", problem)
+				}
+				return
+			}
+
+			if e, g := 1, len(problems); e != g {
+				t.Fatalf("expected %d problem, got %d: %v", e, g, problems)
+			}
+			if e, g := tc.ExpectedAbility, problems[0].AbilityID; e != g {
+				t.Errorf("AbilityID: expected '%s', got '%s'", e, g)
+			}
+			if g := problems[0].Err.Error(); !strings.Contains(g, tc.ExpectedError) {
+				t.Errorf("expected an error containing '%s', got '%s'", tc.ExpectedError, g)
+			}
+		})
+	}
+}
+
+func TestEmbeddedAbilities(t *testing.T) {
+	// Le catalogue embarqué est lu sans erreur, sinon loadAbilities panique.
+	if len(AllAbilities()) == 0 {
+		t.Error("expected embedded abilities")
+	}
+}
@@ -10,7 +10,11 @@ type Costs struct {
 	MoveExponent  float64
 	PowerFactor   float64
 	PowerExponent float64
-	MaxTotal      float64
+	// DefenseFactor / DefenseExponent : prix de l'armure. Une Défense à 0 ne
+	// coûte rien.
+	DefenseFactor   float64
+	DefenseExponent float64
+	MaxTotal        float64
 }
 
 // DefaultCosts : facteurs re-mesurés le 2026-08-17 sous la condition de
@@ -38,6 +42,14 @@ var DefaultCosts = Costs{
 	PowerFactor:   1.6,
 	PowerExponent: 1.30,
 
+	// Chaque point de Défense retire un point à chaque coup reçu, jusqu'au
+	// plancher d'un point : face à la Puissance 1 ou 2 de la plupart des
+	// unités, le premier point vaut à peu près deux points de Santé, le
+	// deuxième rend l'unité presque insensible aux coups — d'où l'exposant
+	// plus raide que celui de la Puissance.
+	DefenseFactor:   2.4,
+	DefenseExponent: 1.60,
+
 	MaxTotal: 30,
 }
 
@@ -46,6 +58,7 @@ func CalculateTotalCost(stats Stats, abilities []Ability, costs Costs) float64 {
 	rangeCost := CalculeExponentialCost(stats.Range, costs.RangeFactor, costs.RangeExponent)
 	moveCost := CalculeExponentialCost(stats.Move, costs.MoveFactor, costs.MoveExponent)
 	attackCost := CalculeExponentialCost(stats.Power, costs.PowerFactor, costs.PowerExponent)
+	defenseCost := CalculeExponentialCost(stats.Defense, costs.DefenseFactor, costs.DefenseExponent)
 
 	// Synergie "bonus"
 	synergyBonus := (float64(stats.Range) * costs.RangeFactor) * (float64(stats.Power) * costs.PowerFactor) * 0.1
@@ -55,7 +68,7 @@ func CalculateTotalCost(stats Stats, abilities []Ability, costs Costs) float64 {
 		abilitiesCost += c.Cost
 	}
 
-	return math.Ceil(healthCost + rangeCost + moveCost + attackCost + synergyBonus + abilitiesCost)
+	return math.Ceil(healthCost + rangeCost + moveCost + attackCost + defenseCost + synergyBonus + abilitiesCost)
 }
 
 func CalculateSimpleCost(value int, costFactor float64) float64 {
@@ -0,0 +1,222 @@
+package core
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+	"go.yaml.in/yaml/v3"
+)
+
+/* =============================================================================
+   Capacités déclaratives.
+
+   Une capacité peut décrire dans son fichier YAML son ciblage et la liste de
+   ses effets ; le moteur (cf. sim) en tire les actions sans code dédié :
+
+     targeting: { type: enemy, range: 3, lineOfSight: true }
+     effects:
+       - { type: damage, amount: 2 }
+
+   Le ciblage choisit une cible par action : le lanceur lui-même (self), un
+   allié ou un ennemi à portée, ou une case atteignable par un mouvement
+   (cell). Les effets s'appliquent ensuite dans l'ordre déclaré : dégâts,
+   dégâts subis par le lanceur, déplacement, échange de positions, pose d'un
+   statut. Un effet de zone (area) touche tous les ennemis du lanceur à cette
+   distance du centre de l'effet.
+
+   Les quantités sont des entiers ou des références aux caractéristiques du
+   lanceur (cf. Quantity). Une capacité sans ciblage reste codée en Go, ou
+   en JavaScript (cf. Ability.Script).
+   ========================================================================== */
+
+type TargetType string
+
+const (
+	TargetSelf  TargetType = "self"
+	TargetAlly  TargetType = "ally"
+	TargetEnemy TargetType = "enemy"
+	TargetCell  TargetType = "cell"
+)
+
+type Targeting struct {
+	Type TargetType `yaml:"type"`
+	// Range : portée en cases (distance de Chebyshev). Pour une case, budget
+	// de mouvement ; absent, celui de l'unité.
+	Range Quantity `yaml:"range"`
+	// LineOfSight : la cible doit être en ligne de vue. Un ennemi visé sans
+	// ligne de vue l'est aussi sans égard pour les protections qui
+	// empêchent de le cibler (cf. Tir de Précision).
+	LineOfSight bool `yaml:"lineOfSight"`
+}
+
+type EffectType string
+
+const (
+	EffectDamage     EffectType = "damage"
+	EffectSelfDamage EffectType = "self-damage"
+	EffectMove       EffectType = "move"
+	EffectSwap       EffectType = "swap"
+	EffectStatus     EffectType = "status"
+)
+
+// EffectSubject désigne l'unité visée par un effet.
+type EffectSubject string
+
+const (
+	SubjectTarget EffectSubject = "target"
+	SubjectSelf   EffectSubject = "self"
+)
+
+type Effect struct {
+	Type EffectType `yaml:"type"`
+	// Amount : points de dégâts (damage, self-damage).
+	Amount Quantity `yaml:"amount"`
+	// Area : rayon de la zone, 0 pour l'unité visée seule (damage, status).
+	Area int `yaml:"area"`
+	// Status : nom du statut posé (status).
+	Status string `yaml:"status"`
+	// Value : valeur du statut, 1 par défaut. « target » y range la cible.
+	Value *Quantity `yaml:"value"`
+	// On : unité visée par l'effet, la cible par défaut (damage, status).
+	On EffectSubject `yaml:"on"`
+}
+
+// Subject renvoie l'unité visée par l'effet.
+func (e Effect) Subject() EffectSubject {
+	if e.Type == EffectSelfDamage || e.On == SubjectSelf {
+		return SubjectSelf
+	}
+	return SubjectTarget
+}
+
+// Quantity : entier littéral, ou référence à une caractéristique du lanceur
+// (power, range, move, health, defense). La valeur d'un statut peut aussi
+// référencer la cible (target), cf. le Gardien.
+type Quantity struct {
+	Value int
+	Ref   string
+}
+
+const (
+	QuantityPower   = "power"
+	QuantityRange   = "range"
+	QuantityMove    = "move"
+	QuantityHealth  = "health"
+	QuantityDefense = "defense"
+	QuantityTarget  = "target"
+)
+
+// IsZero indique si la quantité est absente du fichier.
+func (q Quantity) IsZero() bool {
+	return q.Value == 0 && q.Ref == ""
+}
+
+// Resolve renvoie la valeur de la quantité pour un lanceur de
+// caractéristiques stats. Une référence à la cible vaut target.
+func (q Quantity) Resolve(stats Stats, target int) int {
+	switch q.Ref {
+	case QuantityPower:
+		return stats.Power
+	case QuantityRange:
+		return stats.Range
+	case QuantityMove:
+		return stats.Move
+	case QuantityHealth:
+		return stats.Health
+	case QuantityDefense:
+		return stats.Defense
+	case QuantityTarget:
+		return target
+	default:
+		return q.Value
+	}
+}
+
+func (q *Quantity) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return errors.Errorf("line %d: expected an integer or a characteristic", node.Line)
+	}
+
+	if value, err := strconv.Atoi(node.Value); err == nil {
+		*q = Quantity{Value: value}
+		return nil
+	}
+
+	switch node.Value {
+	case QuantityPower, QuantityRange, QuantityMove, QuantityHealth, QuantityDefense, QuantityTarget:
+		*q = Quantity{Ref: node.Value}
+		return nil
+	default:
+		return errors.Errorf("line %d: unknown quantity '%s'", node.Line, node.Value)
+	}
+}
+
+// validateEffects vérifie la cohérence du ciblage et des effets d'une capacité
+// déclarative. Les noms de statut sont vérifiés par le moteur, qui les
+// enregistre.
+func (a Ability) validateEffects() error {
+	if a.Script != "" && (a.Targeting != nil || len(a.Effects) > 0) {
+		return errors.New("an ability is either scripted or declared, not both")
+	}
+
+	if a.Targeting == nil {
+		if len(a.Effects) > 0 {
+			return errors.New("effects declared without targeting")
+		}
+		return nil
+	}
+
+	targetsUnit := false
+	switch a.Targeting.Type {
+	case TargetAlly, TargetEnemy:
+		targetsUnit = true
+	case TargetSelf, TargetCell:
+	default:
+		return errors.Errorf("unknown targeting type '%s'", a.Targeting.Type)
+	}
+
+	if len(a.Effects) == 0 {
+		return errors.New("targeting declared without effects")
+	}
+
+	for i, effect := range a.Effects {
+		if effect.Area < 0 {
+			return errors.Errorf("effect #%d: invalid area %d", i, effect.Area)
+		}
+		switch effect.On {
+		case "", SubjectTarget, SubjectSelf:
+		default:
+			return errors.Errorf("effect #%d: unknown subject '%s'", i, effect.On)
+		}
+		if effect.Subject() == SubjectTarget && effect.Area == 0 && !targetsUnit &&
+			(effect.Type == EffectDamage || effect.Type == EffectStatus) {
+			return errors.Errorf("effect #%d: '%s' needs a unit target or an area", i, effect.Type)
+		}
+
+		switch effect.Type {
+		case EffectDamage, EffectSelfDamage:
+			if effect.Amount.IsZero() {
+				return errors.Errorf("effect #%d: missing damage amount", i)
+			}
+		case EffectMove:
+			if a.Targeting.Type != TargetCell {
+				return errors.Errorf("effect #%d: move needs a cell target", i)
+			}
+		case EffectSwap:
+			if !targetsUnit {
+				return errors.Errorf("effect #%d: swap needs a unit target", i)
+			}
+		case EffectStatus:
+			if effect.Status == "" {
+				return errors.Errorf("effect #%d: missing status", i)
+			}
+			if effect.Value != nil && effect.Value.Ref == QuantityTarget && !targetsUnit {
+				return errors.Errorf("effect #%d: status value references a missing target", i)
+			}
+		default:
+			return errors.Errorf("effect #%d: unknown effect type '%s'", i, effect.Type)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,272 @@
+package core
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/dop251/goja"
+	"github.com/dop251/goja/ast"
+	"github.com/dop251/goja/parser"
+	"github.com/pkg/errors"
+)
+
+/* =============================================================================
+   Garde des scripts de capacité.
+
+   Un script ne doit pas bloquer la partie : ni boucle sans fin, ni
+   récursion sans fond. Une minuterie ne convient pas : sous WebAssembly
+   (la Caserne), le script occupe le seul fil d'exécution et aucune
+   minuterie ne se déclenche avant qu'il rende la main.
+
+   Le script est donc instrumenté à la compilation (cf. CompileScript) :
+   chaque tour de boucle et chaque appel de fonction compte un pas. Au-delà
+   de ScriptStepBudget pas dans un même appel, le runtime est interrompu
+   (cf. goja.Runtime.Interrupt) et l'appel échoue avec ErrScriptBudget. La
+   profondeur d'appel est en outre bornée par scriptStackSize.
+
+   La garde vise les fautes de script, d'où quelques précautions : le nom
+   de la fonction de comptage est réservé, et eval comme le constructeur
+   Function, qui compileraient du code non instrumenté, sont retirés.
+   ========================================================================== */
+
+// ScriptStepBudget borne le nombre de pas d'un appel de script : largement
+// de quoi parcourir le plateau et ses unités, une boucle sans fin est
+// interrompue en quelques millisecondes.
+const ScriptStepBudget = 100_000
+
+// scriptStackSize borne la profondeur d'appel d'un script.
+const scriptStackSize = 256
+
+// scriptStep : fonction de comptage appelée par le script instrumenté.
+const scriptStep = "__escarmoucheStep"
+
+var ErrScriptBudget = errors.New("script exceeded its step budget")
+
+// scriptPrelude retire ce qui compilerait du code non instrumenté.
+const scriptPrelude = `(function() {
+  var blocked = function() { throw new Error("code generation is not available to abilities") }
+  globalThis.eval = blocked
+  ;[function() {}, function*() {}, async function() {}].forEach(function(f) {
+    Object.defineProperty(Object.getPrototypeOf(f), "constructor", { value: blocked })
+  })
+  globalThis.Function = blocked
+})()`
+
+// CompileScript compile un script de capacité, instrumenté pour la garde :
+// à exécuter dans un runtime muni d'une ScriptGuard.
+func CompileScript(name string, source string) (*goja.Program, error) {
+	if strings.Contains(source, scriptStep) {
+		return nil, errors.Errorf("identifier '%s' is reserved", scriptStep)
+	}
+
+	instrumented, err := instrumentScript(name, source)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	program, err := goja.Compile(name, instrumented, true)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return program, nil
+}
+
+// ScriptGuard compte les pas d'un runtime. Elle est créée une fois par
+// runtime, chaque appel de Run repartant d'un budget neuf.
+type ScriptGuard struct {
+	vm    *goja.Runtime
+	steps int
+}
+
+// NewScriptGuard installe la garde dans le runtime.
+func NewScriptGuard(vm *goja.Runtime) (*ScriptGuard, error) {
+	guard := &ScriptGuard{vm: vm}
+
+	vm.SetMaxCallStackSize(scriptStackSize)
+
+	step := vm.ToValue(func() {
+		guard.steps++
+		if guard.steps == ScriptStepBudget {
+			vm.Interrupt(ErrScriptBudget)
+		}
+	})
+	// Ni modifiable ni masquable : un script ne peut se soustraire au
+	// comptage.
+	if err := vm.GlobalObject().DefineDataProperty(scriptStep, step, goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_FALSE); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if _, err := vm.RunString(scriptPrelude); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return guard, nil
+}
+
+// Run exécute run avec un budget de pas neuf. Le runtime reste utilisable
+// après un appel interrompu.
+func (g *ScriptGuard) Run(run func() (goja.Value, error)) (goja.Value, error) {
+	g.steps = 0
+
+	value, err := run()
+	if g.steps >= ScriptStepBudget {
+		// Un appel achevé juste après l'épuisement du budget laisse
+		// l'interruption en suspens : elle ne doit pas viser l'appel suivant.
+		g.vm.ClearInterrupt()
+		return nil, errors.WithStack(ErrScriptBudget)
+	}
+
+	return value, err
+}
+
+// scriptInsertion : texte inséré dans la source à un décalage donné. Une
+// fermeture précède les ouvertures au même décalage.
+type scriptInsertion struct {
+	offset  int
+	closing bool
+	text    string
+}
+
+// instrumentScript insère un appel à scriptStep au début de chaque corps de
+// boucle et de fonction. Un corps qui n'est pas un bloc est enveloppé :
+// « while (c) i++ » devient « while (c) {step(); i++} », « x => x * 2 »
+// devient « x => (step(), x * 2) ».
+func instrumentScript(name string, source string) (string, error) {
+	program, err := parser.ParseFile(nil, name, source, 0)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	call := scriptStep + "();"
+	insertions := []scriptInsertion{}
+	opening := func(idx int, text string) {
+		insertions = append(insertions, scriptInsertion{offset: idx - 1, text: text})
+	}
+	closing := func(idx int, text string) {
+		insertions = append(insertions, scriptInsertion{offset: idx - 1, closing: true, text: text})
+	}
+
+	// Un même nœud peut être référencé deux fois dans l'arbre (cf.
+	// ast.FunctionLiteral.DeclarationList).
+	seen := map[ast.Node]bool{}
+
+	var visit func(value reflect.Value)
+	visit = func(value reflect.Value) {
+		switch value.Kind() {
+		case reflect.Interface:
+			if !value.IsNil() {
+				visit(value.Elem())
+			}
+			return
+		case reflect.Pointer:
+			if value.IsNil() {
+				return
+			}
+		case reflect.Slice:
+			for i := range value.Len() {
+				visit(value.Index(i))
+			}
+			return
+		case reflect.Struct:
+			for i := range value.NumField() {
+				if value.Type().Field(i).IsExported() {
+					visit(value.Field(i))
+				}
+			}
+			return
+		default:
+			return
+		}
+
+		node, isNode := value.Interface().(ast.Node)
+		if !isNode {
+			visit(value.Elem())
+			return
+		}
+		if seen[node] {
+			return
+		}
+		seen[node] = true
+
+		// body : corps de boucle, enveloppé s'il n'est pas un bloc.
+		var body ast.Statement
+		switch n := node.(type) {
+		case *ast.ForStatement:
+			body = n.Body
+		case *ast.ForInStatement:
+			body = n.Body
+		case *ast.ForOfStatement:
+			body = n.Body
+		case *ast.WhileStatement:
+			body = n.Body
+		case *ast.DoWhileStatement:
+			body = n.Body
+		case *ast.FunctionLiteral:
+			opening(int(n.Body.LeftBrace)+1, call)
+		case *ast.ArrowFunctionLiteral:
+			switch b := n.Body.(type) {
+			case *ast.BlockStatement:
+				opening(int(b.LeftBrace)+1, call)
+			case *ast.ExpressionBody:
+				opening(int(b.Idx0()), "("+scriptStep+"(), ")
+				defer closing(int(b.Idx1()), ")")
+			}
+		}
+
+		switch b := body.(type) {
+		case nil:
+		case *ast.BlockStatement:
+			opening(int(b.LeftBrace)+1, call)
+		default:
+			opening(int(b.Idx0()), "{"+call)
+			defer closing(statementEnd(source, int(b.Idx1())), "}")
+		}
+
+		visit(value.Elem())
+	}
+	visit(reflect.ValueOf(program))
+
+	// Tri stable : à décalage égal, les ouvertures restent dans l'ordre de
+	// visite (du nœud englobant au nœud englobé), les fermetures dans
+	// l'ordre inverse.
+	slices.SortStableFunc(insertions, func(a, b scriptInsertion) int {
+		if a.offset != b.offset {
+			return a.offset - b.offset
+		}
+		switch {
+		case a.closing && !b.closing:
+			return -1
+		case !a.closing && b.closing:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	var sb strings.Builder
+	last := 0
+	for _, insertion := range insertions {
+		sb.WriteString(source[last:insertion.offset])
+		sb.WriteString(insertion.text)
+		last = insertion.offset
+	}
+	sb.WriteString(source[last:])
+
+	return sb.String(), nil
+}
+
+// statementEnd prolonge la fin d'une instruction jusqu'à son point-virgule :
+// le nœud s'arrête avant, et « if (a) while (c) i++; else … » ne doit pas
+// devenir « if (a) while (c) {step(); i++}; else … ».
+func statementEnd(source string, idx int) int {
+	i := idx - 1
+	for i < len(source) && strings.ContainsRune(" \t\r\n", rune(source[i])) {
+		i++
+	}
+	if i < len(source) && source[i] == ';' {
+		return i + 2
+	}
+	return idx
+}
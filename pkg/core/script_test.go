@@ -0,0 +1,130 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dop251/goja"
+	"github.com/pkg/errors"
+)
+
+func runScript(t *testing.T, guard *ScriptGuard, vm *goja.Runtime, source string) (goja.Value, error) {
+	t.Helper()
+
+	program, err := CompileScript("test.js", source)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	return guard.Run(func() (goja.Value, error) { return vm.RunProgram(program) })
+}
+
+func TestScriptStepBudget(t *testing.T) {
+	scripts := map[string]string{
+		"for":               `for (;;) {}`,
+		"for without block": `for (;;);`,
+		"while":             `while (true) {}`,
+		"while statement":   `var i = 0; while (true) i++`,
+		"do while":          `do {} while (true)`,
+		"for of":            `var a = [1]; for (var x of a) a.push(x)`,
+		"recursion":         `function f(n) { return n ? f(n - 1) + f(n - 1) : 0 } f(64)`,
+		"arrow recursion":   `var f = n => n ? f(n - 1) + f(n - 1) : 0; f(64)`,
+		"method":            `var o = { f(n) { return n ? o.f(n - 1) + o.f(n - 1) : 0 } }; o.f(64)`,
+		"nested":            `var x; if (true) while (true) for (;;) x = 1; else x = 2`,
+	}
+
+	vm := goja.New()
+	guard, err := NewScriptGuard(vm)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	for name, source := range scripts {
+		t.Run(name, func(t *testing.T) {
+			if _, err := runScript(t, guard, vm, source); !errors.Is(err, ErrScriptBudget) {
+				t.Errorf("expected the step budget to be exceeded, got %v", err)
+			}
+
+			// La garde sert à nouveau, avec un budget neuf.
+			value, err := runScript(t, guard, vm, `1 + 1`)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if e, g := int64(2), value.ToInteger(); e != g {
+				t.Errorf("expected %d, got %d", e, g)
+			}
+		})
+	}
+}
+
+// L'instrumentation ne change pas ce que calcule le script.
+func TestInstrumentedScript(t *testing.T) {
+	type testCase struct {
+		Source   string
+		Expected int64
+	}
+
+	testCases := map[string]testCase{
+		"for":             {Source: `var s = 0; for (var i = 0; i < 4; i++) s += i; s`, Expected: 6},
+		"for else":        {Source: `var s = 0; if (false) for (;;) s++; else s = 3; s`, Expected: 3},
+		"while":           {Source: `var i = 0; while (i < 5) i++; i`, Expected: 5},
+		"do while":        {Source: `var i = 0; do i += 2; while (i < 5) i`, Expected: 6},
+		"for in":          {Source: `var n = 0; for (var k in { a: 1, b: 2 }) n++; n`, Expected: 2},
+		"continue":        {Source: `var s = 0; for (var i = 0; i < 5; i++) { if (i % 2) continue; s += i } s`, Expected: 6},
+		"arrow":           {Source: `var f = x => x * 2; f(4)`, Expected: 8},
+		"arrow object":    {Source: `var f = x => ({ v: x }); f(4).v`, Expected: 4},
+		"arrow sequence":  {Source: `var f = x => (x, x + 1); f(4)`, Expected: 5},
+		"nested arrows":   {Source: `var f = x => y => x + y; f(4)(3)`, Expected: 7},
+		"arrow loop body": {Source: `var fs = []; for (var i = 0; i < 3; i++) fs.push(() => i); fs.length`, Expected: 3},
+		"function":        {Source: `function f(n) { return n ? n + f(n - 1) : 0 } f(4)`, Expected: 10},
+		"method":          {Source: `var o = { f() { return 2 } }; o.f()`, Expected: 2},
+		"map":             {Source: `[1, 2, 3].map(function(x) { return x * x }).reduce((a, b) => a + b)`, Expected: 14},
+		"labelled":        {Source: `var n = 0; outer: for (var i = 0; i < 3; i++) for (var j = 0; j < 3; j++) { if (j == 1) continue outer; n++ } n`, Expected: 3},
+	}
+
+	vm := goja.New()
+	guard, err := NewScriptGuard(vm)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			value, err := runScript(t, guard, vm, tc.Source)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if e, g := tc.Expected, value.ToInteger(); e != g {
+				t.Errorf("expected %d, got %d", e, g)
+			}
+		})
+	}
+}
+
+func TestScriptEscapes(t *testing.T) {
+	if _, err := CompileScript("test.js", scriptStep+" = function() {}"); err == nil {
+		t.Error("expected the step function name to be reserved")
+	}
+
+	scripts := map[string]string{
+		"eval":                 `eval("for (;;) {}")`,
+		"function constructor": `Function("for (;;) {}")()`,
+		"constructor property": `(function() {}).constructor("for (;;) {}")()`,
+		"generator":            `(function*() {}).constructor("for (;;) {}")().next()`,
+	}
+
+	vm := goja.New()
+	guard, err := NewScriptGuard(vm)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	for name, source := range scripts {
+		t.Run(name, func(t *testing.T) {
+			_, err := runScript(t, guard, vm, source)
+			if err == nil || !strings.Contains(err.Error(), "code generation is not available") {
+				t.Errorf("expected code generation to be blocked, got %v", err)
+			}
+		})
+	}
+}
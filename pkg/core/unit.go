@@ -5,4 +5,7 @@ type Stats struct {
 	Range  int
 	Move   int
 	Power  int
+	// Defense : armure, retranchée de chaque coup reçu (cf. sim.applyDamage).
+	// 0 = pas d'armure, la seule caractéristique qui peut valoir 0.
+	Defense int `json:",omitempty"`
 }
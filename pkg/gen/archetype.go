@@ -6,11 +6,11 @@ import (
 )
 
 var (
-	ArchetypeJackOfAllTrades = Archetype{Name: "jackofalltrades", WeightHealth: 25, WeightRange: 25, WeightMove: 25, WeightPower: 25, Abilities: core.AllAbilities()}
-	ArchetypeTank            = Archetype{Name: "tank", WeightHealth: 60, WeightRange: 10, WeightMove: 15, WeightPower: 15, Abilities: core.Abilities("00002-defensive-stance"), WeightAbility: 20}
+	ArchetypeJackOfAllTrades = Archetype{Name: "jackofalltrades", WeightHealth: 20, WeightRange: 20, WeightMove: 20, WeightPower: 20, WeightDefense: 20, Abilities: core.AllAbilities()}
+	ArchetypeTank            = Archetype{Name: "tank", WeightHealth: 40, WeightRange: 10, WeightMove: 15, WeightPower: 15, WeightDefense: 20, Abilities: core.Abilities("00002-defensive-stance"), WeightAbility: 20}
 	ArchetypeSniper          = Archetype{Name: "sniper", WeightHealth: 15, WeightRange: 40, WeightMove: 15, WeightPower: 30}
 	ArchetypeSkirmisher      = Archetype{Name: "skirmisher", WeightHealth: 20, WeightRange: 20, WeightMove: 40, WeightPower: 20, Abilities: core.Abilities("00000-charge"), WeightAbility: 20}
-	ArchetypeBruiser         = Archetype{Name: "bruiser", WeightHealth: 35, WeightRange: 15, WeightMove: 20, WeightPower: 30}
+	ArchetypeBruiser         = Archetype{Name: "bruiser", WeightHealth: 30, WeightRange: 15, WeightMove: 20, WeightPower: 25, WeightDefense: 10}
 	ArchetypeGlassCannon     = Archetype{Name: "glasscannon", WeightHealth: 10, WeightRange: 30, WeightMove: 15, WeightPower: 45, Abilities: core.Abilities("00001-energy-trait"), WeightAbility: 20}
 )
 
@@ -30,6 +30,8 @@ type Archetype struct {
 	WeightRange  int
 	WeightMove   int
 	WeightPower  int
+	// WeightDefense : poids de l'armure, qui part de 0 (cf. RandomUnit).
+	WeightDefense int
 
 	WeightAbility int
 	Abilities     []core.Ability
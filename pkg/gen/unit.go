@@ -21,8 +21,9 @@ type GeneratedUnit struct {
 // purement narratif (cf. core.RankFromCost).
 //
 // La construction est incrémentale : on garantit d'abord 1 point dans chaque
-// caractéristique, puis on ajoute des crans (pondérés par l'archétype) et
-// éventuellement des capacités tant que le budget le permet.
+// caractéristique — sauf la Défense, qui part de 0 —, puis on ajoute des
+// crans (pondérés par l'archétype) et éventuellement des capacités tant que
+// le budget le permet.
 func RandomUnit(targetCost float64, archetype Archetype, costs core.Costs) (*GeneratedUnit, error) {
 	if targetCost > costs.MaxTotal {
 		targetCost = costs.MaxTotal
@@ -31,7 +32,7 @@ func RandomUnit(targetCost float64, archetype Archetype, costs core.Costs) (*Gen
 	availableAbilities := append([]core.Ability{}, archetype.Abilities...)
 	abilities := []core.Ability{}
 
-	// Socle minimal : une unité a toujours au moins 1 partout.
+	// Socle minimal : une unité a toujours au moins 1 partout, hors l'armure.
 	stats := core.Stats{Health: 1, Range: 1, Move: 1, Power: 1}
 
 	evaluation, err := core.Evaluate(stats, abilities, costs)
@@ -73,6 +74,8 @@ func RandomUnit(targetCost float64, archetype Archetype, costs core.Costs) (*Gen
 				stats.Move++
 			case 3:
 				stats.Power++
+			case 4:
+				stats.Defense++
 			}
 		}
 
@@ -106,7 +109,7 @@ func RandomUnit(targetCost float64, archetype Archetype, costs core.Costs) (*Gen
 }
 
 func chooseWeightedStat(archetype Archetype) int {
-	totalWeight := archetype.WeightHealth + archetype.WeightRange + archetype.WeightMove + archetype.WeightPower
+	totalWeight := archetype.WeightHealth + archetype.WeightRange + archetype.WeightMove + archetype.WeightPower + archetype.WeightDefense
 
 	r := rand.Intn(totalWeight)
 
@@ -122,6 +125,11 @@ func chooseWeightedStat(archetype Archetype) int {
 	if r < archetype.WeightMove {
 		return 2 // Movement
 	}
+	r -= archetype.WeightMove
 
-	return 3 // Power
+	if r < archetype.WeightPower {
+		return 3 // Power
+	}
+
+	return 4 // Defense
 }
@@ -0,0 +1,476 @@
+package scenario
+
+import (
+	"embed"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+	"github.com/bornholm/escarmouche/pkg/sim"
+	"github.com/pkg/errors"
+	"go.yaml.in/yaml/v3"
+)
+
+/* =============================================================================
+   Scénarios.
+
+   Un scénario décrit une table de jeu prête à l'emploi : plateau, zones de
+   capture, zones de déploiement, obstacles fixes, terrain, règles de
+   capture, mission, économie d'actions, combat aux dés, moral, brouillard
+   de guerre, équipes et limite de tours. Il se traduit en OptionFunc pour
+   sim.NewGame (cf. Options) : le moteur ne connaît que la géométrie et les
+   règles, jamais le scénario lui-même.
+
+   Un scénario à 3 ou 4 joueurs (board.players, ou une zone de déploiement
+   par joueur) se joue avec sim.NewMultiplayerGame.
+
+   Un champ absent garde le réglage publié : un fichier vide décrit la partie
+   classique. Sans obstacle déclaré, chaque joueur pose le sien pendant la
+   mise en place (ou au hasard, cf. sim.NewGame).
+   ========================================================================== */
+
+type Scenario struct {
+	ID          string
+	Label       core.Text `yaml:"label"`
+	Description core.Text `yaml:"description"`
+	Board       Board     `yaml:"board"`
+	// Obstacles : murs fixes du scénario.
+	Obstacles []Cell `yaml:"obstacles"`
+	// Terrain : rectangles de terrain typé (cf. sim.Terrain).
+	Terrain      []TerrainArea `yaml:"terrain"`
+	CaptureRules *CaptureRules `yaml:"captureRules"`
+	// ActionRules : économie d'actions, activation alternée comprise (cf.
+	// sim.ActionRules). Absentes = règle publiée.
+	ActionRules *ActionRules `yaml:"actionRules"`
+	// CombatRules : attaques tirées aux dés, armure qui annule les coups (cf.
+	// sim.CombatRules). Absentes = combat sans dés, tout coup porté inflige
+	// au moins un point.
+	CombatRules *CombatRules `yaml:"combatRules"`
+	// MoraleRules : escouades ébranlées par leurs pertes (cf.
+	// sim.MoraleRules). Absentes = sans moral.
+	MoraleRules *MoraleRules `yaml:"moraleRules"`
+	// FogOfWar : chaque joueur ne voit que les ennemis en ligne de vue de ses
+	// unités (cf. sim.WithFogOfWar).
+	FogOfWar bool `yaml:"fogOfWar"`
+	// Teams : joueurs alliés, par équipe (cf. sim.WithTeams). Vide = chacun
+	// pour soi.
+	Teams [][]sim.PlayerID `yaml:"teams"`
+	// Mission : condition de victoire (cf. sim.VictoryCondition). Absente =
+	// capture.
+	Mission *Mission `yaml:"mission"`
+	// MaxTurns : limite de tours. 0 = limite par défaut du moteur.
+	MaxTurns uint `yaml:"maxTurns"`
+}
+
+// Mission désigne une condition de victoire déclarée par son nom (cf.
+// sim.RegisterVictoryCondition) ; les autres clés sont ses paramètres.
+type Mission struct {
+	Type   string         `yaml:"type"`
+	Params map[string]any `yaml:",inline"`
+}
+
+type Board struct {
+	Width  int `yaml:"width"`
+	Height int `yaml:"height"`
+	// Objectives : zones de capture. Vide = zone centrale.
+	Objectives []sim.Area `yaml:"objectives"`
+	// Deployment : zones de déploiement par joueur. Vide = DeploymentDepth
+	// rangées de chaque côté.
+	Deployment      []Deployment `yaml:"deployment"`
+	DeploymentDepth int          `yaml:"deploymentDepth"`
+	// Players : nombre de joueurs, un par bord (cf. sim.BoardLayout.Players).
+	// 0 = 2, ou le nombre de joueurs des zones de déploiement données.
+	Players int `yaml:"players"`
+}
+
+type Deployment struct {
+	Player sim.PlayerID `yaml:"player"`
+	Zones  []sim.Area   `yaml:"zones"`
+}
+
+type Cell struct {
+	X int `yaml:"x"`
+	Y int `yaml:"y"`
+}
+
+// TerrainArea type un rectangle de cases. Largeur et hauteur valent 1 par
+// défaut.
+type TerrainArea struct {
+	sim.Area `yaml:",inline"`
+	Type     sim.Terrain `yaml:"type"`
+}
+
+type CaptureRules struct {
+	PointsToWin   int  `yaml:"pointsToWin"`
+	HoldOffRounds int  `yaml:"holdOffRounds"`
+	ContestSteals bool `yaml:"contestSteals"`
+}
+
+type ActionRules struct {
+	Base        int  `yaml:"base"`
+	PerUnits    int  `yaml:"perUnits"`
+	Alternating bool `yaml:"alternating"`
+}
+
+type CombatRules struct {
+	HitOn       int  `yaml:"hitOn"`
+	RollDamage  bool `yaml:"rollDamage"`
+	ArmorBlocks bool `yaml:"armorBlocks"`
+}
+
+type MoraleRules struct {
+	BreakPoint float64 `yaml:"breakPoint"`
+	PassOn     int     `yaml:"passOn"`
+}
+
+// Layout renvoie la géométrie du plateau du scénario.
+func (s Scenario) Layout() sim.BoardLayout {
+	layout := sim.BoardLayout{
+		Width:           s.Board.Width,
+		Height:          s.Board.Height,
+		ObjectiveZones:  s.Board.Objectives,
+		DeploymentDepth: s.Board.DeploymentDepth,
+		Players:         s.Board.Players,
+	}
+
+	if len(s.Board.Deployment) > 0 {
+		layout.DeploymentZones = map[sim.PlayerID][]sim.Area{}
+		for _, d := range s.Board.Deployment {
+			layout.DeploymentZones[d.Player] = append(layout.DeploymentZones[d.Player], d.Zones...)
+		}
+	}
+
+	return layout.Normalize()
+}
+
+// Players renvoie le nombre de joueurs du scénario : autant d'escouades à
+// fournir à sim.NewMultiplayerGame.
+func (s Scenario) Players() int {
+	return s.Layout().Players
+}
+
+// ObstaclePositions renvoie les obstacles fixes du scénario.
+func (s Scenario) ObstaclePositions() []sim.Position {
+	positions := make([]sim.Position, 0, len(s.Obstacles))
+	for _, c := range s.Obstacles {
+		positions = append(positions, sim.Position{X: c.X, Y: c.Y})
+	}
+	return positions
+}
+
+// TerrainCells déplie les rectangles de terrain en cases.
+func (s Scenario) TerrainCells() []sim.TerrainCell {
+	cells := make([]sim.TerrainCell, 0)
+	for _, t := range s.Terrain {
+		area := t.Area
+		if area.Width <= 0 {
+			area.Width = 1
+		}
+		if area.Height <= 0 {
+			area.Height = 1
+		}
+		for _, pos := range area.Positions() {
+			cells = append(cells, sim.TerrainCell{Position: pos, Type: t.Type})
+		}
+	}
+	return cells
+}
+
+// Victory renvoie la condition de victoire du scénario, nil pour la capture.
+func (s Scenario) Victory() (sim.VictoryCondition, error) {
+	if s.Mission == nil {
+		return nil, nil
+	}
+
+	params, err := json.Marshal(s.Mission.Params)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	condition, err := sim.NewVictoryCondition(s.Mission.Type, params)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return condition, nil
+}
+
+// MissionName renvoie le nom de la condition de victoire du scénario.
+func (s Scenario) MissionName() string {
+	if s.Mission == nil {
+		return sim.CaptureVictory{}.Name()
+	}
+	return s.Mission.Type
+}
+
+// Validate vérifie que le scénario est jouable : plateau valide, obstacles
+// sur des emplacements autorisés, terrain connu et dans le plateau, équipes
+// formées de joueurs de la partie.
+func (s Scenario) Validate() error {
+	for _, d := range s.Board.Deployment {
+		if d.Player < sim.PlayerOne || d.Player > sim.PlayerFour {
+			return errors.Errorf("unknown player %d in deployment zones", d.Player)
+		}
+	}
+
+	// Les zones de déploiement données fixent à elles seules le nombre de
+	// joueurs.
+	if s.Board.Players != 0 && len(s.Board.Deployment) > 0 {
+		return errors.New("board players and deployment zones are mutually exclusive")
+	}
+	if s.Board.Players != 0 && (s.Board.Players < 2 || s.Board.Players > sim.MaxPlayers) {
+		return errors.Errorf("a board needs 2 to %d players, got %d", sim.MaxPlayers, s.Board.Players)
+	}
+
+	layout := s.Layout()
+
+	if err := layout.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := sim.ValidateTeams(s.Teams, layout.Players); err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, pos := range s.ObstaclePositions() {
+		if !layout.IsValidObstaclePosition(pos) {
+			return errors.Errorf("invalid obstacle position %s", pos)
+		}
+	}
+
+	for _, cell := range s.TerrainCells() {
+		if err := cell.Type.Validate(); err != nil {
+			return errors.WithStack(err)
+		}
+		if !layout.Contains(cell.Position) {
+			return errors.Errorf("terrain '%s' at %s is out of a %dx%d board", cell.Type, cell.Position, layout.Width, layout.Height)
+		}
+	}
+
+	if s.CaptureRules != nil && s.CaptureRules.PointsToWin < 0 {
+		return errors.Errorf("invalid points to win %d", s.CaptureRules.PointsToWin)
+	}
+
+	if s.CombatRules != nil {
+		if err := s.combatRules().Validate(); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if s.MoraleRules != nil {
+		if err := s.moraleRules().Validate(); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if _, err := s.Victory(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// Options traduit le scénario en réglages de partie. Les stratégies et le
+// déploiement restent à la charge de l'appelant.
+func (s Scenario) Options() []sim.OptionFunc {
+	opts := []sim.OptionFunc{
+		sim.WithBoardLayout(s.Layout()),
+		sim.WithTerrain(s.TerrainCells()...),
+	}
+
+	if len(s.Obstacles) > 0 {
+		opts = append(opts, sim.WithObstacles(s.ObstaclePositions()...))
+	}
+
+	if s.CaptureRules != nil {
+		opts = append(opts, sim.WithCaptureRules(sim.CaptureRules{
+			PointsToWin:   s.CaptureRules.PointsToWin,
+			HoldOffRounds: s.CaptureRules.HoldOffRounds,
+			ContestSteals: s.CaptureRules.ContestSteals,
+		}))
+	}
+
+	if s.ActionRules != nil {
+		opts = append(opts, sim.WithActionRules(sim.ActionRules{
+			Base:        s.ActionRules.Base,
+			PerUnits:    s.ActionRules.PerUnits,
+			Alternating: s.ActionRules.Alternating,
+		}))
+	}
+
+	if s.CombatRules != nil {
+		opts = append(opts, sim.WithCombatRules(s.combatRules()))
+	}
+
+	if s.MoraleRules != nil {
+		opts = append(opts, sim.WithMoraleRules(s.moraleRules()))
+	}
+
+	if s.FogOfWar {
+		opts = append(opts, sim.WithFogOfWar(true))
+	}
+
+	if len(s.Teams) > 0 {
+		opts = append(opts, sim.WithTeams(s.Teams...))
+	}
+
+	// Validate a écarté les missions inconnues.
+	if victory, err := s.Victory(); err == nil && victory != nil {
+		opts = append(opts, sim.WithVictoryCondition(victory))
+	}
+
+	if s.MaxTurns > 0 {
+		opts = append(opts, sim.WithMaxTurns(s.MaxTurns))
+	}
+
+	return opts
+}
+
+func (s Scenario) combatRules() sim.CombatRules {
+	return sim.CombatRules{
+		HitOn:       s.CombatRules.HitOn,
+		RollDamage:  s.CombatRules.RollDamage,
+		ArmorBlocks: s.CombatRules.ArmorBlocks,
+	}
+}
+
+func (s Scenario) moraleRules() sim.MoraleRules {
+	return sim.MoraleRules{
+		BreakPoint: s.MoraleRules.BreakPoint,
+		PassOn:     s.MoraleRules.PassOn,
+	}
+}
+
+// Parse lit un scénario au format YAML.
+func Parse(r io.Reader) (Scenario, error) {
+	scenario := Scenario{}
+
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(&scenario); err != nil && !errors.Is(err, io.EOF) {
+		return Scenario{}, errors.WithStack(err)
+	}
+
+	if err := scenario.Validate(); err != nil {
+		return Scenario{}, errors.WithStack(err)
+	}
+
+	return scenario, nil
+}
+
+// Load lit un fichier de scénario. L'identifiant est le nom du fichier, sans
+// extension.
+func Load(path string) (Scenario, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Scenario{}, errors.WithStack(err)
+	}
+
+	defer f.Close()
+
+	scenario, err := Parse(f)
+	if err != nil {
+		return Scenario{}, errors.Wrapf(err, "could not parse scenario file '%s'", path)
+	}
+
+	scenario.ID = fileID(path)
+
+	return scenario, nil
+}
+
+//go:embed scenarios/*.yml
+var scenariosFS embed.FS
+
+var (
+	scenarios map[string]Scenario
+	loadOnce  sync.Once
+)
+
+func loadScenarios() {
+	loadOnce.Do(func() {
+		scenarios = map[string]Scenario{}
+
+		files, err := fs.Glob(scenariosFS, "scenarios/*.yml")
+		if err != nil {
+			panic(errors.Wrap(err, "could not find scenarios"))
+		}
+
+		for _, f := range files {
+			scenario, err := parseScenarioFile(f)
+			if err != nil {
+				panic(errors.Wrapf(err, "could not parse scenario file '%s'", f))
+			}
+
+			scenarios[scenario.ID] = scenario
+		}
+	})
+}
+
+func parseScenarioFile(path string) (Scenario, error) {
+	f, err := scenariosFS.Open(path)
+	if err != nil {
+		return Scenario{}, errors.WithStack(err)
+	}
+
+	defer f.Close()
+
+	scenario, err := Parse(f)
+	if err != nil {
+		return Scenario{}, errors.WithStack(err)
+	}
+
+	scenario.ID = fileID(path)
+
+	return scenario, nil
+}
+
+func fileID(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// Get renvoie un scénario embarqué.
+func Get(id string) (Scenario, bool) {
+	loadScenarios()
+
+	scenario, exists := scenarios[id]
+
+	return scenario, exists
+}
+
+// All renvoie les scénarios embarqués, triés par identifiant.
+func All() []Scenario {
+	loadScenarios()
+
+	all := make([]Scenario, 0, len(scenarios))
+	for _, scenario := range scenarios {
+		all = append(all, scenario)
+	}
+
+	slices.SortFunc(all, func(a, b Scenario) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	return all
+}
+
+// Resolve renvoie le scénario embarqué d'identifiant ref, ou à défaut lit le
+// fichier de chemin ref.
+func Resolve(ref string) (Scenario, error) {
+	if scenario, exists := Get(ref); exists {
+		return scenario, nil
+	}
+
+	scenario, err := Load(ref)
+	if err != nil {
+		return Scenario{}, errors.WithStack(err)
+	}
+
+	return scenario, nil
+}
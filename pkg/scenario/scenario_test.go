@@ -0,0 +1,147 @@
+package scenario
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+	"github.com/bornholm/escarmouche/pkg/sim"
+)
+
+func scenarioTestSquad() []sim.Unit {
+	return []sim.Unit{
+		{Stats: core.Stats{Health: 3, Range: 1, Move: 2, Power: 2}},
+		{Stats: core.Stats{Health: 2, Range: 3, Move: 1, Power: 1}},
+		{Stats: core.Stats{Health: 4, Range: 1, Move: 1, Power: 1}},
+	}
+}
+
+func TestEmbeddedScenarios(t *testing.T) {
+	all := All()
+	if len(all) == 0 {
+		t.Fatal("expected embedded scenarios")
+	}
+
+	for _, scenario := range all {
+		if scenario.Label.String() == "" {
+			t.Errorf("%s: expected a label", scenario.ID)
+		}
+
+		layout := scenario.Layout()
+
+		squads := make([][]sim.Unit, scenario.Players())
+		for i := range squads {
+			squads[i] = scenarioTestSquad()
+		}
+
+		opts := append(scenario.Options(), sim.WithSeed(5))
+		game := sim.NewMultiplayerGame(squads, opts...)
+		if err := game.Validate(); err != nil {
+			t.Fatalf("%s: %+v", scenario.ID, err)
+		}
+
+		state := game.State()
+		if !reflect.DeepEqual(layout, state.Layout) {
+			t.Errorf("%s: board: expected %+v, got %+v", scenario.ID, layout, state.Layout)
+		}
+		mission := sim.CaptureVictory{}.Name()
+		if state.Victory != nil {
+			mission = state.Victory.Name()
+		}
+		if e, g := scenario.MissionName(), mission; e != g {
+			t.Errorf("%s: mission: expected '%s', got '%s'", scenario.ID, e, g)
+		}
+		for _, pos := range scenario.ObstaclePositions() {
+			if !state.HasObstacle(pos) {
+				t.Errorf("%s: expected an obstacle at %s", scenario.ID, pos)
+			}
+		}
+		for _, cell := range scenario.TerrainCells() {
+			if state.HasObstacle(cell.Position) {
+				continue
+			}
+			if e, g := cell.Type, state.TerrainAt(cell.Position); e != g {
+				t.Errorf("%s: terrain at %s: expected '%s', got '%s'", scenario.ID, cell.Position, e, g)
+			}
+		}
+
+		for range game.Run() {
+		}
+
+		if _, err := sim.Replay(game.Record()); err != nil {
+			t.Errorf("%s: %+v", scenario.ID, err)
+		}
+	}
+}
+
+func TestClassicScenario(t *testing.T) {
+	classic, exists := Get("classic")
+	if !exists {
+		t.Fatal("expected the classic scenario to exist")
+	}
+
+	if e, g := sim.DefaultBoardLayout, classic.Layout(); !reflect.DeepEqual(e, g) {
+		t.Errorf("expected the published board %+v, got %+v", e, g)
+	}
+}
+
+func TestParse(t *testing.T) {
+	type testCase struct {
+		Name          string
+		Content       string
+		ExpectedError bool
+	}
+
+	testCases := []testCase{
+		{Name: "empty", Content: ""},
+		{
+			Name: "zones",
+			Content: `
+board:
+  width: 10
+  height: 8
+  objectives:
+    - { x: 1, y: 3, width: 2, height: 2 }
+    - { x: 7, y: 3, width: 2, height: 2 }
+  deployment:
+    - player: 0
+      zones: [{ x: 2, y: 0, width: 6, height: 2 }]
+    - player: 1
+      zones: [{ x: 2, y: 6, width: 6, height: 2 }]
+terrain:
+  - { x: 4, y: 3, width: 2, height: 2, type: difficult }
+actionRules:
+  perUnits: 3
+maxTurns: 30
+`,
+		},
+		{Name: "mission", Content: "mission: { type: zone-control, required: 1 }"},
+		{Name: "dice", Content: "combatRules: { hitOn: 3, rollDamage: true }"},
+		{Name: "morale", Content: "moraleRules: { breakPoint: 0.5, passOn: 4 }"},
+		{Name: "armor blocks", Content: "combatRules: { armorBlocks: true }"},
+		{Name: "alternating activation", Content: "actionRules: { alternating: true }"},
+		{Name: "fog of war", Content: "fogOfWar: true"},
+		{Name: "four players", Content: "board: { width: 10, height: 10, players: 4 }"},
+		{Name: "teams", Content: "board: { width: 10, height: 10, players: 4 }\nteams: [[0, 2], [1, 3]]"},
+		{Name: "team of an absent player", Content: "teams: [[0, 2]]", ExpectedError: true},
+		{Name: "player in two teams", Content: "board: { width: 10, height: 10, players: 3 }\nteams: [[0, 1], [1, 2]]", ExpectedError: true},
+		{Name: "too many players", Content: "board: { players: 5 }", ExpectedError: true},
+		{Name: "invalid hit roll", Content: "combatRules: { hitOn: 7 }", ExpectedError: true},
+		{Name: "invalid morale break point", Content: "moraleRules: { breakPoint: 1.5 }", ExpectedError: true},
+		{Name: "unknown field", Content: "boards: {}", ExpectedError: true},
+		{Name: "unknown mission", Content: "mission: { type: king-of-the-hill }", ExpectedError: true},
+		{Name: "invalid mission parameters", Content: "mission: { type: kill-points, share: half }", ExpectedError: true},
+		{Name: "unknown terrain", Content: "terrain: [{ x: 0, y: 3, type: lava }]", ExpectedError: true},
+		{Name: "terrain out of the board", Content: "terrain: [{ x: 8, y: 3, type: difficult }]", ExpectedError: true},
+		{Name: "obstacle in a zone", Content: "obstacles: [{ x: 3, y: 3 }]", ExpectedError: true},
+		{Name: "unknown player", Content: "board: { deployment: [{ player: 2, zones: [{ x: 0, y: 0, width: 1, height: 1 }] }] }", ExpectedError: true},
+	}
+
+	for _, tc := range testCases {
+		_, err := Parse(strings.NewReader(tc.Content))
+		if (err != nil) != tc.ExpectedError {
+			t.Errorf("%s: unexpected result: %v", tc.Name, err)
+		}
+	}
+}
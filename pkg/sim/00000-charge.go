@@ -13,7 +13,7 @@ func getPossibleCharges(state GameState, unit *PlayerUnit) []Action {
 		return actions
 	}
 
-	currentPos := state.Positions[unit.ID]
+	currentPos := state.PositionOf(unit.ID)
 
 	// Get all reachable positions using the new pathfinding system
 	reachablePositions := getReachablePositions(state, currentPos, unit.Stats.Move)
@@ -27,9 +27,7 @@ func getPossibleCharges(state GameState, unit *PlayerUnit) []Action {
 			tp := targetPos
 			tid := targetID
 			chargeAction := NewAbilityAction("00000-charge", func(state GameState, action Action) GameState {
-				delete(state.Board, state.Positions[unit.ID].String())
-				state.Positions[unit.ID] = tp
-				state.Board[tp.String()] = unit.ID
+				state.MoveUnit(unit.ID, tp)
 				state, _ = applyDamage(state, tid, 1)
 				state.Inc(unit.ID, CounterRoundAbilities, 1)
 				return state
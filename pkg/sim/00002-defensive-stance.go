@@ -1,37 +0,0 @@
-package sim
-
-func init() {
-	registerAbility("00002-defensive-stance", getPossibleDefensiveStances)
-}
-
-func getPossibleDefensiveStances(state GameState, unit *PlayerUnit) []Action {
-	actions := []Action{}
-
-	// Check if unit has already used abilities this round
-	roundAbilities := state.Get(unit.ID, CounterRoundAbilities, 0)
-	if roundAbilities > 0 {
-		return actions
-	}
-
-	// Check if unit already has defensive stance active (cannot stack)
-	defensiveStance := state.Get(unit.ID, CounterDefensiveStance, 0)
-	if defensiveStance > 0 {
-		return actions
-	}
-
-	defensiveStanceAction := NewAbilityAction("00002-defensive-stance", func(state GameState, action Action) GameState {
-		state.Set(unit.ID, CounterDefensiveStance, 1)
-		state.Inc(unit.ID, CounterRoundAbilities, 1)
-		return state
-	}, &AbilityActionDescription{
-		ID:           "00002-defensive-stance",
-		SourceUnitID: unit.ID,
-		TargetUnitID: -1,
-		TargetX:      -1,
-		TargetY:      -1,
-	})
-
-	actions = append(actions, defensiveStanceAction)
-
-	return actions
-}
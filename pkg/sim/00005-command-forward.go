@@ -1,45 +1,116 @@
 package sim
 
+import "slices"
+
 func init() {
 	registerAbility("00005-command-forward", getPossibleCommandForwards)
 }
 
+// commandForwardPairDestinations : destinations retenues pour chaque allié
+// d'un ordre donné à deux unités. Toutes les combinaisons de destinations
+// multiplieraient les actions (une vingtaine de cases par allié) et
+// noieraient la recherche : chaque allié n'y garde que les cases qui le
+// rapprochent le plus de l'ennemi, l'avance étant l'usage de la carte.
+const commandForwardPairDestinations = 3
+
+// getPossibleCommandForwards : « choisissez jusqu'à 2 unités alliées à
+// portée 3, chacune effectue immédiatement une action de mouvement
+// gratuite ». Un ordre à un seul allié propose toutes ses destinations ; un
+// ordre à deux alliés, résolu dans l'ordre de leurs identifiants, combine
+// leurs meilleures destinations (cf. commandForwardPairDestinations).
 func getPossibleCommandForwards(state GameState, unit *PlayerUnit) []Action {
 	if state.Get(unit.ID, CounterRoundAbilities, 0) > 0 {
 		return nil
 	}
 
-	currentPos := state.Positions[unit.ID]
-	actions := make([]Action, 0)
+	currentPos := state.PositionOf(unit.ID)
+	allies := make([]*PlayerUnit, 0)
 
-	for allyID, ally := range state.Units {
-		if ally.OwnerID != unit.OwnerID || allyID == unit.ID {
+	for ally := range state.Units() {
+		if ally.OwnerID != unit.OwnerID || ally.ID == unit.ID {
 			continue
 		}
-		allyPos := state.Positions[allyID]
-		if int(distance(currentPos, allyPos)) > 3 {
+		if int(distance(currentPos, state.PositionOf(ally.ID))) > 3 {
 			continue
 		}
+		allies = append(allies, ally)
+	}
+
+	actions := make([]Action, 0)
+
+	for _, ally := range allies {
+		for _, targetPos := range getReachablePositions(state, state.PositionOf(ally.ID), ally.Stats.Move) {
+			actions = append(actions, newCommandForward(unit, []AbilityChoice{
+				{UnitID: ally.ID, X: targetPos.X, Y: targetPos.Y},
+			}))
+		}
+	}
 
-		reachable := getReachablePositions(state, allyPos, ally.Stats.Move)
-		for _, targetPos := range reachable {
-			tp := targetPos
-			aid := allyID
-			action := NewAbilityAction("00005-command-forward", func(state GameState, _ Action) GameState {
-				delete(state.Board, state.Positions[aid].String())
-				state.Positions[aid] = tp
-				state.Board[tp.String()] = aid
-				state.Inc(unit.ID, CounterRoundAbilities, 1)
-				return state
-			}, &AbilityActionDescription{
-				ID:           "00005-command-forward",
-				SourceUnitID: unit.ID,
-				TargetUnitID: allyID,
-				TargetX:      tp.X,
-				TargetY:      tp.Y,
-			})
-			actions = append(actions, action)
+	for i, first := range allies {
+		firstMoves := forwardMost(state, first, getReachablePositions(state, state.PositionOf(first.ID), first.Stats.Move))
+
+		for _, second := range allies[i+1:] {
+			for _, firstPos := range firstMoves {
+				// Le second allié se déplace sur le plateau où le premier a
+				// déjà bougé : il peut libérer ou barrer un passage.
+				moved := state.Copy()
+				moved.MoveUnit(first.ID, firstPos)
+
+				secondMoves := forwardMost(moved, second, getReachablePositions(moved, moved.PositionOf(second.ID), second.Stats.Move))
+				for _, secondPos := range secondMoves {
+					actions = append(actions, newCommandForward(unit, []AbilityChoice{
+						{UnitID: first.ID, X: firstPos.X, Y: firstPos.Y},
+						{UnitID: second.ID, X: secondPos.X, Y: secondPos.Y},
+					}))
+				}
+			}
 		}
 	}
+
 	return actions
 }
+
+func newCommandForward(unit *PlayerUnit, choices []AbilityChoice) *AbilityAction {
+	return NewAbilityAction("00005-command-forward", func(state GameState, _ Action) GameState {
+		for _, choice := range choices {
+			state.MoveUnit(choice.UnitID, Position{X: choice.X, Y: choice.Y})
+		}
+		state.Inc(unit.ID, CounterRoundAbilities, 1)
+		return state
+	}, &AbilityActionDescription{
+		ID:           "00005-command-forward",
+		SourceUnitID: unit.ID,
+		TargetUnitID: choices[0].UnitID,
+		TargetX:      choices[0].X,
+		TargetY:      choices[0].Y,
+		Choices:      choices[1:],
+	})
+}
+
+// forwardMost garde les commandForwardPairDestinations destinations les plus
+// proches d'une unité ennemie, dans l'ordre de positions à égalité.
+func forwardMost(state GameState, ally *PlayerUnit, positions []Position) []Position {
+	enemies := make([]Position, 0)
+	for u := range state.Units() {
+		if !state.Allied(u.OwnerID, ally.OwnerID) {
+			enemies = append(enemies, state.PositionOf(u.ID))
+		}
+	}
+
+	closest := func(pos Position) int {
+		best := -1
+		for _, enemy := range enemies {
+			if d := int(distance(pos, enemy)); best < 0 || d < best {
+				best = d
+			}
+		}
+		return best
+	}
+
+	ranked := slices.Clone(positions)
+	slices.SortStableFunc(ranked, func(a, b Position) int {
+		return closest(a) - closest(b)
+	})
+
+	return ranked[:min(len(ranked), commandForwardPairDestinations)]
+}
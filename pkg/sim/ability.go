@@ -1,8 +1,11 @@
 package sim
 
 import (
-	"fmt"
+	"maps"
+	"slices"
+	"strings"
 
+	"github.com/bornholm/escarmouche/pkg/core"
 	"github.com/pkg/errors"
 )
 
@@ -20,6 +23,19 @@ type AbilityActionDescription struct {
 	TargetUnitID UnitID
 	TargetX      int
 	TargetY      int
+	// Choices : choix suivants d'une capacité à cibles multiples, dans
+	// l'ordre où ils sont résolus, après la cible principale (TargetUnitID,
+	// TargetX, TargetY). Vide pour une capacité à cible unique.
+	Choices []AbilityChoice
+}
+
+// AbilityChoice : une cible d'une capacité à cibles multiples — une unité,
+// une case, ou les deux (l'allié et sa destination pour l'Ordre d'Avancer).
+// Les champs sans objet valent -1.
+type AbilityChoice struct {
+	UnitID UnitID
+	X      int
+	Y      int
 }
 
 type AbilityAction struct {
@@ -36,9 +52,10 @@ func (a *AbilityAction) Description() *AbilityActionDescription {
 	return a.description
 }
 
-// String implements Action.
+// String implements Action. La forme rendue est la notation canonique (cf.
+// FormatAction), cibles comprises.
 func (a *AbilityAction) String() string {
-	return fmt.Sprintf("ability:%s", a.id)
+	return formatAbility(a)
 }
 
 // Apply implements Action.
@@ -68,37 +85,154 @@ func NewAbilityAction(id string, apply ApplyFunc, desc *AbilityActionDescription
 var _ Action = &AbilityAction{}
 
 type GetValidActionsFunc func(state GameState, unit *PlayerUnit) []Action
+
+// AbilityRegistry associe à chaque identifiant de capacité son
+// implémentation. Les capacités du moteur remplissent le registre par défaut
+// à l'initialisation du paquet ; une partie peut en utiliser un autre (cf.
+// WithAbilityRegistry). Un registre est rempli avant la partie puis
+// seulement lu : plusieurs parties peuvent le partager.
 type AbilityRegistry struct {
 	abilities map[string]GetValidActionsFunc
+	// origins : provenance de chaque implémentation, que le relevé de partie
+	// consigne (cf. GameSetup.Abilities).
+	origins map[string]abilityOrigin
+}
+
+// AbilityOrigin : provenance de l'implémentation d'une capacité.
+type AbilityOrigin string
+
+const (
+	// AbilityNative : capacité codée en Go dans le moteur.
+	AbilityNative AbilityOrigin = "native"
+	// AbilityDeclared : ciblage et effets déclarés (cf. RegisterDeclared).
+	AbilityDeclared AbilityOrigin = "declared"
+	// AbilityScripted : script JavaScript (cf. RegisterScript).
+	AbilityScripted AbilityOrigin = "scripted"
+	// AbilityCustom : fonction fournie par l'appelant (cf. Register). Seul
+	// un registre qui la contient peut rejouer la partie.
+	AbilityCustom AbilityOrigin = "custom"
+)
+
+type abilityOrigin struct {
+	kind AbilityOrigin
+	// ability : capacité déclarée, ou scriptée (Script).
+	ability core.Ability
 }
 
+// Register enregistre une capacité implémentée par une fonction Go.
 func (r *AbilityRegistry) Register(id string, fn GetValidActionsFunc) {
+	r.register(id, fn, abilityOrigin{kind: AbilityCustom})
+}
+
+// RegisterDeclared enregistre une capacité déclarative (cf. core.Targeting),
+// interprétée par le moteur.
+func (r *AbilityRegistry) RegisterDeclared(ability core.Ability) {
+	r.register(ability.ID, declaredAbility(ability), abilityOrigin{kind: AbilityDeclared, ability: ability})
+}
+
+func (r *AbilityRegistry) register(id string, fn GetValidActionsFunc, origin abilityOrigin) {
 	r.abilities[id] = fn
+	r.origins[id] = origin
+}
+
+// Origin renvoie la provenance de l'implémentation de la capacité.
+func (r *AbilityRegistry) Origin(id string) (AbilityOrigin, bool) {
+	origin, exists := r.origins[id]
+	return origin.kind, exists
+}
+
+// Has indique si la capacité est implémentée.
+func (r *AbilityRegistry) Has(id string) bool {
+	_, exists := r.abilities[id]
+	return exists
+}
+
+// IDs renvoie les identifiants des capacités implémentées, triés.
+func (r *AbilityRegistry) IDs() []string {
+	return slices.Sorted(maps.Keys(r.abilities))
 }
 
-var defaultAbilityRegistry = NewAbilityRegistry()
+// Clone renvoie une copie du registre, à compléter ou modifier sans toucher
+// à l'original.
+func (r *AbilityRegistry) Clone() *AbilityRegistry {
+	return &AbilityRegistry{
+		abilities: maps.Clone(r.abilities),
+		origins:   maps.Clone(r.origins),
+	}
+}
+
+// Validate vérifie que toutes les capacités des unités sont implémentées et
+// signale chaque capacité manquante.
+func (r *AbilityRegistry) Validate(units ...Unit) error {
+	var missing []string
+	for _, unit := range units {
+		for _, ability := range unit.Abilities {
+			if !r.Has(ability.ID) && !slices.Contains(missing, ability.ID) {
+				missing = append(missing, ability.ID)
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		return errors.Errorf("no registered implementation for abilities '%s'", strings.Join(missing, "', '"))
+	}
+
+	return nil
+}
+
+var (
+	defaultAbilityRegistry = NewAbilityRegistry()
+	// nativeAbilities : capacités codées en Go, par opposition à celles que
+	// le moteur tire de leur fichier (déclarées ou scriptées).
+	nativeAbilities []string
+)
+
+// DefaultAbilityRegistry renvoie une copie du registre des capacités du
+// moteur.
+func DefaultAbilityRegistry() *AbilityRegistry {
+	return defaultAbilityRegistry.Clone()
+}
 
 func registerAbility(id string, fn GetValidActionsFunc) {
-	defaultAbilityRegistry.Register(id, fn)
+	defaultAbilityRegistry.register(id, fn, abilityOrigin{kind: AbilityNative})
+	nativeAbilities = append(nativeAbilities, id)
+}
+
+// NativeAbilities renvoie les identifiants des capacités codées en Go dans
+// le moteur, triés (cf. core.CheckAbilities).
+func NativeAbilities() []string {
+	return slices.Sorted(slices.Values(nativeAbilities))
+}
+
+// abilityRegistry renvoie le registre de la partie.
+func (s GameState) abilityRegistry() *AbilityRegistry {
+	if s.Abilities != nil {
+		return s.Abilities
+	}
+	return defaultAbilityRegistry
 }
 
 func getPossibleAbilities(state GameState, unit *PlayerUnit) []Action {
-	return defaultAbilityRegistry.GetPossibleActions(state, unit)
+	return state.abilityRegistry().GetPossibleActions(state, unit)
 }
 
 func NewAbilityRegistry() *AbilityRegistry {
 	return &AbilityRegistry{
 		abilities: map[string]GetValidActionsFunc{},
+		origins:   map[string]abilityOrigin{},
 	}
 }
 
+// GetPossibleActions renvoie les actions de capacité de l'unité. Une
+// capacité sans implémentation n'offre aucune action : Validate (ou
+// Game.Validate) la signale avant la partie.
 func (r *AbilityRegistry) GetPossibleActions(state GameState, unit *PlayerUnit) []Action {
 	actions := make([]Action, 0)
 
 	for _, ability := range unit.Abilities {
 		getValidActions, exists := r.abilities[ability.ID]
 		if !exists {
-			panic(errors.Errorf("no registered '%s' ability", ability.ID))
+			continue
 		}
 
 		actions = append(actions, getValidActions(state, unit)...)
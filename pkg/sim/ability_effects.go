@@ -0,0 +1,196 @@
+package sim
+
+import (
+	"github.com/bornholm/escarmouche/pkg/core"
+)
+
+/* =============================================================================
+   Interprète des capacités déclaratives.
+
+   Les capacités dont le fichier YAML déclare un ciblage et des effets (cf.
+   core.Targeting) sont enregistrées ici, sans fichier Go dédié. Le ciblage
+   donne une action par cible possible, dans l'ordre des générateurs du
+   moteur (getReachableOpponentUnits, state.Units, getReachablePositions) ;
+   les effets sont appliqués dans l'ordre déclaré, puis la capacité est
+   comptée pour le tour.
+
+   Deux règles communes remplacent les gardes écrites à la main :
+     - une capacité qui pose sur son lanceur un statut qu'il porte déjà
+       n'est pas proposée (pas de cumul) ;
+     - une capacité sans cible dont aucune zone ne touche d'ennemi n'est pas
+       proposée.
+   ========================================================================== */
+
+func init() {
+	for _, ability := range core.AllAbilities() {
+		if ability.Targeting == nil {
+			continue
+		}
+		defaultAbilityRegistry.RegisterDeclared(ability)
+	}
+}
+
+// abilityTarget : cible retenue pour une action. unit vaut -1 pour une case
+// ou le lanceur lui-même.
+type abilityTarget struct {
+	unit UnitID
+	cell Position
+}
+
+func declaredAbility(ability core.Ability) GetValidActionsFunc {
+	return func(state GameState, unit *PlayerUnit) []Action {
+		if state.Get(unit.ID, CounterRoundAbilities, 0) > 0 {
+			return nil
+		}
+
+		for _, effect := range ability.Effects {
+			if effect.Type == core.EffectStatus && effect.Subject() == core.SubjectSelf &&
+				state.Get(unit.ID, effect.Status, 0) > 0 {
+				return nil
+			}
+		}
+
+		targets := declaredTargets(state, unit, ability)
+
+		actions := make([]Action, 0, len(targets))
+		for _, target := range targets {
+			desc := &AbilityActionDescription{
+				ID:           ability.ID,
+				SourceUnitID: unit.ID,
+				TargetUnitID: target.unit,
+				TargetX:      -1,
+				TargetY:      -1,
+			}
+			if ability.Targeting.Type == core.TargetCell {
+				desc.TargetX, desc.TargetY = target.cell.X, target.cell.Y
+			}
+
+			action := NewAbilityAction(ability.ID, func(state GameState, _ Action) GameState {
+				for _, effect := range ability.Effects {
+					state = applyEffect(state, unit, target, effect)
+				}
+				state.Inc(unit.ID, CounterRoundAbilities, 1)
+				return state
+			}, desc)
+			actions = append(actions, action)
+		}
+
+		return actions
+	}
+}
+
+func declaredTargets(state GameState, unit *PlayerUnit, ability core.Ability) []abilityTarget {
+	targeting := ability.Targeting
+	currentPos := state.PositionOf(unit.ID)
+	reach := targeting.Range.Resolve(unit.Stats, -1)
+
+	switch targeting.Type {
+	case core.TargetSelf:
+		target := abilityTarget{unit: -1, cell: currentPos}
+		hasArea, hitsEnemy := false, false
+		for _, effect := range ability.Effects {
+			if effect.Area > 0 {
+				hasArea = true
+				hitsEnemy = hitsEnemy || len(effectArea(state, unit, currentPos, effect.Area)) > 0
+			}
+		}
+		if hasArea && !hitsEnemy {
+			return nil
+		}
+		return []abilityTarget{target}
+
+	case core.TargetEnemy:
+		var enemies []UnitID
+		if targeting.LineOfSight {
+			enemies = getReachableOpponentUnits(state, unit.OwnerID, currentPos, reach)
+		} else {
+			enemies = getOpponentsInRange(state, unit.OwnerID, currentPos, reach)
+		}
+		targets := make([]abilityTarget, 0, len(enemies))
+		for _, id := range enemies {
+			targets = append(targets, abilityTarget{unit: id, cell: state.PositionOf(id)})
+		}
+		return targets
+
+	case core.TargetAlly:
+		targets := make([]abilityTarget, 0)
+		for ally := range state.Units() {
+			if ally.OwnerID != unit.OwnerID || ally.ID == unit.ID {
+				continue
+			}
+			allyPos := state.PositionOf(ally.ID)
+			if int(distance(currentPos, allyPos)) > reach {
+				continue
+			}
+			if targeting.LineOfSight && !hasLineOfSight(state, currentPos, allyPos) {
+				continue
+			}
+			targets = append(targets, abilityTarget{unit: ally.ID, cell: allyPos})
+		}
+		return targets
+
+	case core.TargetCell:
+		if targeting.Range.IsZero() {
+			reach = unit.Stats.Move
+		}
+		positions := getReachablePositions(state, currentPos, reach)
+		targets := make([]abilityTarget, 0, len(positions))
+		for _, pos := range positions {
+			targets = append(targets, abilityTarget{unit: -1, cell: pos})
+		}
+		return targets
+	}
+
+	return nil
+}
+
+// effectArea renvoie les ennemis du lanceur à distance radius du centre.
+func effectArea(state GameState, unit *PlayerUnit, center Position, radius int) []UnitID {
+	return getOpponentsInRange(state, unit.OwnerID, center, radius)
+}
+
+// applyEffect applique un effet. Mute l'état reçu — cf. la convention
+// décrite sur Kill.
+func applyEffect(state GameState, unit *PlayerUnit, target abilityTarget, effect core.Effect) GameState {
+	subjects := []UnitID{target.unit}
+	if effect.Subject() == core.SubjectSelf {
+		subjects = []UnitID{unit.ID}
+	}
+	if effect.Area > 0 {
+		center := target.cell
+		if effect.Subject() == core.SubjectSelf {
+			center = state.PositionOf(unit.ID)
+		}
+		subjects = effectArea(state, unit, center, effect.Area)
+	}
+
+	switch effect.Type {
+	case core.EffectDamage, core.EffectSelfDamage:
+		amount := effect.Amount.Resolve(unit.Stats, int(target.unit))
+		for _, id := range subjects {
+			state, _ = applyDamage(state, id, amount)
+		}
+
+	case core.EffectMove:
+		state.MoveUnit(unit.ID, target.cell)
+
+	case core.EffectSwap:
+		state.SwapUnits(unit.ID, target.unit)
+
+	case core.EffectStatus:
+		value := 1
+		if effect.Value != nil {
+			value = effect.Value.Resolve(unit.Stats, int(target.unit))
+		}
+		for _, id := range subjects {
+			// Un statut ne se pose pas sur une unité éliminée par un effet
+			// précédent.
+			if state.Unit(id) == nil {
+				continue
+			}
+			state.Set(id, effect.Status, value)
+		}
+	}
+
+	return state
+}
@@ -0,0 +1,94 @@
+package sim
+
+import (
+	"testing"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+)
+
+func TestDeclaredAbilityStatuses(t *testing.T) {
+	for _, ability := range core.AllAbilities() {
+		for _, effect := range ability.Effects {
+			if effect.Type != core.EffectStatus {
+				continue
+			}
+			if _, exists := LookupStatus(effect.Status); !exists {
+				t.Errorf("%s: unknown status '%s'", ability.ID, effect.Status)
+			}
+		}
+	}
+}
+
+func TestDeclaredAbility(t *testing.T) {
+	// Frappe de zone : 1 point à tous les ennemis adjacents à la cible,
+	// puis 1 point au lanceur.
+	ability := core.Ability{
+		ID:        "test-blast",
+		Targeting: &core.Targeting{Type: core.TargetEnemy, Range: core.Quantity{Ref: core.QuantityRange}, LineOfSight: true},
+		Effects: []core.Effect{
+			{Type: core.EffectDamage, Amount: core.Quantity{Ref: core.QuantityPower}},
+			{Type: core.EffectDamage, Amount: core.Quantity{Value: 1}, Area: 1},
+			{Type: core.EffectSelfDamage, Amount: core.Quantity{Value: 1}},
+		},
+	}
+
+	stats := core.Stats{Health: 3, Range: 2, Move: 1, Power: 2}
+
+	state := NewGameState(BoardLayout{})
+	caster := &PlayerUnit{ID: 0, OwnerID: PlayerOne, Unit: Unit{Stats: stats}}
+	state.AddUnit(caster, Position{X: 1, Y: 1})
+	state.AddUnit(&PlayerUnit{ID: 1, OwnerID: PlayerTwo, Unit: Unit{Stats: stats}}, Position{X: 3, Y: 1})
+	state.AddUnit(&PlayerUnit{ID: 2, OwnerID: PlayerTwo, Unit: Unit{Stats: stats}}, Position{X: 4, Y: 1})
+	state.AddUnit(&PlayerUnit{ID: 3, OwnerID: PlayerTwo, Unit: Unit{Stats: stats}}, Position{X: 6, Y: 6})
+	for id := range UnitID(4) {
+		state.Set(id, CounterHealth, 3)
+	}
+
+	actions := declaredAbility(ability)(state, caster)
+	if e, g := 1, len(actions); e != g {
+		t.Fatalf("expected %d action, got %d", e, g)
+	}
+	if e, g := UnitID(1), actions[0].(*AbilityAction).Description().TargetUnitID; e != g {
+		t.Errorf("expected target %d, got %d", e, g)
+	}
+
+	state = actions[0].Apply(state)
+
+	expected := map[UnitID]int{0: 2, 2: 2, 3: 3}
+	for id, health := range expected {
+		if g := state.Get(id, CounterHealth, 0); g != health {
+			t.Errorf("unit %d: expected health %d, got %d", id, health, g)
+		}
+	}
+	if state.Unit(1) != nil {
+		t.Error("expected unit 1 to be killed")
+	}
+	if e, g := 1, state.Get(0, CounterRoundAbilities, 0); e != g {
+		t.Errorf("expected %d ability this round, got %d", e, g)
+	}
+
+	if actions := declaredAbility(ability)(state, caster); len(actions) != 0 {
+		t.Errorf("expected no action after using an ability, got %v", actions)
+	}
+}
+
+func TestDeclaredAbilitiesRegistered(t *testing.T) {
+	registry := DefaultAbilityRegistry()
+	for _, ability := range core.AllAbilities() {
+		if ability.Targeting == nil {
+			continue
+		}
+		if origin, exists := registry.Origin(ability.ID); !exists || origin != AbilityDeclared {
+			t.Errorf("%s: expected a declared implementation, got '%s'", ability.ID, origin)
+		}
+	}
+}
+
+// getPossibleDefensiveStances : actions de la Posture Défensive, capacité
+// déclarée dans son fichier YAML, telles que le registre par défaut les
+// propose.
+func getPossibleDefensiveStances(state GameState, unit *PlayerUnit) []Action {
+	stance := *unit
+	stance.Abilities = core.Abilities("00002-defensive-stance")
+	return DefaultAbilityRegistry().GetPossibleActions(state, &stance)
+}
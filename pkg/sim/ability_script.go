@@ -0,0 +1,423 @@
+package sim
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+	"github.com/dop251/goja"
+	"github.com/pkg/errors"
+)
+
+/* =============================================================================
+   Capacités scriptées.
+
+   Une capacité peut livrer à côté de son YAML un script JavaScript (cf.
+   core.Ability.Script), exécuté par goja. Le script définit deux fonctions :
+
+     function getValidActions(state, unit) {
+       // une action par cible : { target, x, y }, champs facultatifs
+       return [{ target: 3 }]
+     }
+
+     function apply(state, action) {
+       // action : { unit, target, x, y }
+       state.applyDamage(action.target, 2)
+     }
+
+   state est une façade sur GameState (cf. newScriptFacade) : lecture du
+   plateau et des compteurs, ligne de vue ; apply seul peut en outre
+   déplacer, blesser et écrire les compteurs. Le script n'a accès à rien
+   d'autre, et n'écrit que des compteurs déjà connus du moteur (compteurs
+   du moteur et statuts, cf. RegisterStatus). La capacité est comptée pour
+   le tour après apply, et n'est plus proposée ensuite, comme les autres.
+
+   Un script fautif — exception, résultat mal formé, appel qui épuise son
+   budget de pas (cf. core.ScriptGuard) — ne fait pas tomber la partie : l'erreur est journalisée
+   et la capacité ne propose aucune action, ou reste sans effet. apply
+   travaille sur une copie de l'état, qui n'est retenue qu'en cas de succès.
+   Les scripts du catalogue sont vérifiés à la lecture (cf.
+   core.CheckAbilities).
+
+   Math.random est retiré : une partie doit pouvoir être rejouée à
+   l'identique (cf. Replay). Chaque goroutine exécute le script dans son
+   propre runtime (cf. scriptPool), la recherche et le balancer pouvant
+   jouer plusieurs parties en parallèle.
+   ========================================================================== */
+
+func init() {
+	for _, ability := range core.AllAbilities() {
+		if ability.Script == "" {
+			continue
+		}
+		// Le script a été vérifié à la lecture du catalogue : un échec ici
+		// laisse la capacité sans implémentation, ce que signale Validate.
+		if err := defaultAbilityRegistry.RegisterScript(ability.ID, ability.Script); err != nil {
+			log.Printf("Could not load script of ability '%s': %+v", ability.ID, err)
+		}
+	}
+}
+
+// RegisterScript enregistre une capacité implémentée en JavaScript.
+func (r *AbilityRegistry) RegisterScript(id string, source string) error {
+	pool, err := newScriptPool(id, source)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	r.register(id, pool.getValidActions, abilityOrigin{kind: AbilityScripted, ability: core.Ability{ID: id, Script: source}})
+
+	return nil
+}
+
+// scriptPool garde des runtimes prêts à l'emploi pour un script : un
+// runtime goja ne se partage pas entre goroutines.
+type scriptPool struct {
+	id      string
+	program *goja.Program
+	pool    sync.Pool
+	// failed : une erreur a déjà été journalisée, les suivantes sont tues
+	// (la recherche appelle le script des milliers de fois).
+	failed atomic.Bool
+}
+
+type scriptRuntime struct {
+	vm              *goja.Runtime
+	guard           *core.ScriptGuard
+	getValidActions goja.Callable
+	apply           goja.Callable
+}
+
+func newScriptPool(id string, source string) (*scriptPool, error) {
+	program, err := core.CompileScript(id+".js", source)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	p := &scriptPool{id: id, program: program}
+
+	// Un premier runtime vérifie que le script définit bien ses fonctions.
+	runtime, err := p.newRuntime()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	p.pool.Put(runtime)
+
+	return p, nil
+}
+
+func (p *scriptPool) newRuntime() (*scriptRuntime, error) {
+	vm := goja.New()
+
+	guard, err := core.NewScriptGuard(vm)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if _, err := vm.RunString(`Math.random = function() { throw new Error("Math.random is not available to abilities") }`); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if _, err := guard.Run(func() (goja.Value, error) { return vm.RunProgram(p.program) }); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	runtime := &scriptRuntime{vm: vm, guard: guard}
+
+	var ok bool
+	if runtime.getValidActions, ok = goja.AssertFunction(vm.Get("getValidActions")); !ok {
+		return nil, errors.New("missing 'getValidActions' function")
+	}
+	if runtime.apply, ok = goja.AssertFunction(vm.Get("apply")); !ok {
+		return nil, errors.New("missing 'apply' function")
+	}
+
+	return runtime, nil
+}
+
+func (p *scriptPool) acquire() (*scriptRuntime, error) {
+	if runtime, ok := p.pool.Get().(*scriptRuntime); ok {
+		return runtime, nil
+	}
+
+	runtime, err := p.newRuntime()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not start script")
+	}
+
+	return runtime, nil
+}
+
+// report journalise la première erreur du script.
+func (p *scriptPool) report(err error) {
+	if p.failed.CompareAndSwap(false, true) {
+		log.Printf("Script of ability '%s' failed, further errors are ignored: %v", p.id, err)
+	}
+}
+
+func (p *scriptPool) getValidActions(state GameState, unit *PlayerUnit) []Action {
+	if state.Get(unit.ID, CounterRoundAbilities, 0) > 0 {
+		return nil
+	}
+
+	targets, err := p.targets(state, unit)
+	if err != nil {
+		p.report(err)
+		return nil
+	}
+
+	actions := make([]Action, 0, len(targets))
+	for _, target := range targets {
+		desc := &AbilityActionDescription{
+			ID:           p.id,
+			SourceUnitID: unit.ID,
+			TargetUnitID: UnitID(scriptInt(target["target"], -1)),
+			TargetX:      scriptInt(target["x"], -1),
+			TargetY:      scriptInt(target["y"], -1),
+		}
+		unitID := unit.ID
+		actions = append(actions, NewAbilityAction(p.id, func(state GameState, _ Action) GameState {
+			state = p.apply(state, desc)
+			state.Inc(unitID, CounterRoundAbilities, 1)
+			return state
+		}, desc))
+	}
+
+	return actions
+}
+
+// targets appelle getValidActions sur une façade en lecture seule.
+func (p *scriptPool) targets(state GameState, unit *PlayerUnit) ([]map[string]any, error) {
+	runtime, err := p.acquire()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer p.pool.Put(runtime)
+
+	facade := newScriptFacade(runtime.vm, &state, false)
+	result, err := runtime.guard.Run(func() (goja.Value, error) {
+		return runtime.getValidActions(goja.Undefined(), facade, runtime.vm.ToValue(scriptUnit(state, unit.ID)))
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "getValidActions failed")
+	}
+
+	var targets []map[string]any
+	if err := runtime.vm.ExportTo(result, &targets); err != nil {
+		return nil, errors.Wrap(err, "getValidActions must return an array of targets")
+	}
+
+	return targets, nil
+}
+
+// apply joue le script sur une copie de l'état et ne la retient qu'en cas
+// de succès : un script fautif reste sans effet. Les événements émis sur la
+// copie ne sont transmis qu'à ce moment.
+func (p *scriptPool) apply(state GameState, desc *AbilityActionDescription) GameState {
+	next := state.Copy()
+	next.dice = state.dice
+	var sink eventSink
+	if state.sink != nil {
+		next.sink = &sink
+	}
+
+	if err := p.run(&next, desc); err != nil {
+		p.report(err)
+		return state
+	}
+
+	for _, event := range sink.events {
+		state.emit(event)
+	}
+	next.sink = state.sink
+
+	return next
+}
+
+func (p *scriptPool) run(state *GameState, desc *AbilityActionDescription) error {
+	runtime, err := p.acquire()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer p.pool.Put(runtime)
+
+	facade := newScriptFacade(runtime.vm, state, true)
+	action := map[string]any{
+		"unit":   int(desc.SourceUnitID),
+		"target": int(desc.TargetUnitID),
+		"x":      desc.TargetX,
+		"y":      desc.TargetY,
+	}
+
+	_, err = runtime.guard.Run(func() (goja.Value, error) {
+		return runtime.apply(goja.Undefined(), facade, runtime.vm.ToValue(action))
+	})
+	if err != nil {
+		return errors.Wrap(err, "apply failed")
+	}
+
+	return nil
+}
+
+func scriptInt(value any, defaultValue int) int {
+	switch v := value.(type) {
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	default:
+		return defaultValue
+	}
+}
+
+// scriptUnit décrit une unité pour un script, nil si elle est éliminée.
+func scriptUnit(state GameState, unitID UnitID) map[string]any {
+	unit := state.Unit(unitID)
+	if unit == nil {
+		return nil
+	}
+
+	pos := state.PositionOf(unitID)
+
+	return map[string]any{
+		"id":     int(unit.ID),
+		"owner":  int(unit.OwnerID),
+		"x":      pos.X,
+		"y":      pos.Y,
+		"health": state.Get(unitID, CounterHealth, 0),
+		"stats": map[string]any{
+			"health":  unit.Stats.Health,
+			"range":   unit.Stats.Range,
+			"move":    unit.Stats.Move,
+			"power":   unit.Stats.Power,
+			"defense": unit.Stats.Defense,
+		},
+	}
+}
+
+// newScriptFacade expose l'état à un script. Les mutateurs ne sont exposés
+// que si mutable : ils s'appliquent à *state, que l'appelant récupère après
+// l'appel.
+func newScriptFacade(vm *goja.Runtime, state *GameState, mutable bool) *goja.Object {
+	facade := vm.NewObject()
+
+	set := func(name string, value any) {
+		if err := facade.Set(name, value); err != nil {
+			panic(errors.WithStack(err))
+		}
+	}
+
+	set("width", state.Layout.Width)
+	set("height", state.Layout.Height)
+	set("currentPlayer", int(state.CurrentPlayerID))
+
+	set("units", func() []map[string]any {
+		units := make([]map[string]any, 0, state.UnitCount())
+		for unit := range state.Units() {
+			units = append(units, scriptUnit(*state, unit.ID))
+		}
+		return units
+	})
+	set("unit", func(id int) map[string]any {
+		return scriptUnit(*state, UnitID(id))
+	})
+	set("unitAt", func(x, y int) int {
+		if id, exists := state.UnitAt(Position{X: x, Y: y}); exists {
+			return int(id)
+		}
+		return -1
+	})
+	set("hasObstacle", func(x, y int) bool {
+		return state.HasObstacle(Position{X: x, Y: y})
+	})
+	set("distance", func(x1, y1, x2, y2 int) int {
+		return int(distance(Position{X: x1, Y: y1}, Position{X: x2, Y: y2}))
+	})
+	set("hasLineOfSight", func(x1, y1, x2, y2 int) bool {
+		return hasLineOfSight(*state, Position{X: x1, Y: y1}, Position{X: x2, Y: y2})
+	})
+	set("isTargetable", func(id int) bool {
+		return isTargetable(*state, UnitID(id))
+	})
+	set("reachableEnemies", func(id int, reach int) []int {
+		unit := state.Unit(UnitID(id))
+		if unit == nil {
+			return nil
+		}
+		enemies := getReachableOpponentUnits(*state, unit.OwnerID, state.PositionOf(unit.ID), reach)
+		ids := make([]int, 0, len(enemies))
+		for _, enemy := range enemies {
+			ids = append(ids, int(enemy))
+		}
+		return ids
+	})
+	set("reachablePositions", func(id int, budget int) []map[string]int {
+		positions := getReachablePositions(*state, state.PositionOf(UnitID(id)), budget)
+		cells := make([]map[string]int, 0, len(positions))
+		for _, pos := range positions {
+			cells = append(cells, map[string]int{"x": pos.X, "y": pos.Y})
+		}
+		return cells
+	})
+
+	set("get", func(id int, name string, defaultValue int) int {
+		return state.Get(UnitID(id), name, defaultValue)
+	})
+
+	if !mutable {
+		return facade
+	}
+
+	// Le script n'écrit que des compteurs connus du moteur, et reçoit une
+	// exception sinon : une faute de frappe ne crée pas un compteur que rien
+	// ne lit.
+	known := func(name string) error {
+		if _, exists := counterIndex(name); !exists {
+			return errors.Errorf("unknown counter '%s'", name)
+		}
+		return nil
+	}
+
+	set("set", func(id int, name string, value int) (int, error) {
+		if err := known(name); err != nil {
+			return 0, err
+		}
+		return state.Set(UnitID(id), name, value), nil
+	})
+	set("inc", func(id int, name string, delta int) (int, error) {
+		if err := known(name); err != nil {
+			return 0, err
+		}
+		return state.Inc(UnitID(id), name, delta), nil
+	})
+	set("del", func(id int, name string) error {
+		if err := known(name); err != nil {
+			return err
+		}
+		state.Del(UnitID(id), name)
+		return nil
+	})
+
+	set("move", func(id int, x, y int) bool {
+		to := Position{X: x, Y: y}
+		if state.Unit(UnitID(id)) == nil || !state.Layout.Contains(to) || state.HasObstacle(to) {
+			return false
+		}
+		if _, occupied := state.UnitAt(to); occupied {
+			return false
+		}
+		state.MoveUnit(UnitID(id), to)
+		return true
+	})
+	set("applyDamage", func(id int, damage int) int {
+		if state.Unit(UnitID(id)) == nil {
+			return 0
+		}
+		var remaining int
+		*state, remaining = applyDamage(*state, UnitID(id), damage)
+		return remaining
+	})
+
+	return facade
+}
@@ -0,0 +1,216 @@
+package sim
+
+import (
+	"cmp"
+	"fmt"
+	"testing"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+)
+
+const drainScript = `
+function getValidActions(state, unit) {
+  return state.reachableEnemies(unit.id, 1).map(function(id) {
+    return { target: id }
+  })
+}
+
+function apply(state, action) {
+  state.applyDamage(action.target, 1)
+  var self = state.unit(action.unit)
+  if (self.health < self.stats.health) {
+    state.inc(action.unit, "health", 1)
+  }
+}
+`
+
+func TestScriptedAbility(t *testing.T) {
+	registry := NewAbilityRegistry()
+	if err := registry.RegisterScript("test-drain", drainScript); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	stats := core.Stats{Health: 3, Range: 1, Move: 1, Power: 1}
+
+	state := NewGameState(BoardLayout{})
+	caster := &PlayerUnit{ID: 0, OwnerID: PlayerOne, Unit: Unit{Stats: stats}}
+	state.AddUnit(caster, Position{X: 1, Y: 1})
+	state.AddUnit(&PlayerUnit{ID: 1, OwnerID: PlayerTwo, Unit: Unit{Stats: stats}}, Position{X: 2, Y: 2})
+	state.AddUnit(&PlayerUnit{ID: 2, OwnerID: PlayerTwo, Unit: Unit{Stats: stats}}, Position{X: 5, Y: 5})
+	state.Set(0, CounterHealth, 2)
+	state.Set(1, CounterHealth, 3)
+	state.Set(2, CounterHealth, 3)
+
+	actions := registry.abilities["test-drain"](state, caster)
+	if e, g := 1, len(actions); e != g {
+		t.Fatalf("expected %d action, got %d", e, g)
+	}
+	if e, g := UnitID(1), actions[0].(*AbilityAction).Description().TargetUnitID; e != g {
+		t.Errorf("expected target %d, got %d", e, g)
+	}
+
+	next := actions[0].Apply(state.Copy())
+
+	if e, g := 2, next.Get(1, CounterHealth, 0); e != g {
+		t.Errorf("target health: expected %d, got %d", e, g)
+	}
+	if e, g := 3, next.Get(0, CounterHealth, 0); e != g {
+		t.Errorf("caster health: expected %d, got %d", e, g)
+	}
+	if e, g := 1, next.Get(0, CounterRoundAbilities, 0); e != g {
+		t.Errorf("expected %d ability this round, got %d", e, g)
+	}
+	if e, g := 2, state.Get(0, CounterHealth, 0); e != g {
+		t.Errorf("expected the original state to be untouched, got health %d", g)
+	}
+
+	if actions := registry.abilities["test-drain"](next, caster); len(actions) != 0 {
+		t.Errorf("expected no action after using an ability, got %v", actions)
+	}
+}
+
+// Drain de Vie, capacité scriptée du catalogue, est jouée par le registre
+// par défaut.
+func TestLifeDrain(t *testing.T) {
+	registry := DefaultAbilityRegistry()
+	if origin, exists := registry.Origin("00014-life-drain"); !exists || origin != AbilityScripted {
+		t.Fatalf("expected a scripted implementation, got '%s'", origin)
+	}
+
+	state, unit := scriptTestState()
+	state.Set(0, CounterHealth, 2)
+
+	drain := *unit
+	drain.Abilities = core.Abilities("00014-life-drain")
+
+	actions := registry.GetPossibleActions(state, &drain)
+	if e, g := 1, len(actions); e != g {
+		t.Fatalf("expected %d action, got %d", e, g)
+	}
+
+	state = actions[0].Apply(state)
+
+	if e, g := 2, state.Get(1, CounterHealth, 0); e != g {
+		t.Errorf("target health: expected %d, got %d", e, g)
+	}
+	if e, g := 3, state.Get(0, CounterHealth, 0); e != g {
+		t.Errorf("caster health: expected %d, got %d", e, g)
+	}
+}
+
+func TestInvalidScript(t *testing.T) {
+	scripts := map[string]string{
+		"syntax error":     "function getValidActions(state, unit) {",
+		"missing apply":    "function getValidActions(state, unit) { return [] }",
+		"missing function": "",
+	}
+
+	for name, script := range scripts {
+		if err := NewAbilityRegistry().RegisterScript("test-invalid", script); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestScriptRandomness(t *testing.T) {
+	registry := NewAbilityRegistry()
+	err := registry.RegisterScript("test-random", `
+function getValidActions(state, unit) { return [{ target: Math.random() }] }
+function apply(state, action) {}
+`)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	state, unit := scriptTestState()
+
+	if actions := registry.abilities["test-random"](state, unit); len(actions) != 0 {
+		t.Errorf("expected Math.random to be unavailable, got %v", actions)
+	}
+}
+
+func scriptTestState() (GameState, *PlayerUnit) {
+	stats := core.Stats{Health: 3, Range: 1, Move: 1, Power: 1}
+
+	state := NewGameState(BoardLayout{})
+	unit := &PlayerUnit{ID: 0, OwnerID: PlayerOne, Unit: Unit{Stats: stats}}
+	state.AddUnit(unit, Position{X: 1, Y: 1})
+	state.AddUnit(&PlayerUnit{ID: 1, OwnerID: PlayerTwo, Unit: Unit{Stats: stats}}, Position{X: 2, Y: 2})
+	state.Set(0, CounterHealth, 3)
+	state.Set(1, CounterHealth, 3)
+
+	return state, unit
+}
+
+// Un script fautif ne propose rien, ou reste sans effet : la partie
+// continue.
+func TestFaultyScript(t *testing.T) {
+	type testCase struct {
+		GetValidActions string
+		Apply           string
+	}
+
+	testCases := map[string]testCase{
+		"throwing getValidActions": {
+			GetValidActions: `throw new Error("broken")`,
+		},
+		"non-array result": {
+			GetValidActions: `return 3`,
+		},
+		"endless getValidActions": {
+			GetValidActions: `for (;;) {}`,
+		},
+		"mutating getValidActions": {
+			GetValidActions: `state.applyDamage(1, 3); return [{ target: 1 }]`,
+		},
+		"throwing apply": {
+			Apply: `state.applyDamage(1, 1); throw new Error("broken")`,
+		},
+		"endless apply": {
+			Apply: `state.applyDamage(1, 1); for (;;) {}`,
+		},
+		"unknown counter": {
+			Apply: `state.applyDamage(1, 1); state.set(1, "script-defined-counter", 1)`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			getValidActions := cmp.Or(tc.GetValidActions, `return [{ target: 1 }]`)
+			apply := cmp.Or(tc.Apply, `state.applyDamage(action.target, 1)`)
+
+			registry := NewAbilityRegistry()
+			err := registry.RegisterScript("test-faulty", fmt.Sprintf(`
+function getValidActions(state, unit) { %s }
+function apply(state, action) { %s }
+`, getValidActions, apply))
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+
+			state, unit := scriptTestState()
+
+			actions := registry.abilities["test-faulty"](state, unit)
+			if e, g := 3, state.Get(1, CounterHealth, 0); e != g {
+				t.Errorf("target health after getValidActions: expected %d, got %d", e, g)
+			}
+			if tc.Apply == "" {
+				if len(actions) != 0 {
+					t.Errorf("expected no action, got %v", actions)
+				}
+				return
+			}
+			if e, g := 1, len(actions); e != g {
+				t.Fatalf("expected %d action, got %d", e, g)
+			}
+
+			next := actions[0].Apply(state.Copy())
+			if e, g := 3, next.Get(1, CounterHealth, 0); e != g {
+				t.Errorf("target health after apply: expected %d, got %d", e, g)
+			}
+			if _, exists := counterIndex("script-defined-counter"); exists {
+				t.Error("expected the script not to register a counter")
+			}
+		})
+	}
+}
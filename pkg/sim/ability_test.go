@@ -0,0 +1,73 @@
+package sim
+
+import (
+	"testing"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+)
+
+func TestAbilityRegistry(t *testing.T) {
+	squad := func() []Unit {
+		return []Unit{
+			{Stats: core.Stats{Health: 3, Range: 3, Move: 2, Power: 1}, Abilities: core.Abilities("00001-energy-trait")},
+			{Stats: core.Stats{Health: 3, Range: 1, Move: 2, Power: 2}},
+		}
+	}
+
+	// Variante : le Trait d'Énergie ne propose plus aucune action.
+	variant := DefaultAbilityRegistry()
+	variant.Register("00001-energy-trait", func(GameState, *PlayerUnit) []Action { return nil })
+
+	if defaultAbilityRegistry.abilities["00001-energy-trait"] == nil {
+		t.Fatal("expected the variant to leave the default registry untouched")
+	}
+
+	used := map[bool]int{}
+	for _, withVariant := range []bool{false, true} {
+		opts := []OptionFunc{
+			WithSeed(3),
+			WithPlayerStrategy(PlayerOne, SearchStrategy(2, 300)),
+			WithPlayerStrategy(PlayerTwo, SearchStrategy(2, 300)),
+			WithMaxTurns(20),
+		}
+		if withVariant {
+			opts = append(opts, WithAbilityRegistry(variant))
+		}
+
+		game := NewGame(squad(), squad(), opts...)
+		if err := game.Validate(); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		for step := range game.Run() {
+			if _, ok := step.Action.(*AbilityAction); ok {
+				used[withVariant]++
+			}
+		}
+	}
+
+	if used[false] == 0 {
+		t.Error("expected the default energy trait to be used")
+	}
+	if used[true] != 0 {
+		t.Errorf("expected the variant to offer no ability action, got %d", used[true])
+	}
+
+	// Une capacité sans implémentation est une erreur, pas une panique.
+	game := NewGame(squad(), squad(), WithSeed(3), WithAbilityRegistry(NewAbilityRegistry()))
+	if err := game.Validate(); err == nil {
+		t.Error("expected a missing ability error")
+	}
+	if actions := GetValidActionsForPlayer(game.State(), PlayerOne); len(actions) == 0 {
+		t.Error("expected moves and attacks despite the missing ability")
+	}
+}
+
+func TestAbilityCatalogue(t *testing.T) {
+	for _, problem := range core.CheckAbilities(NativeAbilities()) {
+		t.Error(problem)
+	}
+
+	if _, err := core.LookupAbilities("00000-charge", "99999-unknown"); err == nil {
+		t.Error("expected an unknown ability error")
+	}
+}
@@ -31,11 +31,7 @@ func NewMoveAction(unitID UnitID, targetPos Position) *MoveAction {
 
 // Apply implements Action.
 func (a *MoveAction) Apply(state GameState) GameState {
-	unit := state.Units[a.unitID]
-
-	delete(state.Board, state.Positions[unit.ID].String())
-	state.Positions[unit.ID] = a.targetPos
-	state.Board[a.targetPos.String()] = unit.ID
+	state.MoveUnit(a.unitID, a.targetPos)
 
 	state.Inc(a.unitID, CounterRoundActions, 1)
 
@@ -71,9 +67,7 @@ func NewAttackAction(unitID UnitID, targetID UnitID) *AttackAction {
 
 // Apply implements Action.
 func (a *AttackAction) Apply(state GameState) GameState {
-	unit := state.Units[a.unitID]
-
-	state, _ = applyDamage(state, a.targetID, unit.Stats.Power)
+	state = resolveAttack(state, state.Unit(a.unitID), a.targetID)
 
 	state.Inc(a.unitID, CounterRoundAttacks, 1)
 	state.Inc(a.unitID, CounterRoundActions, 1)
@@ -0,0 +1,139 @@
+package sim
+
+import (
+	"slices"
+)
+
+/* =============================================================================
+   Activation alternée.
+
+   Variante de l'économie d'actions (cf. ActionRules.Alternating) : au lieu
+   de dépenser un budget d'actions sur les unités de son choix, chaque
+   joueur active à son tour UNE unité, qui se déplace puis attaque ou use
+   d'une capacité — dans l'ordre qu'il veut, chacun au plus une fois. La
+   main passe alors au joueur suivant. La manche s'achève quand toutes les
+   unités en jeu ont été activées ; un joueur qui n'a plus d'unité à activer
+   passe son tour jusque-là.
+
+   Pour le moteur, une activation est un tour : beginTurn l'ouvre avec
+   activationActions actions, endTurn la clôt. L'unité activée n'est pas
+   désignée à l'avance : c'est la première qui agit (cf. activating). Un
+   joueur qui n'agit pas du tout consomme l'activation d'une de ses unités,
+   ce qui garantit que la manche s'achève.
+
+   Les transitions de tour de la règle publiée — statuts, moral, terrain
+   dangereux, marquage de la condition de victoire — suivent la manche : la
+   fin de manche applique à chaque joueur sa fin de tour, puis son début de
+   tour. Une manche tient donc lieu d'un tour de chaque joueur, pour le
+   marquage des points de contrôle comme pour la limite de tours de la
+   partie (cf. Game.Played).
+   ========================================================================== */
+
+// activationActions : actions d'une activation, un déplacement plus une
+// attaque ou une capacité.
+const activationActions = 2
+
+// CounterActivated : l'activation de l'unité est close pour la manche.
+const CounterActivated string = "activated"
+
+// activationAllows indique si l'activation de l'unité lui permet encore une
+// action du type donné. Toujours vrai hors activation alternée.
+//
+// Chaque action compte dans CounterRoundActions, attaques et capacités en
+// plus dans leur propre compteur : le reste, ce sont les déplacements.
+func (s GameState) activationAllows(unitID UnitID, actionType ActionType) bool {
+	if !s.ActionRules.Alternating {
+		return true
+	}
+	strikes := s.Get(unitID, CounterRoundAttacks, 0) + s.Get(unitID, CounterRoundAbilities, 0)
+	if actionType == ActionMove {
+		return s.Get(unitID, CounterRoundActions, 0)-strikes == 0
+	}
+	return strikes == 0
+}
+
+// activating renvoie l'unité en cours d'activation : celle qui a agi pendant
+// la manche sans que son activation soit close. Ses compteurs survivant à
+// sa mort (cf. Get), une unité tuée en cours d'activation le reste.
+func (s GameState) activating() (UnitID, bool) {
+	for i := range s.units {
+		unitID := UnitID(i)
+		if s.Get(unitID, CounterRoundActions, 0) > 0 && s.Get(unitID, CounterActivated, 0) == 0 {
+			return unitID, true
+		}
+	}
+	return -1, false
+}
+
+// activeUnits renvoie les unités du joueur qui peuvent agir : toutes sous la
+// règle publiée ; en activation alternée, l'unité en cours d'activation, ou
+// à défaut celles qui n'ont pas encore été activées de la manche.
+func activeUnits(state GameState, playerID PlayerID) []*PlayerUnit {
+	units := getControllableUnits(state, playerID)
+	if !state.ActionRules.Alternating {
+		return units
+	}
+
+	if unitID, ok := state.activating(); ok {
+		if unit := state.Unit(unitID); unit != nil && unit.OwnerID == playerID {
+			return []*PlayerUnit{unit}
+		}
+		return nil
+	}
+
+	return slices.DeleteFunc(units, func(unit *PlayerUnit) bool {
+		return state.Get(unit.ID, CounterActivated, 0) > 0
+	})
+}
+
+// roundOver indique si toutes les unités en jeu ont été activées.
+func (s GameState) roundOver() bool {
+	for unit := range s.Units() {
+		if s.Get(unit.ID, CounterActivated, 0) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// endActivation clôt l'activation du joueur, puis la manche si toutes les
+// unités ont été activées : fin de tour de chaque joueur encore en jeu (cf.
+// closeTurn), puis début de tour de chacun (cf. startTurn), dans l'ordre
+// des joueurs. Mute l'état reçu.
+func endActivation(state GameState, playerID PlayerID) GameState {
+	if unitID, ok := state.activating(); ok {
+		state.Set(unitID, CounterActivated, 1)
+	} else if pending := activeUnits(state, playerID); len(pending) > 0 {
+		// Le joueur n'a rien joué : l'activation de sa première unité en
+		// attente est perdue.
+		state.Set(pending[0].ID, CounterActivated, 1)
+	}
+
+	state.emit(TurnEnded{Player: playerID})
+
+	if !state.roundOver() {
+		return state
+	}
+
+	var present PlayerScores
+	for unit := range state.Units() {
+		present[unit.OwnerID]++
+	}
+
+	for other := range PlayerID(state.PlayerCount()) {
+		if present[other] > 0 {
+			state = closeTurn(state, other)
+		}
+	}
+
+	resetRoundCounters(state)
+	state.DelAll(CounterActivated)
+
+	for other := range PlayerID(state.PlayerCount()) {
+		if present[other] > 0 {
+			state = startTurn(state, other)
+		}
+	}
+
+	return state
+}
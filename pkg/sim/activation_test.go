@@ -0,0 +1,163 @@
+package sim
+
+import (
+	"bytes"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+)
+
+func TestActivation(t *testing.T) {
+	stats := core.Stats{Health: 3, Range: 1, Move: 2, Power: 1}
+
+	state := NewGameState(BoardLayout{})
+	state.ActionRules = ActionRules{Alternating: true}
+	state.AddUnit(&PlayerUnit{ID: 0, OwnerID: PlayerOne, Unit: Unit{Stats: stats}}, Position{X: 1, Y: 1})
+	state.AddUnit(&PlayerUnit{ID: 1, OwnerID: PlayerOne, Unit: Unit{Stats: stats}}, Position{X: 3, Y: 3})
+	state.AddUnit(&PlayerUnit{ID: 2, OwnerID: PlayerTwo, Unit: Unit{Stats: stats}}, Position{X: 1, Y: 4})
+	for unit := range state.Units() {
+		state.Set(unit.ID, CounterHealth, 3)
+	}
+
+	actors := func(state GameState, playerID PlayerID) map[UnitID][]ActionType {
+		actors := map[UnitID][]ActionType{}
+		for _, action := range GetValidActionsForPlayer(state, playerID) {
+			unitID := recordAction(0, playerID, action).Unit
+			if types := actors[unitID]; len(types) == 0 || types[len(types)-1] != action.Type() {
+				actors[unitID] = append(types, action.Type())
+			}
+		}
+		return actors
+	}
+
+	state = beginTurn(state, PlayerOne)
+	if e, g := activationActions, state.ActionsLeft; e != g {
+		t.Errorf("ActionsLeft: expected %d, got %d", e, g)
+	}
+	if e, g := 2, len(actors(state, PlayerOne)); e != g {
+		t.Fatalf("active units: expected %d, got %d", e, g)
+	}
+
+	// Le premier déplacement désigne l'unité activée : elle seule agit
+	// ensuite, et ne se déplace plus.
+	state = NewMoveAction(0, Position{X: 1, Y: 3}).Apply(state)
+	if e, g := (map[UnitID][]ActionType{0: {ActionAttack}}), actors(state, PlayerOne); !reflect.DeepEqual(e, g) {
+		t.Fatalf("actions after the move: expected %v, got %v", e, g)
+	}
+
+	state = NewAttackAction(0, 2).Apply(state)
+	if g := actors(state, PlayerOne); len(g) != 0 {
+		t.Fatalf("expected a spent activation, got %v", g)
+	}
+
+	state = endTurn(state, PlayerOne)
+	if e, g := PlayerTwo, nextPlayer(state, PlayerOne); e != g {
+		t.Fatalf("next player: expected %d, got %d", e, g)
+	}
+
+	// L'unité 1 tient seule la zone : le marquage attend la fin de manche.
+	state = advanceTurn(beginTurn(state, PlayerTwo), PlayerTwo)
+	if e, g := PlayerOne, state.CurrentPlayerID; e != g {
+		t.Fatalf("player after a pass: expected %d, got %d", e, g)
+	}
+	if g := state.Get(2, CounterActivated, 0); g == 0 {
+		t.Error("expected a pass to spend the activation")
+	}
+	if e, g := 0, state.ControlPoints[PlayerOne]; e != g {
+		t.Errorf("control points before the end of the round: expected %d, got %d", e, g)
+	}
+
+	// Le joueur 2 n'a plus d'unité à activer : le joueur 1 enchaîne.
+	if e, g := PlayerOne, nextPlayer(state, PlayerOne); e != g {
+		t.Errorf("next player: expected %d, got %d", e, g)
+	}
+	if e, g := []UnitID{1}, unitIDs(activeUnits(state, PlayerOne)); !reflect.DeepEqual(e, g) {
+		t.Errorf("active units: expected %v, got %v", e, g)
+	}
+
+	state = endTurn(state, PlayerOne)
+	if e, g := 1, state.ControlPoints[PlayerOne]; e != g {
+		t.Errorf("control points after the round: expected %d, got %d", e, g)
+	}
+	if e, g := (PlayerScores{1, 1}), state.TurnsPlayed; e != g {
+		t.Errorf("TurnsPlayed: expected %v, got %v", e, g)
+	}
+	if e, g := 2, len(actors(state, PlayerOne)); e != g {
+		t.Errorf("active units of a new round: expected %d, got %d", e, g)
+	}
+}
+
+func unitIDs(units []*PlayerUnit) []UnitID {
+	ids := make([]UnitID, 0, len(units))
+	for _, unit := range units {
+		ids = append(ids, unit.ID)
+	}
+	return ids
+}
+
+func TestAlternatingGame(t *testing.T) {
+	game := NewGame(recordTestSquad(), recordTestSquad(),
+		WithSeed(3),
+		WithAlternatingActivation(),
+		WithPlayerStrategy(PlayerOne, SearchStrategy(2, 300)),
+		WithPlayerStrategy(PlayerTwo, SearchStrategy(2, 300)),
+		WithMaxTurns(20),
+	)
+
+	// Une activation ne fait agir qu'une unité, et chaque unité n'est activée
+	// qu'une fois par manche.
+	actors := map[uint]UnitID{}
+	activated := map[UnitID]bool{}
+	rounds := 0
+	for step := range game.Run() {
+		played := game.State().TurnsPlayed
+		if played := slices.Max(played[:]); played != rounds {
+			rounds = played
+			clear(activated)
+		}
+		if step.Action == nil {
+			continue
+		}
+
+		unitID := recordAction(step.Turn, step.Player, step.Action).Unit
+		actor, seen := actors[step.Turn]
+		switch {
+		case seen && actor != unitID:
+			t.Fatalf("turn %d: units %d and %d act in the same activation", step.Turn, actor, unitID)
+		case !seen && activated[unitID]:
+			t.Fatalf("turn %d: unit %d activates twice in a round", step.Turn, unitID)
+		}
+		actors[step.Turn] = unitID
+		activated[unitID] = true
+	}
+
+	if rounds == 0 {
+		t.Error("expected at least one complete round")
+	}
+
+	record := game.Record()
+	if record.Result == nil {
+		t.Fatal("expected the game to end")
+	}
+	if !record.Setup.ActionRules.Alternating {
+		t.Error("expected the activation rule to be recorded")
+	}
+
+	var buff bytes.Buffer
+	if err := record.Write(&buff); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	read, err := ReadGameRecord(&buff)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	replayed, err := Replay(read)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if e, g := printState(game.State()), printState(replayed.State()); e != g {
+		t.Errorf("final board: expected\n%s\ngot\n%s", e, g)
+	}
+}
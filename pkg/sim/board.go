@@ -0,0 +1,398 @@
+package sim
+
+import (
+	"math"
+	"slices"
+
+	"github.com/pkg/errors"
+)
+
+/* =============================================================================
+   Géométrie du plateau.
+
+   Dimensions, zones de capture et zones de déploiement sont un réglage de
+   partie, porté par le GameState : mouvements, portées, IA et déploiement
+   lisent tous la géométrie de l'état qu'ils manipulent.
+
+   La valeur zéro d'un BoardLayout vaut le plateau publié (8×8, zone centrale
+   2×2, deux rangées de déploiement de chaque côté) ; un champ laissé à zéro
+   est dérivé des dimensions. Les fonctions du paquet qui ne reçoivent pas
+   d'état (InObjectiveZone, DeploymentRows…) décrivent ce plateau publié.
+
+   Chaque zone de capture se dispute séparément : la tenir seul à la fin de
+   son tour rapporte un marqueur (cf. endTurn).
+
+   Chaque joueur a ses zones de déploiement, le long d'un bord : en haut et
+   en bas à deux joueurs, puis à gauche et à droite pour un troisième et un
+   quatrième (cf. Players). Un joueur déployé sur un bord latéral lit ses
+   « rangées » en colonnes (cf. DeploymentRows) ; le bord opposé au sien est
+   celui du joueur d'en face (cf. facingPlayer).
+   ========================================================================== */
+
+// Area est un rectangle de cases, coin supérieur gauche en X,Y.
+type Area struct {
+	X      int `json:"x" yaml:"x"`
+	Y      int `json:"y" yaml:"y"`
+	Width  int `json:"width" yaml:"width"`
+	Height int `json:"height" yaml:"height"`
+}
+
+// Contains indique si la case appartient au rectangle.
+func (a Area) Contains(pos Position) bool {
+	return pos.X >= a.X && pos.X < a.X+a.Width && pos.Y >= a.Y && pos.Y < a.Y+a.Height
+}
+
+// Overlaps indique si les deux rectangles ont une case en commun.
+func (a Area) Overlaps(b Area) bool {
+	return a.X < b.X+b.Width && b.X < a.X+a.Width && a.Y < b.Y+b.Height && b.Y < a.Y+a.Height
+}
+
+// Positions énumère les cases du rectangle, ligne par ligne.
+func (a Area) Positions() []Position {
+	positions := make([]Position, 0, a.Width*a.Height)
+	for y := a.Y; y < a.Y+a.Height; y++ {
+		for x := a.X; x < a.X+a.Width; x++ {
+			positions = append(positions, Position{X: x, Y: y})
+		}
+	}
+	return positions
+}
+
+type BoardLayout struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// ObjectiveZones : zones de capture. Vide = une zone centrale, 2 cases de
+	// côté (1 sur une dimension impaire, pour rester symétrique).
+	ObjectiveZones []Area `json:"objectiveZones"`
+	// DeploymentZones : zones de déploiement de chaque joueur. Vide =
+	// DeploymentDepth rangées de chaque côté du plateau.
+	DeploymentZones map[PlayerID][]Area `json:"deploymentZones"`
+	// DeploymentDepth : profondeur des zones de déploiement dérivées. 0 = 2.
+	DeploymentDepth int `json:"deploymentDepth"`
+	// Players : nombre de joueurs, de 2 à 4, un par bord pour les zones de
+	// déploiement dérivées. 0 = 2 ; vaut le nombre de joueurs des zones
+	// données sinon.
+	Players int `json:"players,omitempty"`
+}
+
+var DefaultBoardLayout = NewBoardLayout(BoardSize, BoardSize)
+
+// NewBoardLayout construit un plateau aux dimensions données, zone de capture
+// et zones de déploiement dérivées.
+func NewBoardLayout(width, height int) BoardLayout {
+	return BoardLayout{Width: width, Height: height}.Normalize()
+}
+
+// Normalize complète les champs laissés à zéro (cf. BoardLayout).
+func (l BoardLayout) Normalize() BoardLayout {
+	if l.Width <= 0 {
+		l.Width = BoardSize
+	}
+	if l.Height <= 0 {
+		l.Height = BoardSize
+	}
+	if len(l.ObjectiveZones) == 0 {
+		width, height := 2-l.Width%2, 2-l.Height%2
+		l.ObjectiveZones = []Area{{
+			X:      (l.Width - width) / 2,
+			Y:      (l.Height - height) / 2,
+			Width:  width,
+			Height: height,
+		}}
+	}
+	if l.DeploymentDepth <= 0 {
+		l.DeploymentDepth = 2
+	}
+	if len(l.DeploymentZones) == 0 {
+		depth := l.DeploymentDepth
+		// Haut, bas, gauche, droite : les bords latéraux s'arrêtent aux
+		// zones du haut et du bas.
+		edges := []Area{
+			{X: 0, Y: 0, Width: l.Width, Height: depth},
+			{X: 0, Y: l.Height - depth, Width: l.Width, Height: depth},
+			{X: 0, Y: depth, Width: depth, Height: l.Height - 2*depth},
+			{X: l.Width - depth, Y: depth, Width: depth, Height: l.Height - 2*depth},
+		}
+		players := l.Players
+		if players <= 0 {
+			players = 2
+		}
+		l.DeploymentZones = make(map[PlayerID][]Area, players)
+		for playerID := range PlayerID(min(players, len(edges))) {
+			l.DeploymentZones[playerID] = []Area{edges[playerID]}
+		}
+	}
+	l.Players = len(l.DeploymentZones)
+	return l
+}
+
+// Validate vérifie qu'un plateau est jouable : 2 à 4 joueurs, zones dans le
+// plateau, une zone de déploiement au moins par joueur, et aucune zone de
+// déploiement qui déborde sur une autre ou sur une zone de capture.
+func (l BoardLayout) Validate() error {
+	if l.Players > MaxPlayers {
+		return errors.Errorf("a board needs 2 to %d players, got %d", MaxPlayers, l.Players)
+	}
+
+	l = l.Normalize()
+
+	if l.Players < 2 || l.Players > MaxPlayers {
+		return errors.Errorf("a board needs 2 to %d players, got %d", MaxPlayers, l.Players)
+	}
+
+	inside := func(a Area) bool {
+		return a.Width > 0 && a.Height > 0 && a.X >= 0 && a.Y >= 0 && a.X+a.Width <= l.Width && a.Y+a.Height <= l.Height
+	}
+
+	for _, zone := range l.ObjectiveZones {
+		if !inside(zone) {
+			return errors.Errorf("objective zone %+v is out of a %dx%d board", zone, l.Width, l.Height)
+		}
+	}
+
+	for playerID := range PlayerID(l.Players) {
+		if len(l.DeploymentZones[playerID]) == 0 {
+			return errors.Errorf("no deployment zone for player %d", playerID)
+		}
+	}
+
+	for playerID, zones := range l.DeploymentZones {
+		for _, zone := range zones {
+			if !inside(zone) {
+				return errors.Errorf("deployment zone %+v of player %d is out of a %dx%d board", zone, playerID, l.Width, l.Height)
+			}
+			for _, objective := range l.ObjectiveZones {
+				if zone.Overlaps(objective) {
+					return errors.Errorf("deployment zone %+v of player %d overlaps objective zone %+v", zone, playerID, objective)
+				}
+			}
+			for otherID, others := range l.DeploymentZones {
+				if otherID == playerID {
+					continue
+				}
+				for _, other := range others {
+					if zone.Overlaps(other) {
+						return errors.Errorf("deployment zones of players %d and %d overlap", playerID, otherID)
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Contains indique si la case est sur le plateau.
+func (l BoardLayout) Contains(pos Position) bool {
+	return pos.X >= 0 && pos.X < l.Width && pos.Y >= 0 && pos.Y < l.Height
+}
+
+// InObjectiveZone indique si une position est dans une zone de capture.
+func (l BoardLayout) InObjectiveZone(pos Position) bool {
+	for _, zone := range l.ObjectiveZones {
+		if zone.Contains(pos) {
+			return true
+		}
+	}
+	return false
+}
+
+// ObjectivePositions énumère les cases des zones de capture.
+func (l BoardLayout) ObjectivePositions() []Position {
+	positions := make([]Position, 0)
+	for _, zone := range l.ObjectiveZones {
+		positions = append(positions, zone.Positions()...)
+	}
+	return positions
+}
+
+// inDeploymentZone indique si la case appartient à une zone de déploiement
+// du joueur.
+func (l BoardLayout) inDeploymentZone(playerID PlayerID, pos Position) bool {
+	for _, zone := range l.DeploymentZones[playerID] {
+		if zone.Contains(pos) {
+			return true
+		}
+	}
+	return false
+}
+
+// sideways indique si le joueur se déploie sur un bord latéral : toutes ses
+// zones sont plus hautes que larges.
+func (l BoardLayout) sideways(playerID PlayerID) bool {
+	zones := l.DeploymentZones[playerID]
+	for _, zone := range zones {
+		if zone.Height <= zone.Width {
+			return false
+		}
+	}
+	return len(zones) > 0
+}
+
+// rowOf renvoie la rangée de la case pour le joueur : son ordonnée, ou son
+// abscisse s'il se déploie sur un bord latéral (cf. sideways).
+func (l BoardLayout) rowOf(playerID PlayerID, pos Position) int {
+	if l.sideways(playerID) {
+		return pos.X
+	}
+	return pos.Y
+}
+
+// DeploymentRows renvoie les rangées de déploiement d'un joueur, de la plus
+// éloignée des zones de capture à la plus avancée. Pour un joueur déployé
+// sur un bord latéral, ce sont des colonnes (cf. rowOf).
+func (l BoardLayout) DeploymentRows(playerID PlayerID) []int {
+	sideways := l.sideways(playerID)
+
+	rows := make([]int, 0)
+	for _, zone := range l.DeploymentZones[playerID] {
+		from, size := zone.Y, zone.Height
+		if sideways {
+			from, size = zone.X, zone.Width
+		}
+		for row := from; row < from+size; row++ {
+			if !slices.Contains(rows, row) {
+				rows = append(rows, row)
+			}
+		}
+	}
+
+	// Distance d'une rangée à la zone de capture la plus proche.
+	gap := func(row int) int {
+		best := math.MaxInt
+		for _, zone := range l.ObjectiveZones {
+			from, size := zone.Y, zone.Height
+			if sideways {
+				from, size = zone.X, zone.Width
+			}
+			d := 0
+			if row < from {
+				d = from - row
+			} else if row >= from+size {
+				d = row - (from + size - 1)
+			}
+			best = min(best, d)
+		}
+		return best
+	}
+
+	slices.SortStableFunc(rows, func(a, b int) int {
+		if ga, gb := gap(a), gap(b); ga != gb {
+			return gb - ga
+		}
+		return a - b
+	})
+
+	return rows
+}
+
+// DeploymentPositions énumère les cases de déploiement d'un joueur, rangée
+// par rangée dans l'ordre de DeploymentRows.
+func (l BoardLayout) DeploymentPositions(playerID PlayerID) []Position {
+	sideways := l.sideways(playerID)
+
+	positions := make([]Position, 0)
+	for _, row := range l.DeploymentRows(playerID) {
+		if sideways {
+			for y := 0; y < l.Height; y++ {
+				if pos := (Position{X: row, Y: y}); l.inDeploymentZone(playerID, pos) {
+					positions = append(positions, pos)
+				}
+			}
+			continue
+		}
+		for x := 0; x < l.Width; x++ {
+			if pos := (Position{X: x, Y: row}); l.inDeploymentZone(playerID, pos) {
+				positions = append(positions, pos)
+			}
+		}
+	}
+	return positions
+}
+
+// facingPlayer renvoie le joueur déployé sur le bord opposé : PlayerOne et
+// PlayerTwo se font face, PlayerThree et PlayerFour aussi.
+func facingPlayer(playerID PlayerID) PlayerID {
+	return playerID ^ 1
+}
+
+// IsValidObstaclePosition valide l'emplacement d'un obstacle : sur le
+// plateau, hors des zones de capture et hors des zones de déploiement.
+func (l BoardLayout) IsValidObstaclePosition(pos Position) bool {
+	if !l.Contains(pos) || l.InObjectiveZone(pos) {
+		return false
+	}
+	for playerID := range l.DeploymentZones {
+		if l.inDeploymentZone(playerID, pos) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsValidDeploymentPosition vérifie qu'une case appartient à une zone de
+// déploiement du joueur, qu'elle est dans le plateau et libre de tout
+// obstacle. L'occupation par une unité est vérifiée par l'appelant.
+func (l BoardLayout) IsValidDeploymentPosition(playerID PlayerID, pos Position, obstacles map[string]bool) bool {
+	return l.Contains(pos) && l.inDeploymentZone(playerID, pos) && !obstacles[pos.String()]
+}
+
+// ObstaclePositions énumère les emplacements d'obstacle valides, colonne par
+// colonne.
+func (l BoardLayout) ObstaclePositions() []Position {
+	positions := make([]Position, 0)
+	for x := 0; x < l.Width; x++ {
+		for y := 0; y < l.Height; y++ {
+			if pos := (Position{X: x, Y: y}); l.IsValidObstaclePosition(pos) {
+				positions = append(positions, pos)
+			}
+		}
+	}
+	return positions
+}
+
+// objectiveDistance renvoie la distance d'une case à la zone de capture la
+// plus proche.
+func (l BoardLayout) objectiveDistance(pos Position) float64 {
+	best := math.MaxFloat64
+	for _, zone := range l.ObjectiveZones {
+		for y := zone.Y; y < zone.Y+zone.Height; y++ {
+			for x := zone.X; x < zone.X+zone.Width; x++ {
+				if d := distance(pos, Position{X: x, Y: y}); d < best {
+					best = d
+				}
+			}
+		}
+	}
+	return best
+}
+
+// objectiveCenterX renvoie l'abscisse moyenne des milieux des zones de
+// capture.
+func (l BoardLayout) objectiveCenterX() float64 {
+	sum := 0.0
+	for _, zone := range l.ObjectiveZones {
+		sum += float64(zone.X) + float64(zone.Width-1)/2
+	}
+	return sum / float64(len(l.ObjectiveZones))
+}
+
+// objectiveCenterY renvoie l'ordonnée moyenne des milieux des zones de
+// capture.
+func (l BoardLayout) objectiveCenterY() float64 {
+	sum := 0.0
+	for _, zone := range l.ObjectiveZones {
+		sum += float64(zone.Y) + float64(zone.Height-1)/2
+	}
+	return sum / float64(len(l.ObjectiveZones))
+}
+
+// board renvoie la géométrie du plateau de la partie.
+func (s GameState) board() BoardLayout {
+	// Valeur zéro (état construit à la main) : pas d'allocation à chaque
+	// appel, board est sur le chemin de la recherche.
+	if s.Layout.Width == 0 {
+		return DefaultBoardLayout
+	}
+	return s.Layout.Normalize()
+}
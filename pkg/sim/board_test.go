@@ -0,0 +1,189 @@
+package sim
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+)
+
+func TestDefaultBoardLayout(t *testing.T) {
+	if e, g := []Position{{X: 3, Y: 3}, {X: 4, Y: 3}, {X: 3, Y: 4}, {X: 4, Y: 4}}, ObjectiveZone; !reflect.DeepEqual(e, g) {
+		t.Errorf("ObjectiveZone: expected %v, got %v", e, g)
+	}
+
+	if e, g := [2]int{7, 6}, DeploymentRows(PlayerTwo); e != g {
+		t.Errorf("DeploymentRows(PlayerTwo): expected %v, got %v", e, g)
+	}
+
+	// La valeur zéro d'un état construit à la main vaut le plateau publié.
+	if e, g := DefaultBoardLayout, (GameState{}).board(); !reflect.DeepEqual(e, g) {
+		t.Errorf("zero layout: expected %+v, got %+v", e, g)
+	}
+}
+
+func TestNewBoardLayout(t *testing.T) {
+	type testCase struct {
+		Width, Height   int
+		ExpectedZone    Area
+		ExpectedP2Rows  []int
+		ExpectedInvalid bool
+	}
+
+	testCases := []testCase{
+		{Width: 6, Height: 6, ExpectedZone: Area{X: 2, Y: 2, Width: 2, Height: 2}, ExpectedP2Rows: []int{5, 4}},
+		{Width: 10, Height: 12, ExpectedZone: Area{X: 4, Y: 5, Width: 2, Height: 2}, ExpectedP2Rows: []int{11, 10}},
+		{Width: 7, Height: 9, ExpectedZone: Area{X: 3, Y: 4, Width: 1, Height: 1}, ExpectedP2Rows: []int{8, 7}},
+		// Zone de capture à cheval sur les zones de déploiement.
+		{Width: 4, Height: 4, ExpectedZone: Area{X: 1, Y: 1, Width: 2, Height: 2}, ExpectedP2Rows: []int{3, 2}, ExpectedInvalid: true},
+	}
+
+	for _, tc := range testCases {
+		layout := NewBoardLayout(tc.Width, tc.Height)
+
+		if e, g := []Area{tc.ExpectedZone}, layout.ObjectiveZones; !reflect.DeepEqual(e, g) {
+			t.Errorf("%dx%d: objective zone: expected %+v, got %+v", tc.Width, tc.Height, e, g)
+		}
+		if e, g := tc.ExpectedP2Rows, layout.DeploymentRows(PlayerTwo); !reflect.DeepEqual(e, g) {
+			t.Errorf("%dx%d: deployment rows: expected %v, got %v", tc.Width, tc.Height, e, g)
+		}
+		if err := layout.Validate(); (err != nil) != tc.ExpectedInvalid {
+			t.Errorf("%dx%d: unexpected validation result: %v", tc.Width, tc.Height, err)
+		}
+	}
+}
+
+func TestGameOnCustomBoard(t *testing.T) {
+	for _, layout := range []BoardLayout{NewBoardLayout(6, 6), NewBoardLayout(10, 12)} {
+		game := NewGame(recordTestSquad(), recordTestSquad(),
+			WithSeed(3),
+			WithBoardLayout(layout),
+			WithMaxTurns(12),
+		)
+
+		for range game.Run() {
+			state := game.State()
+			for unit := range state.Units() {
+				if pos := state.PositionOf(unit.ID); !layout.Contains(pos) {
+					t.Fatalf("%dx%d: unit %d out of the board at %s", layout.Width, layout.Height, unit.ID, pos)
+				}
+			}
+		}
+
+		var buff strings.Builder
+		game.State().Print(&buff)
+		if e, g := layout.Height*2+1, strings.Count(buff.String(), "\n"); e != g {
+			t.Errorf("%dx%d: printed lines: expected %d, got %d", layout.Width, layout.Height, e, g)
+		}
+
+		record := game.Record()
+		if e, g := layout, record.Setup.Board; !reflect.DeepEqual(e, g) {
+			t.Errorf("%dx%d: recorded board: expected %+v, got %+v", layout.Width, layout.Height, e, g)
+		}
+		if _, err := Replay(record); err != nil {
+			t.Errorf("%dx%d: %+v", layout.Width, layout.Height, err)
+		}
+	}
+}
+
+func TestSquadDeployment(t *testing.T) {
+	layout := NewBoardLayout(4, 4)
+	cells := layout.DeploymentPositions(PlayerOne)
+	stats := core.Stats{Health: 2, Range: 1, Move: 1, Power: 1}
+
+	squad := func(size int) []Unit {
+		return slices.Repeat([]Unit{{Stats: stats}}, size)
+	}
+
+	type testCase struct {
+		Squad      []Unit
+		Deployment []Position
+		// ExpectedError : extrait de l'erreur attendue, vide si la partie
+		// est valide.
+		ExpectedError string
+	}
+
+	testCases := map[string]testCase{
+		"fitting squad": {
+			Squad: squad(len(cells)),
+		},
+		"squad larger than the deployment zone": {
+			Squad:         squad(len(cells) + 1),
+			ExpectedError: "do not fit",
+		},
+		"partial deployment": {
+			Squad:         squad(2),
+			Deployment:    cells[:1],
+			ExpectedError: "1 deployment positions for 2 units",
+		},
+		"stacked deployment": {
+			Squad:         squad(2),
+			Deployment:    []Position{cells[0], cells[0]},
+			ExpectedError: "same cell",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			funcs := []OptionFunc{WithSeed(1), WithBoardLayout(layout)}
+			if tc.Deployment != nil {
+				funcs = append(funcs, WithDeployment(map[PlayerID][]Position{PlayerOne: tc.Deployment}))
+			}
+
+			err := NewGame(tc.Squad, squad(1), funcs...).Validate()
+			switch {
+			case tc.ExpectedError == "" && err != nil:
+				t.Errorf("%+v", err)
+			case tc.ExpectedError != "" && (err == nil || !strings.Contains(err.Error(), tc.ExpectedError)):
+				t.Errorf("expected an error containing '%s', got %v", tc.ExpectedError, err)
+			}
+		})
+	}
+}
+
+func TestMultipleObjectiveZones(t *testing.T) {
+	layout := BoardLayout{
+		Width:          10,
+		Height:         8,
+		ObjectiveZones: []Area{{X: 1, Y: 3, Width: 2, Height: 2}, {X: 7, Y: 3, Width: 2, Height: 2}},
+		DeploymentZones: map[PlayerID][]Area{
+			PlayerOne: {{X: 2, Y: 0, Width: 6, Height: 2}},
+			PlayerTwo: {{X: 2, Y: 6, Width: 6, Height: 2}},
+		},
+	}
+	if err := layout.Validate(); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	if layout.IsValidDeploymentPosition(PlayerOne, Position{X: 0, Y: 0}, nil) {
+		t.Error("expected 0,0 to be out of player one's deployment zone")
+	}
+	if layout.IsValidObstaclePosition(Position{X: 8, Y: 4}) {
+		t.Error("expected no obstacle in the second objective zone")
+	}
+	if !layout.IsValidObstaclePosition(Position{X: 0, Y: 0}) {
+		t.Error("expected an obstacle to fit outside of the zones")
+	}
+
+	unit := &PlayerUnit{ID: 1, OwnerID: PlayerOne, Unit: Unit{Stats: core.Stats{Health: 3, Range: 1, Move: 1, Power: 1}}}
+	other := &PlayerUnit{ID: 2, OwnerID: PlayerOne, Unit: unit.Unit}
+
+	state := NewGameState(layout)
+	state.AddUnit(unit, Position{X: 1, Y: 3})
+	state.AddUnit(other, Position{X: 8, Y: 4})
+	state.Rules = DefaultCaptureRules
+
+	state = endTurn(state, PlayerOne)
+
+	if e, g := 2, state.ControlPoints[PlayerOne]; e != g {
+		t.Errorf("expected one point per held zone: expected %d, got %d", e, g)
+	}
+
+	invalid := layout
+	invalid.DeploymentZones = map[PlayerID][]Area{PlayerOne: {{X: 0, Y: 2, Width: 3, Height: 2}}, PlayerTwo: {{X: 0, Y: 7, Width: 10, Height: 1}}}
+	if err := invalid.Validate(); err == nil {
+		t.Error("expected a deployment zone overlapping an objective zone to be rejected")
+	}
+}
@@ -45,14 +45,11 @@ func TestChargeAbility(t *testing.T) {
 	}
 
 	// Create game state
-	state := GameState{
-		counters:        map[UnitID]map[string]int{},
-		Positions:       map[UnitID]Position{1: {X: 0, Y: 0}, 2: {X: 3, Y: 0}},
-		Board:           map[string]UnitID{"0,0": 1, "3,0": 2},
-		Units:           map[UnitID]*PlayerUnit{1: unit, 2: enemyUnit},
-		CurrentPlayerID: PlayerOne,
-		ActionsLeft:     2,
-	}
+	state := NewGameState(BoardLayout{})
+	state.AddUnit(unit, Position{X: 0, Y: 0})
+	state.AddUnit(enemyUnit, Position{X: 3, Y: 0})
+	state.CurrentPlayerID = PlayerOne
+	state.ActionsLeft = 2
 
 	// Set initial health
 	state.Set(1, CounterHealth, 3)
@@ -73,7 +70,7 @@ func TestChargeAbility(t *testing.T) {
 	newState := chargeAction.Apply(state.Copy())
 
 	// Verify the unit moved and the enemy took 1 damage
-	if newState.Positions[1].X == 0 && newState.Positions[1].Y == 0 {
+	if newState.PositionOf(1) == (Position{X: 0, Y: 0}) {
 		t.Error("Unit should have moved from its original position")
 	}
 
@@ -115,14 +112,10 @@ func TestChargeAbilityAlreadyUsed(t *testing.T) {
 	}
 
 	// Create game state where unit has already used an ability
-	state := GameState{
-		counters:        map[UnitID]map[string]int{},
-		Positions:       map[UnitID]Position{'1': {X: 0, Y: 0}},
-		Board:           map[string]UnitID{"0,0": '1'},
-		Units:           map[UnitID]*PlayerUnit{'1': unit},
-		CurrentPlayerID: PlayerOne,
-		ActionsLeft:     2,
-	}
+	state := NewGameState(BoardLayout{})
+	state.AddUnit(unit, Position{X: 0, Y: 0})
+	state.CurrentPlayerID = PlayerOne
+	state.ActionsLeft = 2
 
 	// Set that the unit has already used an ability this round
 	state.Set(1, CounterRoundAbilities, 1)
@@ -0,0 +1,281 @@
+package sim
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+/* =============================================================================
+   Combat aux dés.
+
+   Une attaque inflige d'ordinaire exactement ses dégâts nominaux (la
+   Puissance de l'attaquant, couvert de la cible déduit). CombatRules permet
+   de les tirer aux dés, comme autour d'une table :
+     - HitOn : l'attaque ne touche que sur un d6 supérieur ou égal ;
+     - RollDamage : les dégâts d'une attaque qui touche se tirent
+       uniformément entre 1 et 2×dégâts−1, de même moyenne.
+
+   Seules les attaques — normales ou jouées en réaction (cf. Reaction) — se
+   tirent aux dés ; les dégâts des capacités restent fixes.
+
+   L'armure (la Défense de la cible) se retranche ensuite de tout coup reçu,
+   attaque, capacité ou terrain (cf. armor). Un coup porté inflige au moins
+   un point, sauf si ArmorBlocks laisse l'armure l'annuler.
+
+   Les dés sont ceux de la partie (cf. seededDice), tirés d'une graine
+   consignée dans le relevé : Replay retrouve les mêmes jets, Undo revient
+   au jet d'avant. Copy ne les recopie pas : la recherche de l'IA ne peut pas
+   lire les jets à venir. Sur ses copies, une attaque inflige ses dégâts
+   moyens (cf. SearchStrategy), ou l'issue que la recherche expectimax
+   examine (cf. ExpectimaxStrategy). Les dégâts moyens ne sont pas
+   arrondis : leur part fractionnaire s'accumule sur la cible et compte dans
+   l'évaluation (cf. resolveExpectedAttack).
+   ========================================================================== */
+
+// CombatRules : résolution des attaques. La valeur zéro reproduit la règle
+// publiée, sans dés.
+type CombatRules struct {
+	// HitOn : résultat minimal d'un d6 pour toucher, de 1 à 6. 0 = l'attaque
+	// touche toujours.
+	HitOn int
+	// RollDamage : les dégâts d'une attaque qui touche sont tirés entre 1 et
+	// 2×dégâts−1.
+	RollDamage bool
+	// ArmorBlocks : une Défense au moins égale aux dégâts annule le coup. Par
+	// défaut, un coup porté inflige toujours au moins un point.
+	ArmorBlocks bool
+}
+
+// IsRandom indique si les attaques se tirent aux dés.
+func (r CombatRules) IsRandom() bool {
+	return r.HitOn > 1 || r.RollDamage
+}
+
+func (r CombatRules) Validate() error {
+	if r.HitOn < 0 || r.HitOn > 6 {
+		return errors.Errorf("invalid hit roll %d, expected a d6 result or 0", r.HitOn)
+	}
+	return nil
+}
+
+// hitChance renvoie la probabilité qu'une attaque touche.
+func (r CombatRules) hitChance() float64 {
+	if r.HitOn <= 1 {
+		return 1
+	}
+	return float64(7-r.HitOn) / 6
+}
+
+// attackOutcome : dégâts infligés par une attaque et leur probabilité.
+type attackOutcome struct {
+	damage      int
+	probability float64
+}
+
+// outcomes renvoie les issues possibles d'une attaque de dégâts nominaux
+// damage, par dégâts croissants. Une attaque manquée inflige 0.
+func (r CombatRules) outcomes(damage int) []attackOutcome {
+	hit := r.hitChance()
+
+	outcomes := make([]attackOutcome, 0)
+	if hit < 1 {
+		outcomes = append(outcomes, attackOutcome{damage: 0, probability: 1 - hit})
+	}
+
+	if !r.RollDamage || damage <= 1 {
+		return append(outcomes, attackOutcome{damage: damage, probability: hit})
+	}
+
+	faces := 2*damage - 1
+	for d := 1; d <= faces; d++ {
+		outcomes = append(outcomes, attackOutcome{damage: d, probability: hit / float64(faces)})
+	}
+
+	return outcomes
+}
+
+// CounterExpectedWounds : part fractionnaire, en millièmes de point, des
+// dégâts moyens encaissés par l'unité sur les copies de la recherche (cf.
+// resolveExpectedAttack).
+const CounterExpectedWounds string = "expected-wounds"
+
+// woundScale : CounterExpectedWounds compte en millièmes de point.
+const woundScale = 1000
+
+// expectedDamage renvoie les dégâts moyens qu'une attaque de dégâts nominaux
+// damage porte à la cible, armure retranchée de chaque issue. Arrondie, la
+// moyenne d'un coup faible contre une cible armée vaudrait souvent 0.
+func (s GameState) expectedDamage(targetID UnitID, damage int) float64 {
+	expected := 0.0
+	for _, outcome := range s.Combat.outcomes(damage) {
+		expected += float64(s.armor(targetID, outcome.damage)) * outcome.probability
+	}
+	return expected
+}
+
+// woundsOf renvoie les dégâts moyens que l'unité a encaissés sans qu'ils
+// atteignent un point entier (cf. CounterExpectedWounds).
+func (s GameState) woundsOf(unitID UnitID) float64 {
+	return float64(s.Get(unitID, CounterExpectedWounds, 0)) / woundScale
+}
+
+// combatDice tire les dégâts d'une attaque, et les tests de moral (cf.
+// MoraleRules). roll renvoie faux quand l'attaque n'est pas tirée : elle
+// inflige alors ses dégâts moyens (cf. resolveExpectedAttack).
+type combatDice interface {
+	roll(rules CombatRules, damage int) (int, bool)
+	d6() int
+}
+
+// seededDice : dés de la partie. Le n-ième jet ne dépend que de la graine et
+// de n : rétablir rolls suffit à rejouer les mêmes jets (cf. Undo).
+type seededDice struct {
+	seed  int64
+	rolls uint64
+}
+
+// intn renvoie un jet entre 0 et n-1.
+func (d *seededDice) intn(n int) int {
+	// splitmix64
+	x := uint64(d.seed) + (d.rolls+1)*0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	x ^= x >> 31
+	d.rolls++
+	return int(x % uint64(n))
+}
+
+func (d *seededDice) d6() int {
+	return d.intn(6) + 1
+}
+
+func (d *seededDice) roll(rules CombatRules, damage int) (int, bool) {
+	if rules.HitOn > 1 && d.intn(6)+1 < rules.HitOn {
+		return 0, true
+	}
+	if rules.RollDamage && damage > 1 {
+		return d.intn(2*damage-1) + 1, true
+	}
+	return damage, true
+}
+
+// forcedDice impose l'issue de la première attaque résolue : c'est le nœud
+// de hasard de la recherche expectimax. Les attaques suivantes (réactions)
+// infligent leurs dégâts moyens.
+type forcedDice struct {
+	damage int
+	used   bool
+}
+
+// d6 : la recherche expectimax suppose les tests de moral réussis ; elle
+// n'en tire d'ailleurs aucun (cf. checkMorale).
+func (d *forcedDice) d6() int {
+	return 6
+}
+
+func (d *forcedDice) roll(rules CombatRules, damage int) (int, bool) {
+	if d.used {
+		return 0, false
+	}
+	d.used = true
+	return d.damage, true
+}
+
+// rollAttack renvoie les dégâts d'une attaque de dégâts nominaux damage,
+// selon les règles de combat en vigueur. Faux si l'attaque n'est pas tirée,
+// faute de dés (cf. combatDice).
+func (s GameState) rollAttack(damage int) (int, bool) {
+	if !s.Combat.IsRandom() {
+		return damage, true
+	}
+	if s.dice == nil {
+		return 0, false
+	}
+	return s.dice.roll(s.Combat, damage)
+}
+
+// armor renvoie les dégâts d'un coup une fois la Défense de la cible
+// retranchée (cf. CombatRules.ArmorBlocks).
+func (s GameState) armor(targetID UnitID, damage int) int {
+	target := s.Unit(targetID)
+	if target == nil || damage <= 0 {
+		return damage
+	}
+	floor := 1
+	if s.Combat.ArmorBlocks {
+		floor = 0
+	}
+	return max(damage-target.Stats.Defense, floor)
+}
+
+// resolveAttack résout une attaque de l'unité contre la cible : dégâts
+// nominaux, puis jet de dés. Une attaque manquée n'inflige rien, pas même
+// aux statuts de la cible. Mute l'état reçu.
+func resolveAttack(state GameState, attacker *PlayerUnit, targetID UnitID) GameState {
+	nominal := state.attackDamage(attacker, targetID)
+	damage, rolled := state.rollAttack(nominal)
+	if !rolled {
+		return resolveExpectedAttack(state, targetID, nominal)
+	}
+
+	if state.Combat.IsRandom() {
+		state.emit(AttackRolled{Attacker: attacker.ID, Target: targetID, Nominal: nominal, Damage: damage})
+	}
+
+	if damage > 0 {
+		state, _ = applyDamage(state, targetID, damage)
+	}
+
+	return state
+}
+
+// resolveExpectedAttack résout une attaque qui n'est pas tirée : la cible, ou
+// le Gardien qui l'intercepte, encaisse les dégâts moyens (cf.
+// expectedDamage). Leur part entière est
+// infligée, armure déjà retranchée ; leur part fractionnaire s'ajoute à
+// CounterExpectedWounds, dont chaque point complet est infligé à son tour.
+// Mute l'état reçu.
+func resolveExpectedAttack(state GameState, targetID UnitID, nominal int) GameState {
+	// L'interception précède l'armure, comme dans dealDamage : un Gardien
+	// encaisse le coup avec sa propre Défense.
+	receiverID, redirectedFrom := targetID, UnitID(-1)
+	if nominal > 0 {
+		for {
+			interceptorID, intercepted := interceptDamage(state, receiverID)
+			if !intercepted {
+				break
+			}
+			receiverID, redirectedFrom = interceptorID, receiverID
+		}
+	}
+
+	carried := int(math.Round(state.expectedDamage(receiverID, nominal)*woundScale)) +
+		state.Get(receiverID, CounterExpectedWounds, 0)
+	whole, fraction := carried/woundScale, carried%woundScale
+
+	if fraction > 0 {
+		state.Set(receiverID, CounterExpectedWounds, fraction)
+	} else {
+		state.Del(receiverID, CounterExpectedWounds)
+	}
+	if whole > 0 {
+		state, _ = dealDamage(state, receiverID, whole, redirectedFrom, true)
+	}
+
+	return state
+}
+
+// attackOutcomes renvoie les issues de l'action si c'est une attaque tirée
+// aux dés, nil sinon.
+func attackOutcomes(state GameState, action Action) []attackOutcome {
+	attack, ok := action.(*AttackAction)
+	if !ok || !state.Combat.IsRandom() {
+		return nil
+	}
+	attacker := state.Unit(attack.UnitID())
+	if attacker == nil {
+		return nil
+	}
+	return state.Combat.outcomes(state.attackDamage(attacker, attack.TargetID()))
+}
@@ -0,0 +1,282 @@
+package sim
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+)
+
+func TestCombatOutcomes(t *testing.T) {
+	type testCase struct {
+		Name     string
+		Rules    CombatRules
+		Damage   int
+		Defense  int
+		Outcomes int
+		Expected float64
+	}
+
+	testCases := []testCase{
+		{Name: "no dice", Rules: CombatRules{}, Damage: 2, Outcomes: 1, Expected: 2},
+		{Name: "hit on 4", Rules: CombatRules{HitOn: 4}, Damage: 2, Outcomes: 2, Expected: 1},
+		{Name: "rolled damage", Rules: CombatRules{RollDamage: true}, Damage: 3, Outcomes: 5, Expected: 3},
+		{Name: "rolled damage of 1", Rules: CombatRules{RollDamage: true}, Damage: 1, Outcomes: 1, Expected: 1},
+		{Name: "hit on 3 and rolled damage", Rules: CombatRules{HitOn: 3, RollDamage: true}, Damage: 3, Outcomes: 6, Expected: 2},
+		{Name: "hit on 5", Rules: CombatRules{HitOn: 5}, Damage: 1, Outcomes: 2, Expected: 1.0 / 3},
+		{Name: "hit on 5 against armor", Rules: CombatRules{HitOn: 5}, Damage: 1, Defense: 1, Outcomes: 2, Expected: 1.0 / 3},
+		{Name: "rolled damage against blocking armor", Rules: CombatRules{HitOn: 4, RollDamage: true, ArmorBlocks: true}, Damage: 2, Defense: 1, Outcomes: 4, Expected: 0.5},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			outcomes := tc.Rules.outcomes(tc.Damage)
+			if e, g := tc.Outcomes, len(outcomes); e != g {
+				t.Errorf("len(outcomes): expected %d, got %d", e, g)
+			}
+
+			total := 0.0
+			for _, outcome := range outcomes {
+				total += outcome.probability
+			}
+			if math.Abs(total-1) > 1e-9 {
+				t.Errorf("total probability: expected 1, got %f", total)
+			}
+
+			state := NewGameState(BoardLayout{})
+			state.Combat = tc.Rules
+			state.AddUnit(&PlayerUnit{ID: 0, OwnerID: PlayerOne, Unit: Unit{Stats: core.Stats{Health: 3, Defense: tc.Defense}}}, Position{X: 0, Y: 0})
+			if e, g := tc.Expected, state.expectedDamage(0, tc.Damage); math.Abs(e-g) > 1e-9 {
+				t.Errorf("expectedDamage: expected %f, got %f", e, g)
+			}
+		})
+	}
+}
+
+// Sans dés, un coup faible contre une cible armée compte pour ses dégâts
+// moyens : un demi-point par attaque, un point toutes les deux.
+func TestExpectedAttack(t *testing.T) {
+	stats := core.Stats{Health: 3, Range: 1, Move: 1, Power: 1}
+
+	state := NewGameState(BoardLayout{})
+	state.Combat = CombatRules{HitOn: 4}
+	attacker := &PlayerUnit{ID: 0, OwnerID: PlayerOne, Unit: Unit{Stats: stats}}
+	state.AddUnit(attacker, Position{X: 1, Y: 1})
+	state.AddUnit(&PlayerUnit{ID: 1, OwnerID: PlayerTwo, Unit: Unit{Stats: core.Stats{Health: 3, Range: 1, Move: 1, Power: 1, Defense: 1}}}, Position{X: 2, Y: 2})
+	state.Set(0, CounterHealth, 3)
+	state.Set(1, CounterHealth, 3)
+
+	before := evaluateState(state, PlayerOne)
+	state = resolveAttack(state, attacker, 1)
+	if g := evaluateState(state, PlayerOne); g <= before {
+		t.Errorf("evaluation: expected more than %f after the attack, got %f", before, g)
+	}
+	if e, g := 3, state.Get(1, CounterHealth, 0); e != g {
+		t.Errorf("health after one attack: expected %d, got %d", e, g)
+	}
+
+	state = resolveAttack(state, attacker, 1)
+	if e, g := 2, state.Get(1, CounterHealth, 0); e != g {
+		t.Errorf("health after two attacks: expected %d, got %d", e, g)
+	}
+	if g := state.woundsOf(1); g != 0 {
+		t.Errorf("expected no pending wounds, got %f", g)
+	}
+}
+
+// Un Gardien intercepte l'attaque moyenne avant l'armure : c'est sa
+// Défense, nulle, qui compte, et non celle de l'unité protégée.
+func TestExpectedAttackGuardian(t *testing.T) {
+	state := NewGameState(BoardLayout{})
+	state.Combat = CombatRules{HitOn: 4}
+	attacker := &PlayerUnit{ID: 0, OwnerID: PlayerOne, Unit: Unit{Stats: core.Stats{Health: 3, Range: 1, Move: 1, Power: 3}}}
+	state.AddUnit(attacker, Position{X: 1, Y: 1})
+	state.AddUnit(&PlayerUnit{ID: 1, OwnerID: PlayerTwo, Unit: Unit{Stats: core.Stats{Health: 3, Range: 1, Move: 1, Power: 1, Defense: 2}}}, Position{X: 2, Y: 2})
+	state.AddUnit(&PlayerUnit{ID: 2, OwnerID: PlayerTwo, Unit: Unit{Stats: core.Stats{Health: 3, Range: 1, Move: 1, Power: 1}}}, Position{X: 3, Y: 2})
+	for id := range UnitID(3) {
+		state.Set(id, CounterHealth, 3)
+	}
+	state.Set(2, CounterGuardianOf, 1)
+
+	state = resolveAttack(state, attacker, 1)
+
+	if e, g := 3, state.Get(1, CounterHealth, 0); e != g {
+		t.Errorf("protected unit health: expected %d, got %d", e, g)
+	}
+	if g := state.woundsOf(1); g != 0 {
+		t.Errorf("protected unit: expected no pending wounds, got %f", g)
+	}
+	// 3 points une fois sur deux : 1,5 point.
+	if e, g := 2, state.Get(2, CounterHealth, 0); e != g {
+		t.Errorf("guardian health: expected %d, got %d", e, g)
+	}
+	if e, g := 0.5, state.woundsOf(2); e != g {
+		t.Errorf("guardian: expected %f pending wounds, got %f", e, g)
+	}
+	if e, g := -1, state.Get(2, CounterGuardianOf, -1); e != g {
+		t.Errorf("expected the guardian status to be used, got %d", g)
+	}
+}
+
+func TestSeededDice(t *testing.T) {
+	rules := CombatRules{HitOn: 3, RollDamage: true}
+
+	dice := &seededDice{seed: 42}
+	rolls := make([]int, 0, 50)
+	for range 50 {
+		damage, _ := dice.roll(rules, 3)
+		if damage < 0 || damage > 5 {
+			t.Fatalf("roll: expected damage between 0 and 5, got %d", damage)
+		}
+		rolls = append(rolls, damage)
+	}
+
+	// Rétablir le compteur de jets rejoue les mêmes jets (cf. Undo).
+	dice.rolls = 0
+	for i, e := range rolls {
+		if g, _ := dice.roll(rules, 3); e != g {
+			t.Fatalf("roll %d: expected %d, got %d", i, e, g)
+		}
+	}
+}
+
+func newDiceTestGame(seed int64) *Game {
+	return NewGame(recordTestSquad(), recordTestSquad(),
+		WithSeed(seed),
+		WithCombatRules(CombatRules{HitOn: 3, RollDamage: true}),
+		WithPlayerStrategy(PlayerOne, ExpectimaxStrategy(2, 300)),
+		WithPlayerStrategy(PlayerTwo, SearchStrategy(2, 300)),
+		WithMaxTurns(10),
+	)
+}
+
+func TestDiceReplay(t *testing.T) {
+	game := newDiceTestGame(7)
+
+	rolled := 0
+	for step := range game.Run() {
+		for _, event := range step.Events {
+			if _, ok := event.(AttackRolled); ok {
+				rolled++
+			}
+		}
+	}
+	if rolled == 0 {
+		t.Fatal("expected at least one attack to be rolled")
+	}
+
+	var buff bytes.Buffer
+	if err := game.Record().Write(&buff); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	record, err := ReadGameRecord(&buff)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if record.Setup.DiceSeed == 0 {
+		t.Fatal("expected the record to carry the dice seed")
+	}
+
+	replayed, err := Replay(record)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if e, g := printState(game.State()), printState(replayed.State()); e != g {
+		t.Errorf("final board: expected\n%s\ngot\n%s", e, g)
+	}
+	if !reflect.DeepEqual(game.Record(), replayed.Record()) {
+		t.Errorf("replayed game record differs from the original one")
+	}
+}
+
+func TestDiceUndo(t *testing.T) {
+	game := newDiceTestGame(3)
+
+	states := []GameState{game.State().Copy()}
+	for range game.Run() {
+		if game.Step() < len(states) {
+			continue
+		}
+		states = append(states, game.State().Copy())
+	}
+
+	// Rejouer depuis une position antérieure retrouve les mêmes jets.
+	step := len(states) / 2
+	if err := game.JumpTo(step); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	for range game.Run() {
+	}
+
+	if e, g := printState(states[len(states)-1]), printState(game.State()); e != g {
+		t.Errorf("final board: expected\n%s\ngot\n%s", e, g)
+	}
+}
+
+func TestExpectimaxWithoutDice(t *testing.T) {
+	game := NewGame(recordTestSquad(), recordTestSquad(), WithSeed(5))
+	state := game.State()
+
+	// Sans dés, il n'y a pas de nœud de hasard : les deux recherches jouent
+	// la même action.
+	for _, playerID := range []PlayerID{PlayerOne, PlayerTwo} {
+		expected := SearchStrategy(2, 300)(state.Copy(), playerID)
+		got := ExpectimaxStrategy(2, 300)(state.Copy(), playerID)
+		if e, g := expected.String(), got.String(); e != g {
+			t.Errorf("player %d: expected %s, got %s", playerID, e, g)
+		}
+	}
+}
+
+func TestArmor(t *testing.T) {
+	type testCase struct {
+		Name     string
+		Defense  int
+		Blocks   bool
+		Stance   bool
+		Damage   int
+		Expected DamageDealt
+	}
+
+	testCases := []testCase{
+		{Name: "no armor", Defense: 0, Damage: 3, Expected: DamageDealt{Damage: 3, Dealt: 3, Health: 2}},
+		{Name: "armor", Defense: 1, Damage: 3, Expected: DamageDealt{Damage: 3, Armor: 1, Dealt: 2, Health: 3}},
+		{Name: "minimum damage", Defense: 3, Damage: 2, Expected: DamageDealt{Damage: 2, Armor: 1, Dealt: 1, Health: 4}},
+		{Name: "blocked", Defense: 3, Blocks: true, Damage: 2, Expected: DamageDealt{Damage: 2, Armor: 2, Dealt: 0, Health: 5}},
+		{Name: "armor then stance", Defense: 1, Stance: true, Damage: 3, Expected: DamageDealt{Damage: 3, Armor: 1, Absorbed: 1, Dealt: 1, Health: 4}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			state := NewGameState(BoardLayout{})
+			state.Combat = CombatRules{ArmorBlocks: tc.Blocks}
+			state.AddUnit(&PlayerUnit{ID: 0, OwnerID: PlayerOne, Unit: Unit{Stats: core.Stats{Health: 5, Range: 1, Move: 1, Power: 1, Defense: tc.Defense}}}, Position{X: 3, Y: 3})
+			state.Set(0, CounterHealth, 5)
+			if tc.Stance {
+				state.Set(0, CounterDefensiveStance, 1)
+			}
+
+			sink := &eventSink{}
+			state.sink = sink
+
+			applyDamage(state, 0, tc.Damage)
+
+			expected := tc.Expected
+			expected.RedirectedFrom = -1
+			var dealt []DamageDealt
+			for _, event := range sink.flush() {
+				if e, ok := event.(DamageDealt); ok {
+					dealt = append(dealt, e)
+				}
+			}
+			if !reflect.DeepEqual([]DamageDealt{expected}, dealt) {
+				t.Errorf("events: expected %+v, got %+v", expected, dealt)
+			}
+			if e, g := expected.Health, state.Get(0, CounterHealth, 0); e != g {
+				t.Errorf("health: expected %d, got %d", e, g)
+			}
+		})
+	}
+}
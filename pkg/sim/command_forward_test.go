@@ -0,0 +1,87 @@
+package sim
+
+import (
+	"testing"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+)
+
+func TestCommandForward(t *testing.T) {
+	stats := core.Stats{Health: 3, Range: 1, Move: 1, Power: 1}
+
+	state := NewGameState(BoardLayout{})
+	commander := &PlayerUnit{ID: 0, OwnerID: PlayerOne, Unit: Unit{Stats: stats, Abilities: core.Abilities("00005-command-forward")}}
+	state.AddUnit(commander, Position{X: 3, Y: 0})
+	state.AddUnit(&PlayerUnit{ID: 1, OwnerID: PlayerOne, Unit: Unit{Stats: stats}}, Position{X: 2, Y: 1})
+	state.AddUnit(&PlayerUnit{ID: 2, OwnerID: PlayerOne, Unit: Unit{Stats: stats}}, Position{X: 4, Y: 1})
+	// Hors de portée de l'ordre.
+	state.AddUnit(&PlayerUnit{ID: 3, OwnerID: PlayerOne, Unit: Unit{Stats: stats}}, Position{X: 7, Y: 0})
+	state.AddUnit(&PlayerUnit{ID: 4, OwnerID: PlayerTwo, Unit: Unit{Stats: stats}}, Position{X: 3, Y: 6})
+	for id := range UnitID(5) {
+		state.Set(id, CounterHealth, 3)
+	}
+	state.CurrentPlayerID = PlayerOne
+	state.ActionsLeft = 2
+
+	actions := getPossibleCommandForwards(state, commander)
+
+	singles, pairs := 0, 0
+	var pair *AbilityAction
+	for _, action := range actions {
+		desc := action.(*AbilityAction).Description()
+		if desc.TargetUnitID == 3 {
+			t.Fatalf("unit 3 is out of range: %s", action)
+		}
+		switch len(desc.Choices) {
+		case 0:
+			singles++
+		case 1:
+			pairs++
+			if pair == nil {
+				pair = action.(*AbilityAction)
+			}
+			if desc.TargetUnitID != 1 || desc.Choices[0].UnitID != 2 {
+				t.Errorf("expected allies to be ordered, got %s", action)
+			}
+		default:
+			t.Errorf("expected at most 2 allies, got %s", action)
+		}
+	}
+
+	// Un pas de mouvement ne permet pas la diagonale : chaque allié a
+	// 4 destinations.
+	if e, g := 8, singles; e != g {
+		t.Errorf("expected %d single orders, got %d", e, g)
+	}
+	if max := commandForwardPairDestinations * commandForwardPairDestinations; pairs == 0 || pairs > max {
+		t.Errorf("expected between 1 and %d double orders, got %d", max, pairs)
+	}
+
+	// Les alliés retenus avancent vers l'ennemi.
+	desc := pair.Description()
+	if desc.TargetY != 2 || desc.Choices[0].Y != 2 {
+		t.Errorf("expected both allies to move forward, got %s", pair)
+	}
+
+	next := pair.Apply(state.Copy())
+	if e, g := (Position{X: desc.TargetX, Y: desc.TargetY}), next.PositionOf(1); e != g {
+		t.Errorf("unit 1: expected %s, got %s", e, g)
+	}
+	if e, g := (Position{X: desc.Choices[0].X, Y: desc.Choices[0].Y}), next.PositionOf(2); e != g {
+		t.Errorf("unit 2: expected %s, got %s", e, g)
+	}
+
+	// Notation : les choix se suivent.
+	text := FormatAction(pair)
+	parsed, err := ParseAction(state, text)
+	if err != nil {
+		t.Fatalf("ParseAction(%q): %+v", text, err)
+	}
+	if e, g := text, FormatAction(parsed); e != g {
+		t.Errorf("round trip: expected %q, got %q", e, g)
+	}
+
+	if e, g := 1, len(recordAction(0, PlayerOne, pair).Choices); e != g {
+		t.Errorf("expected %d recorded choice, got %d", e, g)
+	}
+}
@@ -45,14 +45,11 @@ func TestDefensiveStanceAbility(t *testing.T) {
 	}
 
 	// Create game state
-	state := GameState{
-		counters:        map[UnitID]map[string]int{},
-		Positions:       map[UnitID]Position{1: {X: 0, Y: 0}, 2: {X: 1, Y: 0}},
-		Board:           map[string]UnitID{"0,0": 1, "1,0": 2},
-		Units:           map[UnitID]*PlayerUnit{1: unit, 2: enemyUnit},
-		CurrentPlayerID: PlayerOne,
-		ActionsLeft:     2,
-	}
+	state := NewGameState(BoardLayout{})
+	state.AddUnit(unit, Position{X: 0, Y: 0})
+	state.AddUnit(enemyUnit, Position{X: 1, Y: 0})
+	state.CurrentPlayerID = PlayerOne
+	state.ActionsLeft = 2
 
 	// Set initial health
 	state.Set(1, CounterHealth, 3)
@@ -127,14 +124,10 @@ func TestDefensiveStanceCannotStack(t *testing.T) {
 	}
 
 	// Create game state with defensive stance already active
-	state := GameState{
-		counters:        map[UnitID]map[string]int{},
-		Positions:       map[UnitID]Position{1: {X: 0, Y: 0}},
-		Board:           map[string]UnitID{"0,0": 1},
-		Units:           map[UnitID]*PlayerUnit{1: unit},
-		CurrentPlayerID: PlayerOne,
-		ActionsLeft:     2,
-	}
+	state := NewGameState(BoardLayout{})
+	state.AddUnit(unit, Position{X: 0, Y: 0})
+	state.CurrentPlayerID = PlayerOne
+	state.ActionsLeft = 2
 
 	// Set defensive stance already active
 	state.Set(1, CounterDefensiveStance, 1)
@@ -172,14 +165,10 @@ func TestDefensiveStanceAlreadyUsedAbility(t *testing.T) {
 	}
 
 	// Create game state where unit has already used an ability
-	state := GameState{
-		counters:        map[UnitID]map[string]int{},
-		Positions:       map[UnitID]Position{1: {X: 0, Y: 0}},
-		Board:           map[string]UnitID{"0,0": 1},
-		Units:           map[UnitID]*PlayerUnit{1: unit},
-		CurrentPlayerID: PlayerOne,
-		ActionsLeft:     2,
-	}
+	state := NewGameState(BoardLayout{})
+	state.AddUnit(unit, Position{X: 0, Y: 0})
+	state.CurrentPlayerID = PlayerOne
+	state.ActionsLeft = 2
 
 	// Set that the unit has already used an ability this round
 	state.Set(1, CounterRoundAbilities, 1)
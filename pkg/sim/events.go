@@ -0,0 +1,183 @@
+package sim
+
+/* =============================================================================
+   Événements de partie.
+
+   Apply et ses auxiliaires modifient l'état sans rien dire : pour savoir ce
+   qui s'est passé, il fallait comparer deux instantanés. Le moteur émet
+   désormais un événement typé pour chaque effet — déplacement, dégât,
+   élimination, jet de dés, statut posé ou levé, réaction, marqueur de
+   contrôle, début et fin de tour.
+
+   Les événements partent dans le puits attaché à l'état (cf. eventSink) ;
+   Copy ne le recopie pas. La recherche de l'IA, qui ne joue que des copies,
+   n'émet donc rien et ne paie qu'un test de pointeur nil. Seule la partie
+   en cours attache un puits à son état : chaque GameStep porte les
+   événements survenus depuis le précédent (fin du tour précédent et début
+   du tour courant compris), et les observateurs (cf. WithObserver) les
+   reçoivent au fil de l'eau.
+   ========================================================================== */
+
+type EventType string
+
+const (
+	EventTurnStarted        EventType = "turn-started"
+	EventTurnEnded          EventType = "turn-ended"
+	EventUnitMoved          EventType = "unit-moved"
+	EventDamageDealt        EventType = "damage-dealt"
+	EventUnitKilled         EventType = "unit-killed"
+	EventStatusApplied      EventType = "status-applied"
+	EventStatusExpired      EventType = "status-expired"
+	EventControlPointScored EventType = "control-point-scored"
+	EventControlPointStolen EventType = "control-point-stolen"
+	EventUnitReacted        EventType = "unit-reacted"
+	EventAttackRolled       EventType = "attack-rolled"
+	EventMoraleChecked      EventType = "morale-checked"
+)
+
+type Event interface {
+	Type() EventType
+}
+
+// ObserverFunc reçoit les événements de la partie, dans l'ordre où ils
+// surviennent.
+type ObserverFunc func(event Event)
+
+type TurnStarted struct {
+	Player      PlayerID
+	ActionsLeft int
+}
+
+func (TurnStarted) Type() EventType { return EventTurnStarted }
+
+type TurnEnded struct {
+	Player PlayerID
+}
+
+func (TurnEnded) Type() EventType { return EventTurnEnded }
+
+type UnitMoved struct {
+	Unit     UnitID
+	From, To Position
+}
+
+func (UnitMoved) Type() EventType { return EventUnitMoved }
+
+// DamageDealt : Damage points de dégât visaient Target. RedirectedFrom
+// désigne l'allié protégé quand un Gardien a pris les coups à sa place
+// (-1 sinon), Armor les points retranchés par la Défense de la cible,
+// Absorbed ceux annulés par la Posture Défensive, Dealt ceux effectivement
+// retirés.
+type DamageDealt struct {
+	Target         UnitID
+	RedirectedFrom UnitID
+	Damage         int
+	Armor          int
+	Absorbed       int
+	Dealt          int
+	Health         int
+}
+
+func (DamageDealt) Type() EventType { return EventDamageDealt }
+
+type UnitKilled struct {
+	Unit     UnitID
+	Owner    PlayerID
+	Position Position
+}
+
+func (UnitKilled) Type() EventType { return EventUnitKilled }
+
+// StatusApplied : un statut (cf. Status) est posé sur l'unité.
+// Value porte la valeur du compteur, l'allié protégé pour le Gardien.
+type StatusApplied struct {
+	Unit   UnitID
+	Status string
+	Value  int
+}
+
+func (StatusApplied) Type() EventType { return EventStatusApplied }
+
+// StatusExpired : le statut est levé — expiré ou consommé.
+type StatusExpired struct {
+	Unit   UnitID
+	Status string
+}
+
+func (StatusExpired) Type() EventType { return EventStatusExpired }
+
+type ControlPointScored struct {
+	Player PlayerID
+	Total  int
+}
+
+func (ControlPointScored) Type() EventType { return EventControlPointScored }
+
+// ControlPointStolen : Player perd un marqueur au profit de By.
+type ControlPointStolen struct {
+	Player PlayerID
+	By     PlayerID
+	Total  int
+}
+
+func (ControlPointStolen) Type() EventType { return EventControlPointStolen }
+
+// UnitReacted : l'unité joue la réaction offerte par son statut Status
+// (cf. Reaction) à l'action de l'unité Trigger. Ses effets suivent.
+type UnitReacted struct {
+	Unit    UnitID
+	Status  string
+	Trigger UnitID
+}
+
+func (UnitReacted) Type() EventType { return EventUnitReacted }
+
+// AttackRolled : jet de dés d'une attaque (cf. CombatRules). Nominal :
+// dégâts de l'attaque sans dés ; Damage : dégâts tirés, 0 pour une attaque
+// manquée. Le DamageDealt qui suit, s'il y en a un, en porte les effets.
+type AttackRolled struct {
+	Attacker UnitID
+	Target   UnitID
+	Nominal  int
+	Damage   int
+}
+
+func (AttackRolled) Type() EventType { return EventAttackRolled }
+
+// MoraleChecked : test de moral d'une unité d'une escouade ébranlée (cf.
+// MoraleRules). Les effets d'un échec suivent ; une unité qui fuit quitte
+// le plateau sans UnitKilled.
+type MoraleChecked struct {
+	Unit    UnitID
+	Roll    int
+	Outcome MoraleOutcome
+}
+
+func (MoraleChecked) Type() EventType { return EventMoraleChecked }
+
+// eventSink recueille les événements d'une partie.
+type eventSink struct {
+	events    []Event
+	observers []ObserverFunc
+}
+
+func (s *eventSink) emit(event Event) {
+	s.events = append(s.events, event)
+	for _, observer := range s.observers {
+		observer(event)
+	}
+}
+
+// flush renvoie les événements recueillis depuis le précédent appel.
+func (s *eventSink) flush() []Event {
+	events := s.events
+	s.events = nil
+	return events
+}
+
+// emit transmet un événement au puits de l'état, s'il en a un.
+func (s GameState) emit(event Event) {
+	if s.sink != nil {
+		s.sink.emit(event)
+	}
+}
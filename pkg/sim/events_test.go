@@ -0,0 +1,102 @@
+package sim
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+)
+
+func TestDamageEvents(t *testing.T) {
+	stats := core.Stats{Health: 2, Range: 1, Move: 1, Power: 1}
+
+	state := NewGameState(BoardLayout{})
+	state.AddUnit(&PlayerUnit{ID: 0, OwnerID: PlayerOne, Unit: Unit{Stats: stats}}, Position{X: 1, Y: 1})
+	state.AddUnit(&PlayerUnit{ID: 1, OwnerID: PlayerOne, Unit: Unit{Stats: stats}}, Position{X: 2, Y: 1})
+	state.Set(0, CounterHealth, 2)
+	state.Set(1, CounterHealth, 2)
+	state.Set(1, CounterGuardianOf, 0)
+	state.Set(1, CounterDefensiveStance, 1)
+
+	sink := &eventSink{}
+	state.sink = sink
+
+	// Le Gardien (1) prend les coups destinés à son allié (0), sa Posture
+	// Défensive en annule un, le second l'achève.
+	state, _ = applyDamage(state, 0, 3)
+
+	expected := []Event{
+		StatusExpired{Unit: 1, Status: CounterGuardianOf},
+		StatusExpired{Unit: 1, Status: CounterDefensiveStance},
+		DamageDealt{Target: 1, RedirectedFrom: 0, Damage: 3, Absorbed: 1, Dealt: 2, Health: 0},
+		UnitKilled{Unit: 1, Owner: PlayerOne, Position: Position{X: 2, Y: 1}},
+	}
+	if g := sink.flush(); !reflect.DeepEqual(expected, g) {
+		t.Errorf("expected %+v, got %+v", expected, g)
+	}
+
+	// Une copie n'émet rien.
+	copy := state.Copy()
+	copy.MoveUnit(0, Position{X: 1, Y: 2})
+	if g := sink.flush(); len(g) != 0 {
+		t.Errorf("expected no event from a copy, got %+v", g)
+	}
+}
+
+func TestGameEvents(t *testing.T) {
+	observed := []Event{}
+
+	game := NewGame(recordTestSquad(), recordTestSquad(),
+		WithSeed(7),
+		WithPlayerStrategy(PlayerOne, SearchStrategy(2, 300)),
+		WithPlayerStrategy(PlayerTwo, SearchStrategy(2, 300)),
+		WithObserver(func(event Event) { observed = append(observed, event) }),
+		WithMaxTurns(30),
+	)
+
+	stepped := []Event{}
+	counts := map[EventType]int{}
+	for step := range game.Run() {
+		stepped = append(stepped, step.Events...)
+		for _, event := range step.Events {
+			counts[event.Type()]++
+		}
+
+		if move, ok := step.Action.(*MoveAction); ok {
+			expected := UnitMoved{Unit: move.UnitID(), To: move.TargetPos()}
+			found := false
+			for _, event := range step.Events {
+				if moved, ok := event.(UnitMoved); ok && moved.Unit == expected.Unit && moved.To == expected.To {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("expected a unit-moved event for %s, got %+v", move, step.Events)
+			}
+		}
+	}
+
+	if !reflect.DeepEqual(observed, stepped) {
+		t.Error("expected the observer to receive the events carried by the steps")
+	}
+
+	if len(stepped) == 0 || stepped[0].Type() != EventTurnStarted {
+		t.Fatalf("expected the first event to start a turn, got %+v", stepped)
+	}
+	if e, g := counts[EventTurnEnded], counts[EventTurnStarted]; g < e || g > e+1 {
+		t.Errorf("expected turns to start and end in pairs: %d started, %d ended", g, e)
+	}
+
+	losses := PlayerScores{}
+	for _, event := range stepped {
+		if killed, ok := event.(UnitKilled); ok {
+			losses[killed.Owner]++
+		}
+	}
+	state := game.State()
+	for _, playerID := range []PlayerID{PlayerOne, PlayerTwo} {
+		if e, g := len(recordTestSquad())-len(getControllableUnits(state, playerID)), losses[playerID]; e != g {
+			t.Errorf("player %d: expected %d unit-killed events, got %d", playerID, e, g)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package sim
+
+import (
+	"iter"
+	"slices"
+)
+
+/* =============================================================================
+   Brouillard de guerre.
+
+   Chaque stratégie recevait l'état complet : l'IA voyait tout. Avec
+   WithFogOfWar, un joueur ne voit plus que les unités ennemies en ligne de
+   vue d'au moins une des siennes (cf. hasLineOfSight, sans limite de
+   portée) : stratégies et réactions reçoivent sa vue (cf. View), où les
+   autres sont cachées. Les alliés partagent leur vue (cf. Allied).
+
+   Une unité cachée n'est pas tuée : son profil reste connu (les escouades
+   se déploient à découvert), ni sa position ni ses compteurs ne le sont
+   (cf. HiddenUnits). Les pertes, les marqueurs de contrôle et le reste de
+   l'état restent publics.
+
+   L'action choisie sur la vue est rejouée sur l'état réel : la partie
+   reprend l'action légale équivalente (cf. matchAction). Une action que
+   l'état réel interdit — une case occupée par une unité cachée — est
+   perdue, comme un tour passé.
+
+   Sur une vue, la recherche de l'IA croirait la partie gagnée dès que plus
+   aucun ennemi n'est visible : BeliefStrategy replace les unités cachées à
+   leur dernière position connue avant de la consulter.
+   ========================================================================== */
+
+// Sees indique si le joueur voit la case : une de ses unités, ou d'un allié,
+// l'occupe ou l'a en ligne de vue.
+func (s GameState) Sees(playerID PlayerID, pos Position) bool {
+	for unit := range s.Units() {
+		if !s.Allied(unit.OwnerID, playerID) {
+			continue
+		}
+		if from := s.PositionOf(unit.ID); from == pos || hasLineOfSight(s, from, pos) {
+			return true
+		}
+	}
+	return false
+}
+
+// View renvoie une copie de l'état telle que le joueur la voit : les unités
+// ennemies qu'il ne voit pas en sont cachées (cf. HiddenUnits).
+func (s GameState) View(playerID PlayerID) GameState {
+	view := s.Copy()
+	for unit := range s.Units() {
+		if !s.Allied(unit.OwnerID, playerID) && !s.Sees(playerID, s.PositionOf(unit.ID)) {
+			view.hide(unit.ID)
+		}
+	}
+	return view
+}
+
+// hide retire une unité du plateau sans la tuer : ni perte comptée, ni
+// événement. Sa position et ses compteurs sont oubliés. Mute l'état reçu.
+func (s GameState) hide(unitID UnitID) {
+	slot := s.slot(unitID)
+	if slot == nil || slot.unit == nil {
+		return
+	}
+	for index := range maxCounters {
+		if slot.present&(1<<index) != 0 {
+			s.toggle(counterKey(unitID, index, int(slot.counters[index])))
+		}
+	}
+	for name, value := range slot.overflow {
+		s.toggle(overflowKey(unitID, name, value))
+	}
+	if i := s.cellIndex(slot.pos); i >= 0 && s.cells[i].unit == int16(unitID)+1 {
+		s.cells[i].unit = 0
+	}
+	s.toggle(unitKey(unitID, slot.pos))
+	*slot = unitSlot{hidden: slot.unit}
+}
+
+// HiddenUnits énumère les unités cachées de la vue (cf. View), par
+// identifiant croissant. Vide sur un état complet.
+func (s GameState) HiddenUnits() iter.Seq[*PlayerUnit] {
+	return func(yield func(*PlayerUnit) bool) {
+		for i := range s.units {
+			if unit := s.units[i].hidden; unit != nil {
+				if !yield(unit) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// IsHidden indique si l'unité est cachée dans la vue.
+func (s GameState) IsHidden(unitID UnitID) bool {
+	slot := s.slot(unitID)
+	return slot != nil && slot.hidden != nil
+}
+
+// VisibleEvents filtre les événements d'un pas de jeu pour la vue reçue :
+// ceux qui désignent une unité cachée en sont retirés. Les éliminations,
+// publiques, sont toujours conservées.
+func (s GameState) VisibleEvents(events []Event) []Event {
+	visible := make([]Event, 0, len(events))
+	for _, event := range events {
+		var units []UnitID
+		switch e := event.(type) {
+		case UnitMoved:
+			units = []UnitID{e.Unit}
+		case DamageDealt:
+			units = []UnitID{e.Target}
+		case StatusApplied:
+			units = []UnitID{e.Unit}
+		case StatusExpired:
+			units = []UnitID{e.Unit}
+		case UnitReacted:
+			units = []UnitID{e.Unit}
+		case AttackRolled:
+			units = []UnitID{e.Attacker, e.Target}
+		}
+		if !slices.ContainsFunc(units, s.IsHidden) {
+			visible = append(visible, event)
+		}
+	}
+	return visible
+}
+
+// matchAction renvoie l'action légale de l'état équivalente à celle reçue
+// — même type, mêmes unités, mêmes cases — nil s'il n'y en a pas.
+func matchAction(state GameState, playerID PlayerID, action Action) Action {
+	expected := recordAction(0, playerID, action)
+	for _, candidate := range GetValidActionsForPlayer(state, playerID) {
+		if recordAction(0, playerID, candidate).equal(expected) {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// belief : dernière observation d'une unité ennemie.
+type belief struct {
+	pos    Position
+	health int
+}
+
+// BeliefStrategy adapte une stratégie au brouillard de guerre. Elle retient
+// la dernière position et la santé de chaque ennemi vu, et les lui rend
+// quand il est caché : la stratégie joue sur cet état supposé. Un ennemi qui
+// n'est plus à sa dernière position connue — la case est en vue, et vide —,
+// ou qui n'a jamais été vu, est supposé sur la case libre hors de vue la
+// plus proche de cette position, ou de sa zone de déploiement.
+//
+// L'action retenue doit être légale sur la vue : une action qui visait une
+// unité supposée cède la place à DefaultStrategy. Sans brouillard, il n'y a
+// rien à supposer et la stratégie joue sur l'état reçu.
+//
+// La mémoire est propre à la StrategyFunc renvoyée : une par joueur et par
+// partie.
+func BeliefStrategy(strategy StrategyFunc) StrategyFunc {
+	seen := map[UnitID]belief{}
+
+	return func(view GameState, playerID PlayerID) Action {
+		for unit := range view.Units() {
+			if !view.Allied(unit.OwnerID, playerID) {
+				seen[unit.ID] = belief{pos: view.PositionOf(unit.ID), health: view.Get(unit.ID, CounterHealth, 0)}
+			}
+		}
+
+		state := view.Copy()
+		supposed := false
+		for unit := range view.HiddenUnits() {
+			if view.Allied(unit.OwnerID, playerID) {
+				continue
+			}
+			last, known := seen[unit.ID]
+			if !known {
+				deployment := view.board().DeploymentPositions(unit.OwnerID)
+				if len(deployment) == 0 {
+					continue
+				}
+				last = belief{pos: deployment[0], health: unit.Stats.Health}
+			}
+			pos, found := nearestHidingPlace(state, playerID, last.pos)
+			if !found {
+				continue
+			}
+			state.AddUnit(unit, pos)
+			state.Set(unit.ID, CounterHealth, last.health)
+			supposed = true
+		}
+
+		action := strategy(state, playerID)
+		if !supposed || action == nil {
+			return action
+		}
+
+		if legal := matchAction(view, playerID, action); legal != nil {
+			return legal
+		}
+
+		return DefaultStrategy(view, playerID)
+	}
+}
+
+// nearestHidingPlace renvoie la case libre, hors de la vue du joueur, la plus
+// proche de la position donnée — elle-même si elle convient.
+func nearestHidingPlace(state GameState, playerID PlayerID, from Position) (Position, bool) {
+	board := state.board()
+
+	var (
+		best     Position
+		bestDist float64
+		found    bool
+	)
+	for y := range board.Height {
+		for x := range board.Width {
+			pos := Position{X: x, Y: y}
+			if _, occupied := state.UnitAt(pos); occupied || state.HasObstacle(pos) {
+				continue
+			}
+			dist := distance(from, pos)
+			if found && dist >= bestDist {
+				continue
+			}
+			if state.Sees(playerID, pos) {
+				continue
+			}
+			best, bestDist, found = pos, dist, true
+		}
+	}
+
+	return best, found
+}
@@ -0,0 +1,162 @@
+package sim
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+)
+
+func TestView(t *testing.T) {
+	state := NewGameState(BoardLayout{})
+	state.AddUnit(&PlayerUnit{ID: 0, OwnerID: PlayerOne, Unit: Unit{Stats: core.Stats{Health: 3, Range: 2, Move: 1, Power: 1}}}, Position{X: 0, Y: 0})
+	state.AddUnit(&PlayerUnit{ID: 1, OwnerID: PlayerTwo, Unit: Unit{Stats: core.Stats{Health: 3, Range: 2, Move: 1, Power: 1}}}, Position{X: 0, Y: 4})
+	state.AddUnit(&PlayerUnit{ID: 2, OwnerID: PlayerTwo, Unit: Unit{Stats: core.Stats{Health: 3, Range: 2, Move: 1, Power: 1}}}, Position{X: 4, Y: 4})
+	for id := range UnitID(3) {
+		state.Set(id, CounterHealth, 3)
+	}
+	// Le mur coupe la ligne de vue vers l'unité 1.
+	state.SetObstacle(Position{X: 0, Y: 2})
+
+	view := state.View(PlayerOne)
+
+	if view.Unit(1) != nil || !view.IsHidden(1) {
+		t.Errorf("expected unit 1 to be hidden")
+	}
+	if view.Unit(2) == nil || view.IsHidden(2) {
+		t.Errorf("expected unit 2 to be visible")
+	}
+	if e, g := 3, view.Get(2, CounterHealth, 0); e != g {
+		t.Errorf("unit 2 health: expected %d, got %d", e, g)
+	}
+	if e, g := -1, view.Get(1, CounterHealth, -1); e != g {
+		t.Errorf("unit 1 health: expected it to be unknown, got %d", g)
+	}
+	if _, occupied := view.UnitAt(Position{X: 0, Y: 4}); occupied {
+		t.Errorf("expected the cell of unit 1 to look empty")
+	}
+	if state.Unit(1) == nil {
+		t.Errorf("expected the view not to alter the state")
+	}
+
+	// Une vue se hache comme l'état où l'unité cachée n'a jamais existé.
+	expected := NewGameState(BoardLayout{})
+	expected.AddUnit(state.Unit(0), Position{X: 0, Y: 0})
+	expected.AddUnit(state.Unit(2), Position{X: 4, Y: 4})
+	expected.Set(0, CounterHealth, 3)
+	expected.Set(2, CounterHealth, 3)
+	if e, g := expected.Hash(), view.Hash(); e != g {
+		t.Errorf("view hash: expected %x, got %x", e, g)
+	}
+
+	events := view.VisibleEvents([]Event{
+		UnitMoved{Unit: 1},
+		UnitMoved{Unit: 2},
+		UnitKilled{Unit: 1},
+	})
+	if e, g := 2, len(events); e != g {
+		t.Errorf("len(VisibleEvents()): expected %d, got %d", e, g)
+	}
+}
+
+func TestFogOfWar(t *testing.T) {
+	squad := func() []Unit {
+		return []Unit{
+			{Stats: core.Stats{Health: 3, Range: 1, Move: 2, Power: 2}},
+			{Stats: core.Stats{Health: 2, Range: 3, Move: 1, Power: 2}},
+			{Stats: core.Stats{Health: 2, Range: 2, Move: 3, Power: 1}},
+		}
+	}
+
+	// Chaque stratégie vérifie qu'elle ne reçoit que sa vue.
+	watched := func() StrategyFunc {
+		strategy := BeliefStrategy(SearchStrategy(2, 300))
+		return func(view GameState, playerID PlayerID) Action {
+			for unit := range view.Units() {
+				if unit.OwnerID != playerID && !view.Sees(playerID, view.PositionOf(unit.ID)) {
+					t.Errorf("player %d received unit %d out of sight", playerID, unit.ID)
+				}
+			}
+			return strategy(view, playerID)
+		}
+	}
+
+	hidden := 0
+	for seed := range int64(3) {
+		game := NewGame(squad(), squad(),
+			WithSeed(seed),
+			WithFogOfWar(true),
+			WithObstacles(Position{X: 2, Y: 3}, Position{X: 5, Y: 4}),
+			WithPlayerStrategy(PlayerOne, watched()),
+			WithPlayerStrategy(PlayerTwo, watched()),
+			WithMaxTurns(10),
+		)
+		for range game.Run() {
+			for _, playerID := range []PlayerID{PlayerOne, PlayerTwo} {
+				hidden += len(slices.Collect(game.View(playerID).HiddenUnits()))
+			}
+		}
+
+		var buff bytes.Buffer
+		if err := game.Record().Write(&buff); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		record, err := ReadGameRecord(&buff)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if !record.Setup.FogOfWar {
+			t.Fatalf("expected the record to carry the fog of war")
+		}
+
+		replayed, err := Replay(record)
+		if err != nil {
+			t.Fatalf("seed %d: %+v", seed, err)
+		}
+		if e, g := printState(game.State()), printState(replayed.State()); e != g {
+			t.Errorf("seed %d: final board: expected\n%s\ngot\n%s", seed, e, g)
+		}
+	}
+
+	if hidden == 0 {
+		t.Errorf("expected at least one unit to be hidden during the games")
+	}
+}
+
+func TestBeliefStrategy(t *testing.T) {
+	state := NewGameState(BoardLayout{})
+	state.AddUnit(&PlayerUnit{ID: 0, OwnerID: PlayerOne, Unit: Unit{Stats: core.Stats{Health: 3, Range: 2, Move: 1, Power: 1}}}, Position{X: 0, Y: 0})
+	state.AddUnit(&PlayerUnit{ID: 1, OwnerID: PlayerTwo, Unit: Unit{Stats: core.Stats{Health: 3, Range: 2, Move: 1, Power: 1}}}, Position{X: 0, Y: 4})
+	state.Set(0, CounterHealth, 3)
+	state.Set(1, CounterHealth, 2)
+	state.CurrentPlayerID = PlayerOne
+	state.ActionsLeft = 2
+
+	var believed GameState
+	strategy := BeliefStrategy(func(state GameState, playerID PlayerID) Action {
+		believed = state
+		return nil
+	})
+
+	// Vu une première fois…
+	strategy(state.View(PlayerOne), PlayerOne)
+
+	// … puis caché derrière un mur : la stratégie le suppose toujours là.
+	state.SetObstacle(Position{X: 0, Y: 2})
+	view := state.View(PlayerOne)
+	if !view.IsHidden(1) {
+		t.Fatalf("expected unit 1 to be hidden")
+	}
+	strategy(view, PlayerOne)
+
+	if believed.Unit(1) == nil {
+		t.Fatalf("expected unit 1 to be supposed on the board")
+	}
+	if e, g := (Position{X: 0, Y: 4}), believed.PositionOf(1); e != g {
+		t.Errorf("supposed position: expected %s, got %s", e, g)
+	}
+	if e, g := 2, believed.Get(1, CounterHealth, 0); e != g {
+		t.Errorf("supposed health: expected %d, got %d", e, g)
+	}
+}
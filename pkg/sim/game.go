@@ -3,8 +3,10 @@ package sim
 import (
 	"iter"
 	"math/rand"
+	"slices"
 
 	"github.com/bornholm/escarmouche/pkg/core"
+	"github.com/pkg/errors"
 )
 
 type Unit struct {
@@ -16,38 +18,85 @@ type Game struct {
 	turn       uint
 	players    []PlayerID
 	strategies map[PlayerID]StrategyFunc
-	state      GameState
-	maxTurns   uint
+	// reactions : décision de chaque joueur sur les réactions de ses unités
+	// (cf. Reaction).
+	reactions map[PlayerID]ReactionFunc
+	state     GameState
+	// dice : dés de la partie (cf. CombatRules), attachés à l'état courant
+	// pendant Run comme le puits d'événements.
+	dice *seededDice
+	// fog : stratégies et réactions reçoivent la vue de leur joueur (cf.
+	// View).
+	fog      bool
+	maxTurns uint
+	// inTurn : le début du tour courant a déjà été appliqué. Run peut être
+	// interrompu entre deux actions puis relancé (cf. Replay) : il reprend
+	// alors le tour là où il s'était arrêté.
+	inTurn bool
+	// deployment : positions de déploiement fournies par l'appelant (cf.
+	// WithDeployment), vérifiées par Validate.
+	deployment map[PlayerID][]Position
+	// record : mise en place et issue de la partie ; les actions sont tirées
+	// de l'historique (cf. Record).
+	record GameRecord
+	// events : puits attaché à l'état courant pendant Run (cf. Event).
+	events *eventSink
+	// history : instantanés de la partie après chaque action, position de
+	// départ comprise ; cursor désigne la position courante.
+	history []snapshot
+	cursor  int
 }
 
 func NewGame(player1 []Unit, player2 []Unit, funcs ...OptionFunc) *Game {
-	opts := NewOptions(funcs...)
+	return NewMultiplayerGame([][]Unit{player1, player2}, funcs...)
+}
 
-	gameState := GameState{
-		counters:      map[UnitID]map[string]int{},
-		Board:         map[string]UnitID{},
-		Positions:     map[UnitID]Position{},
-		Units:         map[UnitID]*PlayerUnit{},
-		Obstacles:     map[string]bool{},
-		ControlPoints: map[PlayerID]int{},
-		TurnsPlayed:   map[PlayerID]int{},
-		Rules:         opts.CaptureRules,
-		ActionRules:   opts.ActionRules,
+// NewMultiplayerGame construit une partie à 2, 3 ou 4 joueurs : une escouade
+// par joueur, indexée par PlayerID. Sans zones de déploiement fournies, le
+// plateau en dérive une par escouade, le long d'un bord (cf.
+// BoardLayout.Players).
+func NewMultiplayerGame(squads [][]Unit, funcs ...OptionFunc) *Game {
+	opts := NewOptions(funcs...)
+	if opts.Board.Players == 0 && len(opts.Board.DeploymentZones) == 0 {
+		opts.Board.Players = len(squads)
 	}
+	opts.Board = opts.Board.Normalize()
+
+	gameState := NewGameState(opts.Board)
+	gameState.Teams = newTeams(opts.Teams)
+	gameState.Rules = opts.CaptureRules
+	gameState.ActionRules = opts.ActionRules
+	gameState.Combat = opts.Combat
+	gameState.Morale = opts.Morale
+	gameState.Victory = opts.Victory
+	gameState.Abilities = opts.Abilities
+	gameState.Costs = opts.Costs
 
 	var unitID UnitID = 0
 
-	initSquad := func(playerID PlayerID, row int, units []Unit) {
-		availablePositions := []int{0, 1, 2, 3, 4, 5, 6, 7}
+	initSquad := func(playerID PlayerID, units []Unit) {
+		// Placement par défaut : au hasard sur la rangée du fond, puis sur
+		// les rangées suivantes si elle ne suffit pas.
+		cells := opts.Board.DeploymentPositions(playerID)
+		backRow := slices.IndexFunc(cells, func(pos Position) bool {
+			return opts.Board.rowOf(playerID, pos) != opts.Board.rowOf(playerID, cells[0])
+		})
+		if backRow < 0 {
+			backRow = len(cells)
+		}
+		availablePositions := cells[:backRow]
 
-		rand.Shuffle(len(availablePositions), func(i, j int) {
+		opts.Rand.Shuffle(len(availablePositions), func(i, j int) {
 			availablePositions[i], availablePositions[j] = availablePositions[j], availablePositions[i]
 		})
 
 		placed := opts.Deployment[playerID]
 
 		for i, u := range units {
-			pos := Position{X: availablePositions[i], Y: row}
+			var pos Position
+			if i < len(cells) {
+				pos = cells[i]
+			}
 			if i < len(placed) {
 				pos = placed[i]
 			}
@@ -56,75 +105,112 @@ func NewGame(player1 []Unit, player2 []Unit, funcs ...OptionFunc) *Game {
 				OwnerID: playerID,
 				Unit: Unit{
 					Stats: core.Stats{
-						Health: u.Stats.Health,
-						Range:  u.Stats.Range,
-						Power:  u.Stats.Power,
-						Move:   u.Stats.Move,
+						Health:  u.Stats.Health,
+						Range:   u.Stats.Range,
+						Power:   u.Stats.Power,
+						Move:    u.Stats.Move,
+						Defense: u.Stats.Defense,
 					},
 					Abilities: append([]core.Ability{}, u.Abilities...),
 				},
 			}
 
+			gameState.AddUnit(unit, pos)
 			gameState.Set(unit.ID, CounterHealth, u.Stats.Health)
-
-			gameState.Board[pos.String()] = unit.ID
-			gameState.Positions[unit.ID] = pos
-			gameState.Units[unitID] = unit
+			gameState.StartingCost[playerID] += gameState.unitCost(unit)
 
 			unitID++
 		}
 	}
 
-	initSquad(PlayerOne, 0, player1)
-	initSquad(PlayerTwo, 7, player2)
+	for playerID, units := range squads {
+		initSquad(PlayerID(playerID), units)
+	}
+
+	gameState = gameState.victory().Setup(gameState)
 
 	// Obstacles : un par joueur. Fournis par l'appelant (phase de mise en
 	// place interactive) ou tirés au hasard parmi les emplacements valides.
 	obstacles := opts.Obstacles
 	if len(obstacles) == 0 {
-		obstacles = randomObstacles(gameState, 2)
+		obstacles = randomObstacles(opts.Rand, gameState, len(squads))
 	}
 	for _, pos := range obstacles {
-		if IsValidObstaclePosition(pos) {
-			if _, occupied := gameState.Board[pos.String()]; !occupied {
-				gameState.Obstacles[pos.String()] = true
+		if opts.Board.IsValidObstaclePosition(pos) {
+			if _, occupied := gameState.UnitAt(pos); !occupied {
+				gameState.SetObstacle(pos)
 			}
 		}
 	}
 
-	players := []PlayerID{PlayerOne, PlayerTwo}
+	// Terrain : fixé par l'appelant, jamais tiré au hasard. Les murs priment.
+	for _, cell := range opts.Terrain {
+		if cell.Type == TerrainOpen || !opts.Board.Contains(cell.Position) || gameState.HasObstacle(cell.Position) {
+			continue
+		}
+		gameState.SetTerrain(cell.Position, cell.Type)
+	}
+
+	players := make([]PlayerID, len(squads))
+	for i := range players {
+		players[i] = PlayerID(i)
+	}
 
-	rand.Shuffle(len(players), func(i, j int) {
+	// Le tirage a lieu même quand le premier joueur est imposé : la suite des
+	// tirages d'une graine donnée ne dépend pas de l'option.
+	opts.Rand.Shuffle(len(players), func(i, j int) {
 		players[i], players[j] = players[j], players[i]
 	})
+	first := players[0]
+	if opts.FirstPlayer >= 0 {
+		first = opts.FirstPlayer
+	}
+
+	// Les tours tournent dans l'ordre des joueurs à partir du premier (cf.
+	// nextPlayer).
+	for i := range players {
+		players[i] = (first + PlayerID(i)) % PlayerID(len(players))
+	}
 
 	gameState.CurrentPlayerID = players[0]
 
+	// Tirée en dernier, et même sans dés : les tirages précédents d'une
+	// graine donnée n'en dépendent pas.
+	dice := &seededDice{seed: opts.Rand.Int63()}
+	if opts.DiceSeed != 0 {
+		dice.seed = opts.DiceSeed
+	}
+
+	setup := newGameSetup(gameState, players[0], opts.MaxTurns, dice.seed)
+	setup.Teams = opts.Teams
+	setup.FogOfWar = opts.FogOfWar
+
 	return &Game{
 		state:      gameState,
 		players:    players,
 		turn:       0,
 		strategies: opts.Strategies,
+		reactions:  opts.Reactions,
+		dice:       dice,
+		fog:        opts.FogOfWar,
 		maxTurns:   opts.MaxTurns, // Prevent infinite games
+		deployment: opts.Deployment,
+		events:     &eventSink{observers: opts.Observers},
+		record:     GameRecord{Setup: setup},
+		history:    []snapshot{{state: gameState.Copy()}},
 	}
 }
 
 // randomObstacles tire des emplacements d'obstacle valides et libres.
-func randomObstacles(state GameState, count int) []Position {
+func randomObstacles(rng *rand.Rand, state GameState, count int) []Position {
 	candidates := make([]Position, 0)
-	for x := 0; x < BoardSize; x++ {
-		for y := 0; y < BoardSize; y++ {
-			pos := Position{X: x, Y: y}
-			if !IsValidObstaclePosition(pos) {
-				continue
-			}
-			if _, occupied := state.Board[pos.String()]; occupied {
-				continue
-			}
-			candidates = append(candidates, pos)
+	for _, pos := range state.board().ObstaclePositions() {
+		if _, occupied := state.UnitAt(pos); occupied {
+			continue
 		}
+		candidates = append(candidates, pos)
 	}
-	rand.Shuffle(len(candidates), func(i, j int) {
+	rng.Shuffle(len(candidates), func(i, j int) {
 		candidates[i], candidates[j] = candidates[j], candidates[i]
 	})
 	if count > len(candidates) {
@@ -134,7 +220,89 @@ func randomObstacles(state GameState, count int) []Position {
 }
 
 func (g *Game) State() GameState {
-	return g.state
+	state := g.state
+	state.sink = nil
+	state.dice = nil
+	return state
+}
+
+// View renvoie l'état tel que le joueur le voit : l'état complet sans
+// brouillard de guerre, sa vue sinon (cf. WithFogOfWar).
+func (g *Game) View(playerID PlayerID) GameState {
+	return g.view(g.state, playerID)
+}
+
+// view renvoie la copie de l'état que reçoivent les stratégies du joueur.
+func (g *Game) view(state GameState, playerID PlayerID) GameState {
+	if g.fog {
+		return state.View(playerID)
+	}
+	return state.Copy()
+}
+
+// Validate vérifie que le registre de la partie implémente toutes les
+// capacités de ses unités (cf. WithAbilityRegistry), que ses règles de
+// combat sont jouables et que chaque escouade et chaque équipe a sa place
+// sur le plateau. À appeler avant Run : une capacité manquante n'offrirait
+// simplement aucune action, une unité sans place serait posée sur une autre.
+func (g *Game) Validate() error {
+	players := g.state.PlayerCount()
+
+	units := make([]Unit, 0, len(g.state.units))
+	squads := make([]int, players)
+	for i := range g.state.units {
+		if unit := g.state.units[i].unit; unit != nil {
+			if int(unit.OwnerID) >= players {
+				return errors.Errorf("player %d has no deployment zone on a %d-player board", unit.OwnerID, players)
+			}
+			units = append(units, unit.Unit)
+			squads[unit.OwnerID]++
+		}
+	}
+
+	// Le placement par défaut ne dispose que de la zone de déploiement ; un
+	// placement fourni doit couvrir toute l'escouade.
+	board := g.state.board()
+	for playerID, size := range squads {
+		placed := len(g.deployment[PlayerID(playerID)])
+		cells := len(board.DeploymentPositions(PlayerID(playerID)))
+		switch {
+		case placed > 0 && placed < size:
+			return errors.Errorf("player %d: %d deployment positions for %d units", playerID, placed, size)
+		case placed == 0 && size > cells:
+			return errors.Errorf("player %d: %d units do not fit in %d deployment cells", playerID, size, cells)
+		}
+	}
+
+	occupied := map[Position]UnitID{}
+	for unit := range g.state.Units() {
+		pos := g.state.PositionOf(unit.ID)
+		if !board.Contains(pos) {
+			return errors.Errorf("unit %d deployed off the board at %s", unit.ID, pos)
+		}
+		if other, exists := occupied[pos]; exists {
+			return errors.Errorf("units %d and %d deployed on the same cell %s", other, unit.ID, pos)
+		}
+		occupied[pos] = unit.ID
+	}
+
+	if err := ValidateTeams(g.record.Setup.Teams, players); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := g.state.abilityRegistry().Validate(units...); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := g.state.Combat.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := g.state.Morale.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
 }
 
 func (g *Game) Turn() uint {
@@ -147,147 +315,205 @@ type GameStep struct {
 	Turn   uint
 	IsOver bool
 	Winner PlayerID
+	// Events : événements survenus depuis le GameStep précédent — fin du
+	// tour précédent et début du tour courant compris.
+	Events []Event
 }
 
 // beginTurn applique les transitions de début de tour pour le joueur donné.
 // Partagée entre la boucle de jeu et le minimax : l'IA simule exactement les
 // mêmes règles que le moteur.
 //
-// Sémantique des statuts (alignée sur le texte des cartes) :
-//   - les protections posées sur soi (Inciblable, Gardien) durent « jusqu'au
-//     début de votre prochain tour » : elles expirent ici ;
-//   - la Surcharge en attente se transforme en verrou d'attaque pour CE tour ;
-//   - la Posture Défensive n'expire pas avec le temps : elle est consommée
-//     par le prochain point de dégât (cf. applyDamage).
+// Les compteurs du tour sont remis à zéro, puis viennent les transitions
+// propres au joueur (cf. startTurn). En activation alternée, le tour n'est
+// qu'une activation : ces transitions suivent la manche (cf. endActivation).
 func beginTurn(state GameState, playerID PlayerID) GameState {
 	state.CurrentPlayerID = playerID
 	state.ActionsLeft = state.actionsFor(playerID)
+	state.emit(TurnStarted{Player: playerID, ActionsLeft: state.ActionsLeft})
+
+	if state.ActionRules.Alternating {
+		return state
+	}
 
+	resetRoundCounters(state)
+
+	return startTurn(state, playerID)
+}
+
+// resetRoundCounters efface les compteurs d'actions du tour de toutes les
+// unités.
+func resetRoundCounters(state GameState) {
 	state.DelAll(CounterRoundAttacks)
 	state.DelAll(CounterRoundAbilities)
 	state.DelAll(CounterRoundActions)
+}
 
-	for _, unit := range state.Units {
-		if unit.OwnerID != playerID {
-			continue
-		}
-		state.Del(unit.ID, CounterUntargetable)
-		state.Del(unit.ID, CounterGuardianOf)
-
-		if state.Get(unit.ID, CounterOverchargePending, 0) > 0 {
-			state.Del(unit.ID, CounterOverchargePending)
-			state.Set(unit.ID, CounterOverchargeLock, 1)
-		}
-	}
+// startTurn applique les transitions de début de tour propres au joueur.
+// Les statuts de durée ExpiresAtTurnStart portés par ses unités expirent
+// (cf. Status) : protections posées sur soi, Surcharge en attente qui
+// devient verrou d'attaque pour CE tour. Les unités d'une escouade ébranlée
+// passent ensuite leur test de moral (cf. MoraleRules).
+func startTurn(state GameState, playerID PlayerID) GameState {
+	expireStatuses(state, playerID, ExpiresAtTurnStart)
 
-	return state
+	return checkMorale(state, playerID)
 }
 
-// endTurn applique les transitions de fin de tour pour le joueur donné et
-// marque un point de contrôle s'il tient la zone centrale de façon exclusive,
-// selon les CaptureRules en vigueur.
-//   - la Suppression (« une seule action à son prochain tour ») expire à la
-//     fin du tour du joueur affecté ;
-//   - le verrou de Surcharge (« ne pourra pas attaquer lors de son prochain
-//     tour ») expire de même.
+// endTurn applique les transitions de fin de tour pour le joueur donné (cf.
+// closeTurn). En activation alternée, elle clôt l'activation, et la manche
+// avec (cf. endActivation).
 func endTurn(state GameState, playerID PlayerID) GameState {
-	for _, unit := range state.Units {
-		if unit.OwnerID != playerID {
-			continue
-		}
-		state.Del(unit.ID, CounterSuppressed)
-		state.Del(unit.ID, CounterOverchargeLock)
+	if state.ActionRules.Alternating {
+		return endActivation(state, playerID)
 	}
 
-	state.TurnsPlayed[playerID] = state.TurnsPlayed[playerID] + 1
+	state = closeTurn(state, playerID)
+	state.emit(TurnEnded{Player: playerID})
 
-	if controlsObjective(state, playerID) &&
-		state.TurnsPlayed[playerID] > state.Rules.HoldOffRounds {
-		state.ControlPoints[playerID] = state.ControlPoints[playerID] + 1
+	return state
+}
 
-		if state.Rules.ContestSteals {
-			opponent := getOpponentPlayerID(playerID)
-			if state.ControlPoints[opponent] > 0 {
-				state.ControlPoints[opponent] = state.ControlPoints[opponent] - 1
-			}
-		}
-	}
+// closeTurn applique les transitions de fin de tour propres au joueur, puis
+// le marquage de la condition de victoire en vigueur (cf. VictoryCondition ;
+// par défaut, un point de contrôle par zone de capture tenue seul).
+//   - les statuts de durée ExpiresAtTurnEnd portés par ses unités expirent
+//     (Suppression, verrou de Surcharge) ;
+//   - le terrain dangereux blesse les unités du joueur qui s'y tiennent,
+//     avant le décompte de la zone.
+func closeTurn(state GameState, playerID PlayerID) GameState {
+	expireStatuses(state, playerID, ExpiresAtTurnEnd)
 
-	return state
+	state = applyHazards(state, playerID)
+
+	state.TurnsPlayed[playerID]++
+
+	return state.victory().EndTurn(state, playerID)
 }
 
-// controlsObjective : au moins une unité du joueur dans la zone centrale, et
-// aucune unité adverse.
-func controlsObjective(state GameState, playerID PlayerID) bool {
-	mine, theirs := 0, 0
-	for _, pos := range ObjectiveZone {
-		unitID, occupied := state.Board[pos.String()]
-		if !occupied {
-			continue
+// controlledObjectives compte les zones de capture que le joueur tient : au
+// moins une de ses unités dans la zone, et aucune unité ennemie. Les unités
+// alliées ne la contestent pas.
+func controlledObjectives(state GameState, playerID PlayerID) int {
+	controlled := 0
+	for _, zone := range state.board().ObjectiveZones {
+		mine, theirs := 0, 0
+		for _, pos := range zone.Positions() {
+			unitID, occupied := state.UnitAt(pos)
+			if !occupied {
+				continue
+			}
+			switch owner := state.Unit(unitID).OwnerID; {
+			case owner == playerID:
+				mine++
+			case !state.Allied(owner, playerID):
+				theirs++
+			}
 		}
-		if state.Units[unitID].OwnerID == playerID {
-			mine++
-		} else {
-			theirs++
+		if mine > 0 && theirs == 0 {
+			controlled++
 		}
 	}
-	return mine > 0 && theirs == 0
+	return controlled
 }
 
 func (g *Game) Run() iter.Seq[GameStep] {
 	return func(yield func(GameStep) bool) {
+		// Chaque passage dans la boucle relit la position courante : le corps
+		// de la boucle de l'appelant peut revenir en arrière (cf. Undo).
 		for {
+			if g.record.Result != nil {
+				return
+			}
+
+			// Undo et Replay rétablissent des copies, sans puits ni dés.
+			g.state.sink = g.events
+			g.state.dice = g.dice
+
 			// Check for maximum turns reached
-			if g.turn >= g.maxTurns {
-				yield(GameStep{
+			if g.Played() >= g.maxTurns {
+				yield(g.finish(GameStep{
 					Action: nil,
 					Player: g.state.CurrentPlayerID,
 					Turn:   uint(g.turn),
 					IsOver: true,
 					Winner: GetWinnerOnTimeout(g.state),
-				})
+					Events: g.events.flush(),
+				}))
 				return
 			}
 
 			playerID := g.players[int(g.turn)%len(g.players)]
 
-			g.state = beginTurn(g.state, playerID)
+			// Un joueur éliminé passe son tour (cf. nextPlayer), comme, en
+			// activation alternée, celui qui n'a plus d'unité à activer.
+			if !g.inTurn && len(activeUnits(g.state, playerID)) == 0 {
+				g.turn++
+				continue
+			}
 
-			for range g.state.ActionsLeft {
+			if !g.inTurn {
+				g.state = beginTurn(g.state, playerID)
+				g.inTurn = true
+			}
+
+			if g.state.ActionsLeft > 0 {
 				g.state.ActionsLeft--
 
 				strategy := g.strategies[playerID]
-				action := strategy.NextAction(g.state.Copy(), playerID)
+				action := strategy.NextAction(g.view(g.state, playerID), playerID)
+
+				// Choisie sur une vue, l'action se rejoue sur l'état réel ;
+				// celui-ci peut l'interdire (cf. matchAction).
+				if g.fog && action != nil {
+					action = matchAction(g.state, playerID, action)
+				}
 
+				var reacted []UnitID
 				if action != nil {
-					g.state = action.Apply(g.state)
+					g.state, reacted = applyAction(g.state, playerID, action, g.react)
 				}
 
-				isOver, winner := isGameOver(g.state)
+				isOver, winner := terminalState(g.state)
 
-				keepGoing := yield(GameStep{
+				step := GameStep{
 					Action: action,
 					Player: playerID,
 					Turn:   uint(g.turn),
 					IsOver: isOver,
 					Winner: PlayerID(winner),
-				})
+					Events: g.events.flush(),
+				}
+				if isOver {
+					step = g.finish(step)
+				}
+
+				recorded := recordAction(g.turn, playerID, action)
+				recorded.Reactions = reacted
+				g.pushHistory(recorded)
+
+				keepGoing := yield(step)
 				if !keepGoing || isOver {
 					return
 				}
+
+				continue
 			}
 
 			g.state = endTurn(g.state, playerID)
+			g.inTurn = false
 
-			// Victoire par capture d'objectif
-			if g.state.ControlPoints[playerID] >= g.state.pointsToWin() {
-				yield(GameStep{
+			// Élimination par le terrain dangereux, victoire marquée en fin de
+			// tour (capture d'objectif…)
+			if isOver, winner := terminalState(g.state); isOver {
+				yield(g.finish(GameStep{
 					Action: nil,
 					Player: playerID,
 					Turn:   uint(g.turn),
 					IsOver: true,
-					Winner: playerID,
-				})
+					Winner: winner,
+					Events: g.events.flush(),
+				}))
 				return
 			}
 
@@ -296,34 +522,62 @@ func (g *Game) Run() iter.Seq[GameStep] {
 	}
 }
 
+// Played renvoie le nombre de tours joués, à comparer à la limite de tours.
+// Sous la règle publiée, c'est Turn. En activation alternée, où chaque
+// activation est un tour, une manche achevée compte pour un tour de chaque
+// joueur : la limite garde la durée de partie de la règle publiée.
+func (g *Game) Played() uint {
+	if !g.state.ActionRules.Alternating {
+		return g.turn
+	}
+	return uint(slices.Max(g.state.TurnsPlayed[:]) * len(g.players))
+}
+
+// react consulte la stratégie de réaction du joueur, sur sa vue de l'état
+// comme pour NextAction.
+func (g *Game) react(state GameState, playerID PlayerID, reaction *Reaction) bool {
+	decide, exists := g.reactions[playerID]
+	if !exists {
+		decide = DefaultReaction
+	}
+	return decide.React(g.view(state, playerID), playerID, reaction)
+}
+
+// finish consigne l'issue de la partie dans le relevé.
+func (g *Game) finish(step GameStep) GameStep {
+	g.record.Result = &GameResult{
+		Turn:   step.Turn,
+		Winner: step.Winner,
+	}
+	return step
+}
+
+// isGameOver : la partie s'achève quand une seule équipe a encore des
+// unités ; elle l'emporte.
 func isGameOver(state GameState) (bool, PlayerID) {
-	remainingUnits := map[PlayerID]int{}
+	var remainingUnits PlayerScores
 
-	for _, u := range state.Units {
-		remainingUnits[u.OwnerID] += 1
+	for u := range state.Units() {
+		remainingUnits[state.Team(u.OwnerID)]++
 	}
 
-	if len(remainingUnits) == 1 {
-		var winner PlayerID
-		for playerID := range remainingUnits {
-			winner = playerID
-			break
+	var winner PlayerID = -1
+	for team, remaining := range remainingUnits {
+		if remaining == 0 {
+			continue
 		}
-
-		return true, winner
+		if winner >= 0 {
+			return false, -1
+		}
+		winner = PlayerID(team)
 	}
 
-	return false, -1
+	return winner >= 0, winner
 }
 
-// GetWinnerOnTimeout départage une partie qui atteint la limite de tours :
-// points de contrôle d'abord, santé totale ensuite.
+// GetWinnerOnTimeout départage une partie qui atteint la limite de tours,
+// selon la condition de victoire en vigueur : par défaut, points de contrôle
+// d'abord, santé totale ensuite.
 func GetWinnerOnTimeout(s GameState) PlayerID {
-	if s.ControlPoints[PlayerOne] != s.ControlPoints[PlayerTwo] {
-		if s.ControlPoints[PlayerOne] > s.ControlPoints[PlayerTwo] {
-			return PlayerOne
-		}
-		return PlayerTwo
-	}
-	return GetHealthWinner(s)
+	return s.victory().TimeoutWinner(s)
 }
@@ -3,8 +3,16 @@ package sim
 import (
 	"fmt"
 	"io"
+	"iter"
 	"maps"
 	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+	"github.com/pkg/errors"
 )
 
 type PlayerID int
@@ -12,6 +20,8 @@ type PlayerID int
 const (
 	PlayerOne PlayerID = iota
 	PlayerTwo
+	PlayerThree
+	PlayerFour
 )
 
 type UnitID int
@@ -58,20 +68,25 @@ const (
 	CounterOverchargePending string = "overcharge-pending"
 	CounterOverchargeLock    string = "overcharge-lock"
 	CounterGuardianOf        string = "guardian-of"
+	// Statuts de réaction (cf. Reaction) : Tir de Couverture et Riposte.
+	CounterOverwatch     string = "overwatch"
+	CounterCounterStrike string = "counter-strike"
 )
 
+// BoardSize : côté du plateau publié (cf. BoardLayout pour les variantes).
 const BoardSize = 8
 
-// ObjectiveZone est la zone de capture centrale 2×2. La contrôler de façon
-// exclusive à la fin de son tour rapporte 1 marqueur — et en retire 1 à
-// l'adversaire ; le premier joueur à ControlPointsToWin l'emporte.
+// ObjectiveZone est la zone de capture centrale 2×2 du plateau publié. La
+// contrôler de façon exclusive à la fin de son tour rapporte 1 marqueur — et
+// en retire 1 à l'adversaire ; le premier joueur à ControlPointsToWin
+// l'emporte.
 //
 // Réglage issu du banc d'essai des conditions de victoire
 // (docs/20260817_dominant-strategy.md) : à 3 marqueurs cumulatifs, 83 % des
 // parties se gagnaient à la course en ~7 tours et une doctrine dominait à
 // 100 %. À 5 marqueurs volables, les fins se partagent moitié capture,
 // moitié élimination et aucune doctrine ne dépasse 75 %.
-var ObjectiveZone = []Position{{X: 3, Y: 3}, {X: 4, Y: 3}, {X: 3, Y: 4}, {X: 4, Y: 4}}
+var ObjectiveZone = DefaultBoardLayout.ObjectivePositions()
 
 const ControlPointsToWin = 5
 
@@ -97,17 +112,25 @@ var DefaultCaptureRules = CaptureRules{PointsToWin: ControlPointsToWin, ContestS
 // actions par tour quel que soit l'effectif — ce qui pénalise structurellement
 // les escouades nombreuses (6 unités n'en activent qu'un tiers par tour).
 // PerUnits > 0 fait croître le budget d'actions avec l'effectif survivant.
+// Alternating remplace le budget par l'activation alternée (cf.
+// activation.go).
 type ActionRules struct {
 	// Base : actions accordées chaque tour. 0 vaut 2 (règle publiée).
 	Base int
 	// PerUnits : +1 action par tranche complète de PerUnits unités encore en
 	// vie au début du tour. 0 = désactivé.
 	PerUnits int
+	// Alternating : les joueurs activent leurs unités une à une, chacun à
+	// son tour. Base et PerUnits sont alors ignorés.
+	Alternating bool
 }
 
 // actionsFor calcule le budget d'actions du joueur pour un tour, selon les
 // ActionRules en vigueur et son effectif survivant.
 func (s GameState) actionsFor(playerID PlayerID) int {
+	if s.ActionRules.Alternating {
+		return activationActions
+	}
 	base := s.ActionRules.Base
 	if base <= 0 {
 		base = 2
@@ -116,7 +139,7 @@ func (s GameState) actionsFor(playerID PlayerID) int {
 		return base
 	}
 	alive := 0
-	for _, unit := range s.Units {
+	for unit := range s.Units() {
 		if unit.OwnerID == playerID {
 			alive++
 		}
@@ -133,170 +156,601 @@ func (s GameState) pointsToWin() int {
 	return s.Rules.PointsToWin
 }
 
-// InObjectiveZone indique si une position est dans la zone de capture.
+// InObjectiveZone indique si une position est dans la zone de capture du
+// plateau publié.
 func InObjectiveZone(pos Position) bool {
-	return pos.X >= 3 && pos.X <= 4 && pos.Y >= 3 && pos.Y <= 4
+	return DefaultBoardLayout.InObjectiveZone(pos)
 }
 
-// IsValidObstaclePosition valide l'emplacement d'un obstacle : hors de la
-// zone centrale et hors des zones de déploiement (deux premières rangées de
-// chaque côté).
+// IsValidObstaclePosition valide l'emplacement d'un obstacle sur le plateau
+// publié : hors de la zone centrale et hors des zones de déploiement (deux
+// premières rangées de chaque côté).
 func IsValidObstaclePosition(pos Position) bool {
-	if pos.X < 0 || pos.X >= BoardSize || pos.Y < 0 || pos.Y >= BoardSize {
-		return false
-	}
-	if InObjectiveZone(pos) {
-		return false
-	}
-	if pos.Y <= 1 || pos.Y >= BoardSize-2 {
-		return false
-	}
-	return true
+	return DefaultBoardLayout.IsValidObstaclePosition(pos)
 }
 
+/* =============================================================================
+   Représentation de l'état.
+
+   La recherche alpha-beta copie l'état à chaque nœud : il tient donc dans
+   deux tableaux, sans map ni chaîne.
+
+     cells  une case par position du plateau, ligne par ligne : unité
+            présente, obstacle, terrain ;
+     units  une entrée par unité, indexée par UnitID : unité (nil une fois
+            tuée), position et compteurs. Les compteurs sont rangés par
+            indice (cf. counterIndex), un bit de présence par compteur
+            distinguant « absent » de « zéro ».
+
+   Copy se résume à deux copies de tableaux. La partie de l'empreinte de
+   Zobrist qui porte sur ces tableaux suit chaque mutation (cf. Hash). Les
+   accesseurs (Unit, UnitAt,
+   PositionOf, Units…) et les mutateurs (AddUnit, MoveUnit, Kill…) sont la
+   seule interface : aucun appelant ne touche aux tableaux. Les anciens
+   champs en maps (Units, Positions, Board, Obstacles, Terrain) restent
+   lisibles par des accesseurs dépréciés, qui en construisent une copie.
+
+   Un état se construit par NewGameState puis AddUnit, SetObstacle et
+   SetTerrain — c'est ce que font NewGame et les tests.
+   ========================================================================== */
+
+// MaxPlayers : nombre maximal de joueurs d'une partie ; borne les tableaux
+// indexés par joueur.
+const MaxPlayers = 4
+
+// PlayerScores porte une valeur par joueur, indexée par PlayerID.
+type PlayerScores [MaxPlayers]int
+
 type GameState struct {
-	counters  map[UnitID]map[string]int
-	Positions map[UnitID]Position
-	Board     map[string]UnitID
-	Units     map[UnitID]*PlayerUnit
-	// Obstacles : cases infranchissables qui bloquent aussi la ligne de vue.
-	// Un par joueur, posé pendant la mise en place.
-	Obstacles map[string]bool
-	// ControlPoints : tours de contrôle exclusif de la zone centrale
-	// accumulés par joueur.
-	ControlPoints map[PlayerID]int
+	cells []cell
+	units []unitSlot
+	// zobrist : empreinte des unités et de leurs compteurs, tenue à jour par
+	// les mutateurs. Partagée comme les tableaux, recopiée par Copy.
+	zobrist *uint64
+	// sink : puits des événements émis par les mutateurs (cf. Event). nil =
+	// aucun événement ; Copy ne le recopie pas.
+	sink *eventSink
+	// dice : dés de la partie (cf. CombatRules, MoraleRules). nil = dégâts
+	// moyens, pas de test de moral ; Copy ne les recopie pas.
+	dice combatDice
+	// ControlPoints : marqueurs de contrôle accumulés par joueur (cf.
+	// CaptureVictory, ZoneControlVictory).
+	ControlPoints PlayerScores
+	// Teams : équipe de chaque joueur (cf. WithTeams).
+	Teams Teams
 	// TurnsPlayed : tours achevés par joueur — support de HoldOffRounds.
-	TurnsPlayed     map[PlayerID]int
-	Rules           CaptureRules
-	ActionRules     ActionRules
+	TurnsPlayed PlayerScores
+	// Losses : coût cumulé des unités perdues par joueur (cf. Kill).
+	Losses PlayerScores
+	// StartingCost : coût cumulé des unités de chaque joueur en début de
+	// partie (cf. MoraleRules).
+	StartingCost PlayerScores
+	// Layout : géométrie du plateau. Valeur zéro = plateau publié.
+	Layout      BoardLayout
+	Rules       CaptureRules
+	ActionRules ActionRules
+	Combat      CombatRules
+	Morale      MoraleRules
+	// Victory : condition de victoire. nil = capture (cf. CaptureVictory).
+	Victory VictoryCondition
+	// Abilities : implémentations des capacités de la partie. nil = registre
+	// par défaut (cf. WithAbilityRegistry).
+	Abilities *AbilityRegistry
+	// Costs : barème du coût des unités — pertes, départage aux points,
+	// moral. nil = barème publié (cf. WithCosts).
+	Costs           *core.Costs
 	CurrentPlayerID PlayerID
 	ActionsLeft     int
 }
 
-func (s GameState) Get(unitID UnitID, name string, defaultValue int) int {
-	unitCounters, exists := s.counters[unitID]
-	if !exists {
-		return defaultValue
+type cell struct {
+	// unit : UnitID+1 de l'unité présente, 0 = case libre.
+	unit int16
+	// obstacle : case infranchissable qui bloque aussi la ligne de vue.
+	obstacle bool
+	// terrain : indice dans terrainTypes.
+	terrain uint8
+}
+
+type unitSlot struct {
+	unit *PlayerUnit
+	// hidden : unité en jeu mais cachée au joueur de la vue (cf. View).
+	hidden   *PlayerUnit
+	pos      Position
+	present  uint32
+	counters [maxCounters]int32
+	// overflow : compteurs au-delà de maxCounters (cf. registerCounter),
+	// rangés par nom. Partagée entre copies, la map n'est jamais modifiée :
+	// chaque écriture la remplace (cf. setOverflow).
+	overflow map[string]int
+}
+
+// NewGameState construit un état vide sur le plateau donné.
+func NewGameState(layout BoardLayout) GameState {
+	layout = layout.Normalize()
+	return GameState{
+		cells:   make([]cell, layout.Width*layout.Height),
+		zobrist: new(uint64),
+		Layout:  layout,
 	}
+}
 
-	counter, exists := unitCounters[name]
-	if !exists {
-		return defaultValue
+// cellIndex renvoie l'indice de la case dans cells, -1 hors du plateau.
+func (s GameState) cellIndex(pos Position) int {
+	width, height := s.Layout.Width, s.Layout.Height
+	if width == 0 {
+		width, height = BoardSize, BoardSize
+	}
+	if pos.X < 0 || pos.X >= width || pos.Y < 0 || pos.Y >= height {
+		return -1
+	}
+	return pos.Y*width + pos.X
+}
+
+// AddUnit pose une unité sur le plateau. Son identifiant fixe son rang :
+// les unités s'ajoutent dans l'ordre de leurs identifiants, sans trou
+// obligatoire.
+func (s *GameState) AddUnit(unit *PlayerUnit, pos Position) {
+	for len(s.units) <= int(unit.ID) {
+		s.units = append(s.units, unitSlot{})
+	}
+	s.units[unit.ID].unit = unit
+	s.units[unit.ID].hidden = nil
+	s.units[unit.ID].pos = pos
+	s.toggle(unitKey(unit.ID, pos))
+	if i := s.cellIndex(pos); i >= 0 {
+		s.cells[i].unit = int16(unit.ID) + 1
 	}
+}
 
-	return counter
+// SetObstacle pose un obstacle sur une case.
+func (s GameState) SetObstacle(pos Position) {
+	if i := s.cellIndex(pos); i >= 0 {
+		s.cells[i].obstacle = true
+	}
 }
 
-func (s GameState) Inc(unitID UnitID, name string, value int) int {
-	unitCounters, exists := s.counters[unitID]
-	if !exists {
-		unitCounters = map[string]int{}
+// SetTerrain type une case.
+func (s GameState) SetTerrain(pos Position, terrain Terrain) {
+	if i := s.cellIndex(pos); i >= 0 {
+		s.cells[i].terrain = terrainIndex(terrain)
 	}
+}
+
+// Unit renvoie une unité encore en jeu, nil sinon.
+func (s GameState) Unit(unitID UnitID) *PlayerUnit {
+	if unitID < 0 || int(unitID) >= len(s.units) {
+		return nil
+	}
+	return s.units[unitID].unit
+}
+
+// UnitAt renvoie l'unité qui occupe la case, le cas échéant.
+func (s GameState) UnitAt(pos Position) (UnitID, bool) {
+	i := s.cellIndex(pos)
+	if i < 0 || s.cells[i].unit == 0 {
+		return -1, false
+	}
+	return UnitID(s.cells[i].unit - 1), true
+}
+
+// PositionOf renvoie la position d'une unité encore en jeu.
+func (s GameState) PositionOf(unitID UnitID) Position {
+	if s.Unit(unitID) == nil {
+		return Position{}
+	}
+	return s.units[unitID].pos
+}
 
-	counter, exists := unitCounters[name]
+// HasObstacle indique si la case porte un obstacle.
+func (s GameState) HasObstacle(pos Position) bool {
+	i := s.cellIndex(pos)
+	return i >= 0 && s.cells[i].obstacle
+}
+
+// Units énumère les unités encore en jeu, par identifiant croissant.
+//
+// L'ordre est garanti : toute boucle dont le résultat dépend de l'ordre
+// (départage des coups de l'IA, somme de flottants, choix du premier
+// Gardien) reste reproductible d'une partie à l'autre.
+func (s GameState) Units() iter.Seq[*PlayerUnit] {
+	return func(yield func(*PlayerUnit) bool) {
+		for i := range s.units {
+			if unit := s.units[i].unit; unit != nil {
+				if !yield(unit) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// UnitCount compte les unités encore en jeu.
+func (s GameState) UnitCount() int {
+	count := 0
+	for i := range s.units {
+		if s.units[i].unit != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// UnitMap renvoie les unités en jeu indexées par identifiant, comme le
+// faisait l'ancien champ Units.
+//
+// Deprecated: construit une map à chaque appel ; utiliser Units et Unit.
+func (s GameState) UnitMap() map[UnitID]*PlayerUnit {
+	units := make(map[UnitID]*PlayerUnit, len(s.units))
+	for unit := range s.Units() {
+		units[unit.ID] = unit
+	}
+	return units
+}
+
+// Positions renvoie la position de chaque unité en jeu, comme le faisait
+// l'ancien champ du même nom. La map est une copie : l'écrire ne déplace
+// rien (cf. MoveUnit).
+//
+// Deprecated: construit une map à chaque appel ; utiliser PositionOf.
+func (s GameState) Positions() map[UnitID]Position {
+	positions := make(map[UnitID]Position, len(s.units))
+	for unit := range s.Units() {
+		positions[unit.ID] = s.units[unit.ID].pos
+	}
+	return positions
+}
+
+// Board renvoie l'unité de chaque case occupée, indexée par Position.String,
+// comme le faisait l'ancien champ du même nom. La map est une copie.
+//
+// Deprecated: construit une map à chaque appel ; utiliser UnitAt.
+func (s GameState) Board() map[string]UnitID {
+	board := make(map[string]UnitID, len(s.units))
+	for unit := range s.Units() {
+		board[s.units[unit.ID].pos.String()] = unit.ID
+	}
+	return board
+}
+
+// Obstacles renvoie les cases à obstacle, indexées par Position.String,
+// comme le faisait l'ancien champ du même nom. La map est une copie (cf.
+// SetObstacle).
+//
+// Deprecated: construit une map à chaque appel ; utiliser HasObstacle.
+func (s GameState) Obstacles() map[string]bool {
+	obstacles := map[string]bool{}
+	s.eachCell(func(pos Position, c cell) {
+		if c.obstacle {
+			obstacles[pos.String()] = true
+		}
+	})
+	return obstacles
+}
+
+// Terrain renvoie le type des cases qui ne sont pas dégagées, indexées par
+// Position.String, comme le faisait l'ancien champ du même nom. La map est
+// une copie (cf. SetTerrain).
+//
+// Deprecated: construit une map à chaque appel ; utiliser TerrainAt.
+func (s GameState) Terrain() map[string]Terrain {
+	terrain := map[string]Terrain{}
+	s.eachCell(func(pos Position, c cell) {
+		if t := terrainTypes[c.terrain]; t != TerrainOpen {
+			terrain[pos.String()] = t
+		}
+	})
+	return terrain
+}
+
+// eachCell parcourt les cases du plateau, ligne par ligne.
+func (s GameState) eachCell(fn func(pos Position, c cell)) {
+	width := s.board().Width
+	for i, c := range s.cells {
+		fn(Position{X: i % width, Y: i / width}, c)
+	}
+}
+
+// MoveUnit déplace une unité sur une case libre. Mute l'état reçu.
+func (s GameState) MoveUnit(unitID UnitID, to Position) {
+	if s.Unit(unitID) == nil {
+		return
+	}
+	from := s.units[unitID].pos
+	if i := s.cellIndex(from); i >= 0 && s.cells[i].unit == int16(unitID)+1 {
+		s.cells[i].unit = 0
+	}
+	s.toggle(unitKey(unitID, from) ^ unitKey(unitID, to))
+	s.units[unitID].pos = to
+	if i := s.cellIndex(to); i >= 0 {
+		s.cells[i].unit = int16(unitID) + 1
+	}
+	s.emit(UnitMoved{Unit: unitID, From: from, To: to})
+}
+
+// SwapUnits échange les positions de deux unités. Mute l'état reçu.
+func (s GameState) SwapUnits(a, b UnitID) {
+	if s.Unit(a) == nil || s.Unit(b) == nil {
+		return
+	}
+	posA, posB := s.units[a].pos, s.units[b].pos
+	s.units[a].pos, s.units[b].pos = posB, posA
+	s.toggle(unitKey(a, posA) ^ unitKey(a, posB) ^ unitKey(b, posB) ^ unitKey(b, posA))
+	if i := s.cellIndex(posA); i >= 0 {
+		s.cells[i].unit = int16(b) + 1
+	}
+	if i := s.cellIndex(posB); i >= 0 {
+		s.cells[i].unit = int16(a) + 1
+	}
+	s.emit(UnitMoved{Unit: a, From: posA, To: posB})
+	s.emit(UnitMoved{Unit: b, From: posB, To: posA})
+}
+
+// slot renvoie l'entrée d'une unité, vivante ou tuée, nil si inconnue.
+func (s GameState) slot(unitID UnitID) *unitSlot {
+	if unitID < 0 || int(unitID) >= len(s.units) {
+		return nil
+	}
+	return &s.units[unitID]
+}
+
+// Get lit un compteur. Les compteurs d'une unité tuée restent lisibles
+// (marqueurs de mission, cf. markedUnits).
+func (s GameState) Get(unitID UnitID, name string, defaultValue int) int {
+	slot := s.slot(unitID)
+	if slot == nil {
+		return defaultValue
+	}
+
+	index, exists := counterIndex(name)
 	if !exists {
-		counter = 0
+		if value, exists := slot.overflow[name]; exists {
+			return value
+		}
+		return defaultValue
+	}
+	if slot.present&(1<<index) == 0 {
+		return defaultValue
 	}
 
-	unitCounters[name] = counter + value
-	s.counters[unitID] = unitCounters
+	return int(slot.counters[index])
+}
 
-	return unitCounters[name]
+func (s GameState) Inc(unitID UnitID, name string, value int) int {
+	return s.Set(unitID, name, s.Get(unitID, name, 0)+value)
 }
 
 func (s GameState) Set(unitID UnitID, name string, value int) int {
-	unitCounters, exists := s.counters[unitID]
-	if !exists {
-		unitCounters = map[string]int{}
+	slot := s.slot(unitID)
+	if slot == nil {
+		return value
 	}
 
-	unitCounters[name] = value
-	s.counters[unitID] = unitCounters
+	index, err := registerCounter(name)
+	if err != nil {
+		s.setOverflow(unitID, name, value, true)
+		return value
+	}
+	if slot.present&(1<<index) != 0 {
+		s.toggle(counterKey(unitID, index, int(slot.counters[index])))
+	} else if s.sink != nil && isStatus(index) {
+		s.emit(StatusApplied{Unit: unitID, Status: name, Value: value})
+	}
+	s.toggle(counterKey(unitID, index, value))
+	slot.counters[index] = int32(value)
+	slot.present |= 1 << index
 
 	return value
 }
 
 func (s GameState) Del(unitID UnitID, name string) {
-	unitCounters, exists := s.counters[unitID]
-	if !exists {
-		unitCounters = map[string]int{}
+	slot := s.slot(unitID)
+	if slot == nil {
+		return
 	}
 
-	delete(unitCounters, name)
-	s.counters[unitID] = unitCounters
+	index, exists := counterIndex(name)
+	if !exists {
+		s.setOverflow(unitID, name, 0, false)
+		return
+	}
+	if slot.present&(1<<index) != 0 {
+		s.toggle(counterKey(unitID, index, int(slot.counters[index])))
+		slot.present &^= 1 << index
+		slot.counters[index] = 0
+		if s.sink != nil && isStatus(index) {
+			s.emit(StatusExpired{Unit: unitID, Status: name})
+		}
+	}
 }
 
 func (s GameState) DelAll(name string) {
-	for unitID := range s.counters {
-		s.Del(unitID, name)
+	index, exists := counterIndex(name)
+	if !exists {
+		for i := range s.units {
+			s.setOverflow(UnitID(i), name, 0, false)
+		}
+		return
+	}
+	for i := range s.units {
+		if s.units[i].present&(1<<index) == 0 {
+			continue
+		}
+		s.toggle(counterKey(UnitID(i), index, int(s.units[i].counters[index])))
+		s.units[i].present &^= 1 << index
+		s.units[i].counters[index] = 0
+		if s.sink != nil && isStatus(index) {
+			s.emit(StatusExpired{Unit: UnitID(i), Status: name})
+		}
 	}
 }
 
 func (s GameState) Copy() GameState {
-	copy := GameState{
-		counters:        map[UnitID]map[string]int{},
-		Positions:       map[UnitID]Position{},
-		Board:           map[string]UnitID{},
-		Units:           map[UnitID]*PlayerUnit{},
-		Obstacles:       map[string]bool{},
-		ControlPoints:   map[PlayerID]int{},
-		TurnsPlayed:     map[PlayerID]int{},
-		Rules:           s.Rules,
-		ActionRules:     s.ActionRules,
-		CurrentPlayerID: s.CurrentPlayerID,
-		ActionsLeft:     s.ActionsLeft,
-	}
-
-	maps.Copy(copy.Units, s.Units)
-	maps.Copy(copy.Board, s.Board)
-	maps.Copy(copy.Positions, s.Positions)
-	maps.Copy(copy.Obstacles, s.Obstacles)
-	maps.Copy(copy.ControlPoints, s.ControlPoints)
-	maps.Copy(copy.TurnsPlayed, s.TurnsPlayed)
-
-	// Deep copy the counters map
-	for unitID, unitCounters := range s.counters {
-		copy.counters[unitID] = make(map[string]int)
-		maps.Copy(copy.counters[unitID], unitCounters)
+	copy := s
+	copy.cells = slices.Clone(s.cells)
+	copy.units = slices.Clone(s.units)
+	copy.sink = nil
+	copy.dice = nil
+	if s.zobrist != nil {
+		zobrist := *s.zobrist
+		copy.zobrist = &zobrist
 	}
-
 	return copy
 }
 
-// Kill retire une unité du plateau. Mute l'état reçu : la convention du
-// paquet est que les Apply et leurs auxiliaires mutent, et que l'appelant
-// copie s'il veut préserver l'original.
+// Kill retire une unité du plateau et compte son coût dans les pertes de
+// son propriétaire. Mute l'état reçu : la convention du paquet est que les
+// Apply et leurs auxiliaires mutent, et que l'appelant copie s'il veut
+// préserver l'original.
 func (s GameState) Kill(unitID UnitID) GameState {
+	unit := s.Unit(unitID)
+	if unit == nil {
+		return s
+	}
+	s = s.remove(unitID)
+	s.emit(UnitKilled{Unit: unitID, Owner: unit.OwnerID, Position: s.units[unitID].pos})
+	return s
+}
+
+// remove retire une unité du plateau et compte son coût dans les pertes de
+// son propriétaire, sans rien émettre : Kill pour une élimination,
+// checkMorale pour une fuite.
+func (s GameState) remove(unitID UnitID) GameState {
+	unit := s.Unit(unitID)
+	if unit == nil {
+		return s
+	}
+	s.Losses[unit.OwnerID] += s.unitCost(unit)
 	s.Del(unitID, CounterHealth)
-	delete(s.Board, s.Positions[unitID].String())
-	delete(s.Positions, unitID)
-	delete(s.Units, unitID)
+	if i := s.cellIndex(s.units[unitID].pos); i >= 0 && s.cells[i].unit == int16(unitID)+1 {
+		s.cells[i].unit = 0
+	}
+	s.toggle(unitKey(unitID, s.units[unitID].pos))
+	s.units[unitID].unit = nil
 	return s
 }
 
+// setOverflow écrit (set) ou efface un compteur hors de la table (cf.
+// unitSlot.overflow). Mute l'état reçu.
+func (s GameState) setOverflow(unitID UnitID, name string, value int, set bool) {
+	slot := s.slot(unitID)
+	previous, exists := slot.overflow[name]
+	if !exists && !set {
+		return
+	}
+
+	overflow := make(map[string]int, len(slot.overflow)+1)
+	maps.Copy(overflow, slot.overflow)
+	if exists {
+		s.toggle(overflowKey(unitID, name, previous))
+		delete(overflow, name)
+	}
+	if set {
+		s.toggle(overflowKey(unitID, name, value))
+		overflow[name] = value
+	}
+	if len(overflow) == 0 {
+		overflow = nil
+	}
+	slot.overflow = overflow
+}
+
+// maxCounters borne le nombre de compteurs rangés par indice : le bit de
+// présence de chacun tient dans unitSlot.present. Les suivants, plus lents,
+// sont rangés par nom (cf. unitSlot.overflow).
+const maxCounters = 32
+
+var errTooManyCounters = errors.New("too many counters")
+
+// counterIndexes associe à chaque nom de compteur son indice dans
+// unitSlot.counters. Les compteurs du moteur sont enregistrés d'office ;
+// un nom inconnu l'est à sa première écriture. La table est recopiée à
+// chaque enregistrement, la lecture reste donc sans verrou.
+var (
+	counterIndexes atomic.Pointer[map[string]int]
+	counterMutex   sync.Mutex
+)
+
+func init() {
+	for _, name := range []string{
+		CounterRoundAttacks, CounterHealth, CounterRoundAbilities, CounterRoundActions,
+		CounterActivated, CounterExpectedWounds,
+		CounterDefensiveStance, CounterSuppressed, CounterUntargetable,
+		CounterOverchargePending, CounterOverchargeLock, CounterGuardianOf,
+		CounterLeader, CounterEscort,
+	} {
+		if _, err := registerCounter(name); err != nil {
+			panic(errors.Wrapf(err, "could not register counter '%s'", name))
+		}
+	}
+}
+
+func counterIndex(name string) (int, bool) {
+	indexes := counterIndexes.Load()
+	if indexes == nil {
+		return 0, false
+	}
+	index, exists := (*indexes)[name]
+	return index, exists
+}
+
+// registerCounter renvoie l'indice du compteur, enregistré au besoin.
+// errTooManyCounters : la table est pleine, le compteur reste hors table.
+func registerCounter(name string) (int, error) {
+	if index, exists := counterIndex(name); exists {
+		return index, nil
+	}
+
+	counterMutex.Lock()
+	defer counterMutex.Unlock()
+
+	current := map[string]int{}
+	if indexes := counterIndexes.Load(); indexes != nil {
+		current = *indexes
+	}
+	if index, exists := current[name]; exists {
+		return index, nil
+	}
+	if len(current) >= maxCounters {
+		return -1, errors.WithStack(errTooManyCounters)
+	}
+
+	indexes := maps.Clone(current)
+	indexes[name] = len(current)
+	counterIndexes.Store(&indexes)
+
+	return indexes[name], nil
+}
+
 func (s GameState) PrintConsole() {
 	s.Print(os.Stdout)
 }
 
 func (s GameState) Print(w io.Writer) {
-	fmt.Fprintln(w, "┌────┬────┬────┬────┬────┬────┬────┬────┐")
+	board := s.board()
+	cell := strings.Repeat("─", 4)
+	line := func(left, middle, right string) string {
+		return left + strings.Repeat(cell+middle, board.Width-1) + cell + right
+	}
 
-	for row := 0; row < 8; row++ {
+	fmt.Fprintln(w, line("┌", "┬", "┐"))
+
+	for row := 0; row < board.Height; row++ {
 		fmt.Fprint(w, "|")
 
-		for col := 0; col < 8; col++ {
+		for col := 0; col < board.Width; col++ {
 			pos := Position{X: col, Y: row}
-			if unitID, exists := s.Board[pos.String()]; exists {
+			if unitID, exists := s.UnitAt(pos); exists {
 				fmt.Fprintf(w, "%3d │", unitID)
 			} else {
 				fmt.Fprint(w, "    │")
 			}
 		}
 
-		if row == 7 {
-			fmt.Fprintln(w, "\n└────┴────┴────┴────┴────┴────┴────┴────┘")
+		if row == board.Height-1 {
+			fmt.Fprintln(w, "\n"+line("└", "┴", "┘"))
 		} else {
-			fmt.Fprintln(w, "\n├────┼────┼────┼────┼────┼────┼────┼────┤")
+			fmt.Fprintln(w, "\n"+line("├", "┼", "┤"))
 		}
 	}
 }
@@ -304,17 +758,17 @@ func (s GameState) Print(w io.Writer) {
 // canMoveTo checks if a unit can move from one position to another considering obstacles
 func canMoveTo(state GameState, from Position, to Position) bool {
 	// Check if destination is within bounds
-	if to.X < 0 || to.X >= 8 || to.Y < 0 || to.Y >= 8 {
+	if !state.board().Contains(to) {
 		return false
 	}
 
 	// Check if destination is occupied
-	if _, exists := state.Board[to.String()]; exists {
+	if _, exists := state.UnitAt(to); exists {
 		return false
 	}
 
 	// Obstacles are impassable
-	if state.Obstacles[to.String()] {
+	if state.HasObstacle(to) {
 		return false
 	}
 
@@ -323,19 +777,30 @@ func canMoveTo(state GameState, from Position, to Position) bool {
 }
 
 // getReachablePositions uses BFS to find all positions reachable within the movement range
+//
+// Le terrain difficile rend le coût des cases inégal : une case déjà atteinte
+// peut l'être de nouveau par un chemin moins cher, qui est alors réexploré.
+// Sans terrain, le parcours et l'ordre des cases rendues sont ceux d'un BFS.
 func getReachablePositions(state GameState, startPos Position, moveRange int) []Position {
 	if moveRange <= 0 {
 		return []Position{}
 	}
 
 	reachable := make([]Position, 0)
-	visited := make(map[string]bool)
+	// best : coût du meilleur chemin connu jusqu'à chaque case visitée,
+	// indexé comme les cases de l'état (-1 = non visitée).
+	best := make([]int, len(state.cells))
+	for i := range best {
+		best[i] = -1
+	}
 	queue := []struct {
 		pos   Position
 		steps int
 	}{{startPos, 0}}
 
-	visited[startPos.String()] = true
+	if i := state.cellIndex(startPos); i >= 0 {
+		best[i] = 0
+	}
 
 	// All possible movement directions (including diagonals)
 	directions := []struct{ dx, dy int }{
@@ -360,8 +825,16 @@ func getReachablePositions(state GameState, startPos Position, moveRange int) []
 				Y: current.pos.Y + dir.dy,
 			}
 
-			// Skip if already visited
-			if visited[nextPos.String()] {
+			// Skip positions out of the board
+			index := state.cellIndex(nextPos)
+			if index < 0 {
+				continue
+			}
+
+			// Skip if already reached at no greater cost
+			known := best[index]
+			visited := known >= 0
+			if visited && known <= current.steps+1 {
 				continue
 			}
 
@@ -371,30 +844,32 @@ func getReachablePositions(state GameState, startPos Position, moveRange int) []
 			}
 
 			// Calculate movement cost (diagonal moves cost more)
-			moveCost := 1
+			moveCost := state.moveCost(nextPos)
 			if dir.dx != 0 && dir.dy != 0 {
 				// Diagonal movement costs 1.4 (approximation of sqrt(2))
 				// We'll use integer math: diagonal = 1.4 ≈ 7/5, so we multiply by 5 and compare with 7*moveRange
-				totalCost := current.steps*5 + 7
+				totalCost := current.steps*5 + 7 + (moveCost-1)*5
 				if totalCost > moveRange*5 {
 					continue
 				}
-				moveCost = 1 // For simplicity, we'll treat diagonal as 1 step but check the approximation above
 			}
 
 			newSteps := current.steps + moveCost
 			if newSteps > moveRange {
 				continue
 			}
+			if visited && known <= newSteps {
+				continue
+			}
 
-			visited[nextPos.String()] = true
+			best[index] = newSteps
 			queue = append(queue, struct {
 				pos   Position
 				steps int
 			}{nextPos, newSteps})
 
 			// Add to reachable positions (excluding start position)
-			if nextPos != startPos {
+			if !visited && nextPos != startPos {
 				reachable = append(reachable, nextPos)
 			}
 		}
@@ -406,7 +881,7 @@ func getReachablePositions(state GameState, startPos Position, moveRange int) []
 func getPossibleMoves(state GameState, unit *PlayerUnit) []Action {
 	moves := make([]Action, 0)
 
-	startPos := state.Positions[unit.ID]
+	startPos := state.PositionOf(unit.ID)
 	reachablePositions := getReachablePositions(state, startPos, unit.Stats.Move)
 
 	for _, targetPos := range reachablePositions {
@@ -418,7 +893,7 @@ func getPossibleMoves(state GameState, unit *PlayerUnit) []Action {
 }
 
 func getPossiblePowers(state GameState, unit *PlayerUnit) []Action {
-	reachable := getReachableOpponentUnits(state, unit.OwnerID, state.Positions[unit.ID], unit.Stats.Range)
+	reachable := getReachableOpponentUnits(state, unit.OwnerID, state.PositionOf(unit.ID), state.attackRange(unit))
 
 	attacks := make([]Action, 0, len(reachable))
 	for _, r := range reachable {
@@ -438,11 +913,11 @@ func hasLineOfSight(state GameState, from Position, to Position) bool {
 	for i := 1; i < len(positions)-1; i++ {
 		pos := positions[i]
 		// If there's a unit at this position, line of sight is blocked
-		if _, exists := state.Board[pos.String()]; exists {
+		if _, exists := state.UnitAt(pos); exists {
 			return false
 		}
 		// Obstacles grant full cover
-		if state.Obstacles[pos.String()] {
+		if state.HasObstacle(pos) {
 			return false
 		}
 	}
@@ -498,6 +973,7 @@ func getLinePositions(from Position, to Position) []Position {
 
 func getReachableOpponentUnits(state GameState, playerID PlayerID, from Position, reach int) []UnitID {
 	reachable := make([]UnitID, 0)
+	board := state.board()
 
 	for dx := -reach; dx <= reach; dx++ {
 		for dy := -reach; dy <= reach; dy++ {
@@ -510,16 +986,16 @@ func getReachableOpponentUnits(state GameState, playerID PlayerID, from Position
 				Y: from.Y + dy,
 			}
 
-			if targetPos.X < 0 || targetPos.X >= 8 || targetPos.Y < 0 || targetPos.Y >= 8 {
+			if !board.Contains(targetPos) {
 				continue
 			}
 
-			targetUnitID, exists := state.Board[targetPos.String()]
-			if !exists || state.Units[targetUnitID].OwnerID == playerID {
+			targetUnitID, exists := state.UnitAt(targetPos)
+			if !exists || state.Allied(state.Unit(targetUnitID).OwnerID, playerID) {
 				continue
 			}
 
-			if state.Get(targetUnitID, CounterUntargetable, 0) > 0 {
+			if !isTargetable(state, targetUnitID) {
 				continue
 			}
 
@@ -544,11 +1020,12 @@ func getReachableOpponentUnits(state GameState, playerID PlayerID, from Position
 func getOpponentsInRange(state GameState, playerID PlayerID, from Position, reach int) []UnitID {
 	reachable := make([]UnitID, 0)
 
-	for uid, unit := range state.Units {
-		if unit.OwnerID == playerID {
+	for unit := range state.Units() {
+		if state.Allied(unit.OwnerID, playerID) {
 			continue
 		}
-		targetPos := state.Positions[uid]
+		uid := unit.ID
+		targetPos := state.PositionOf(uid)
 		dist := distance(from, targetPos)
 		if int(dist) <= reach {
 			reachable = append(reachable, uid)
@@ -559,26 +1036,24 @@ func getOpponentsInRange(state GameState, playerID PlayerID, from Position, reac
 }
 
 func getValidActions(state GameState, unit *PlayerUnit) []Action {
-	// Suppression : « ne peut effectuer qu'une seule action à son prochain
-	// tour » (texte de la carte). L'unité garde donc UNE action, pas zéro.
-	if state.Get(unit.ID, CounterSuppressed, 0) > 0 &&
-		state.Get(unit.ID, CounterRoundActions, 0) >= 1 {
-		return nil
-	}
-
 	actions := make([]Action, 0)
 
-	moves := getPossibleMoves(state, unit)
-	actions = append(actions, moves...)
+	// Les statuts (Suppression, verrou de Surcharge…) filtrent les types
+	// d'action permis, cf. Status.Allows ; l'activation alternée aussi, cf.
+	// activationAllows.
+	if allowsAction(state, unit.ID, ActionMove) && state.activationAllows(unit.ID, ActionMove) {
+		moves := getPossibleMoves(state, unit)
+		actions = append(actions, moves...)
+	}
 
 	roundPowers := state.Get(unit.ID, CounterRoundAttacks, 0)
-	if roundPowers == 0 && state.Get(unit.ID, CounterOverchargeLock, 0) == 0 {
+	if roundPowers == 0 && allowsAction(state, unit.ID, ActionAttack) && state.activationAllows(unit.ID, ActionAttack) {
 		attacks := getPossiblePowers(state, unit)
 		actions = append(actions, attacks...)
 	}
 
 	roundAbilities := state.Get(unit.ID, CounterRoundAbilities, 0)
-	if roundAbilities == 0 {
+	if roundAbilities == 0 && allowsAction(state, unit.ID, ActionAbility) && state.activationAllows(unit.ID, ActionAbility) {
 		abilities := getPossibleAbilities(state, unit)
 		actions = append(actions, abilities...)
 	}
@@ -602,30 +1077,38 @@ func distance(pos1, pos2 Position) float64 {
 	return float64(max(abs(pos1.X-pos2.X), abs(pos1.Y-pos2.Y)))
 }
 
-// applyDamage applies damage to a unit, respecting defensive stance and guardian redirection.
-// applyDamage inflige des dégâts en respectant Gardien (redirection) et
-// Posture Défensive (« le prochain point de dégât est annulé », consommée).
+// applyDamage inflige des dégâts en passant par les crochets des statuts :
+// interception par un allié (Gardien), armure de la cible (cf. armor), puis
+// absorption par ses statuts (Posture Défensive, « le prochain point de
+// dégât est annulé »).
 // Mute l'état reçu — cf. la convention décrite sur Kill.
 func applyDamage(state GameState, targetID UnitID, damage int) (GameState, int) {
-	// Redirection par un Gardien allié
-	if targetUnit, exists := state.Units[targetID]; exists {
-		for uid := range state.Units {
-			if state.Units[uid].OwnerID == targetUnit.OwnerID && uid != targetID {
-				if UnitID(state.Get(uid, CounterGuardianOf, -1)) == targetID {
-					state.Del(uid, CounterGuardianOf)
-					return applyDamage(state, uid, damage)
-				}
-			}
-		}
+	return dealDamage(state, targetID, damage, -1, false)
+}
+
+// dealDamage : applyDamage, redirectedFrom désignant l'allié dont un Gardien
+// prend les coups (-1 sinon). armored : l'armure a déjà été retranchée des
+// dégâts (cf. resolveExpectedAttack).
+func dealDamage(state GameState, targetID UnitID, damage int, redirectedFrom UnitID, armored bool) (GameState, int) {
+	if interceptorID, intercepted := interceptDamage(state, targetID); intercepted {
+		return dealDamage(state, interceptorID, damage, targetID, armored)
 	}
 
-	if state.Get(targetID, CounterDefensiveStance, 0) > 0 && damage > 0 {
-		damage--
-		state.Del(targetID, CounterDefensiveStance)
+	event := DamageDealt{Target: targetID, RedirectedFrom: redirectedFrom, Damage: damage}
+
+	if !armored {
+		damage = state.armor(targetID, damage)
 	}
+	event.Armor = event.Damage - damage
+
+	damage = absorbDamage(state, targetID, damage)
+	event.Absorbed = event.Damage - event.Armor - damage
 
 	remainingHealth := state.Inc(targetID, CounterHealth, -damage)
 
+	event.Dealt, event.Health = damage, remainingHealth
+	state.emit(event)
+
 	if remainingHealth <= 0 {
 		state = state.Kill(targetID)
 	}
@@ -633,21 +1116,28 @@ func applyDamage(state GameState, targetID UnitID, damage int) (GameState, int)
 	return state, remainingHealth
 }
 
-// GetHealthWinner determines winner based on total remaining health
+// GetHealthWinner determines winner based on total remaining health, summed
+// by team (cf. Team).
 func GetHealthWinner(s GameState) PlayerID {
-	healthTotals := map[PlayerID]int{}
+	var healthTotals, present PlayerScores
 
-	for _, unit := range s.Units {
+	for unit := range s.Units() {
 		health := s.Get(unit.ID, CounterHealth, unit.Stats.Health)
-		healthTotals[unit.OwnerID] += health
+		healthTotals[s.Team(unit.OwnerID)] += health
+		present[s.Team(unit.OwnerID)]++
 	}
 
+	// Parcours dans l'ordre des joueurs : à égalité, la première équipe
+	// l'emporte.
 	var winner PlayerID
 	maxHealth := -1
-	for playerID, health := range healthTotals {
-		if health > maxHealth {
+	for team := range PlayerID(s.PlayerCount()) {
+		if present[team] == 0 {
+			continue
+		}
+		if health := healthTotals[team]; health > maxHealth {
 			maxHealth = health
-			winner = playerID
+			winner = team
 		}
 	}
 
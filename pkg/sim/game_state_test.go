@@ -0,0 +1,141 @@
+package sim
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+)
+
+func TestGameStateCopy(t *testing.T) {
+	stats := core.Stats{Health: 2, Range: 1, Move: 1, Power: 1}
+
+	state := NewGameState(BoardLayout{})
+	state.AddUnit(&PlayerUnit{ID: 0, OwnerID: PlayerOne, Unit: Unit{Stats: stats}}, Position{X: 1, Y: 1})
+	state.AddUnit(&PlayerUnit{ID: 1, OwnerID: PlayerTwo, Unit: Unit{Stats: stats}}, Position{X: 5, Y: 5})
+	state.Set(0, CounterHealth, 2)
+	state.Set(1, CounterHealth, 2)
+	state.Set(1, CounterLeader, 2)
+	state.SetObstacle(Position{X: 3, Y: 3})
+
+	copy := state.Copy()
+	copy.MoveUnit(0, Position{X: 2, Y: 1})
+	copy.Inc(0, CounterRoundActions, 1)
+	copy = copy.Kill(1)
+
+	// L'original ne voit rien des mutations de la copie.
+	if e, g := (Position{X: 1, Y: 1}), state.PositionOf(0); e != g {
+		t.Errorf("original position: expected %s, got %s", e, g)
+	}
+	if id, exists := state.UnitAt(Position{X: 1, Y: 1}); !exists || id != 0 {
+		t.Errorf("original board: expected unit 0 at 1,1, got %d, %v", id, exists)
+	}
+	if e, g := 0, state.Get(0, CounterRoundActions, 0); e != g {
+		t.Errorf("original counter: expected %d, got %d", e, g)
+	}
+	if state.Unit(1) == nil || state.Losses[PlayerTwo] != 0 {
+		t.Error("expected unit 1 to be alive in the original")
+	}
+
+	if _, exists := copy.UnitAt(Position{X: 1, Y: 1}); exists {
+		t.Error("expected 1,1 to be free after the move")
+	}
+	if id, exists := copy.UnitAt(Position{X: 2, Y: 1}); !exists || id != 0 {
+		t.Errorf("expected unit 0 at 2,1, got %d, %v", id, exists)
+	}
+	if copy.Unit(1) != nil || copy.UnitCount() != 1 {
+		t.Error("expected unit 1 to be dead in the copy")
+	}
+	if _, exists := copy.UnitAt(Position{X: 5, Y: 5}); exists {
+		t.Error("expected 5,5 to be free after the kill")
+	}
+	if e, g := state.unitCost(state.Unit(1)), copy.Losses[PlayerTwo]; e != g {
+		t.Errorf("losses: expected %d, got %d", e, g)
+	}
+
+	// Les marqueurs survivent à l'unité, pas sa santé.
+	if e, g := 2, copy.Get(1, CounterLeader, 0); e != g {
+		t.Errorf("leader marker: expected %d, got %d", e, g)
+	}
+	if e, g := -1, copy.Get(1, CounterHealth, -1); e != g {
+		t.Errorf("dead unit health: expected %d, got %d", e, g)
+	}
+
+	if !copy.HasObstacle(Position{X: 3, Y: 3}) || copy.HasObstacle(Position{X: 9, Y: 9}) {
+		t.Error("unexpected obstacles")
+	}
+}
+
+func TestCounterOverflow(t *testing.T) {
+	// La table des compteurs est globale : le test la rend telle quelle.
+	saved := counterIndexes.Load()
+	defer counterIndexes.Store(saved)
+	for i := 0; ; i++ {
+		if _, err := registerCounter(fmt.Sprintf("test-filler-%d", i)); err != nil {
+			break
+		}
+	}
+
+	state := NewGameState(BoardLayout{})
+	state.AddUnit(&PlayerUnit{ID: 0, OwnerID: PlayerOne, Unit: Unit{Stats: core.Stats{Health: 2}}}, Position{X: 1, Y: 1})
+	empty := state.Hash()
+
+	// Au-delà de la table, le compteur est rangé par nom.
+	if e, g := 3, state.Set(0, "test-overflow", 3); e != g {
+		t.Errorf("Set: expected %d, got %d", e, g)
+	}
+	if e, g := 3, state.Get(0, "test-overflow", 0); e != g {
+		t.Errorf("Get: expected %d, got %d", e, g)
+	}
+	if state.Hash() == empty {
+		t.Error("expected the counter to change the hash")
+	}
+
+	copy := state.Copy()
+	if e, g := 4, copy.Inc(0, "test-overflow", 1); e != g {
+		t.Errorf("Inc: expected %d, got %d", e, g)
+	}
+	if e, g := 3, state.Get(0, "test-overflow", 0); e != g {
+		t.Errorf("original counter: expected %d, got %d", e, g)
+	}
+
+	state.Del(0, "test-overflow")
+	if e, g := -1, state.Get(0, "test-overflow", -1); e != g {
+		t.Errorf("deleted counter: expected %d, got %d", e, g)
+	}
+	if e, g := empty, state.Hash(); e != g {
+		t.Errorf("hash after delete: expected %x, got %x", e, g)
+	}
+
+	// Un statut a besoin d'un indice.
+	if err := RegisterStatus(Status{Name: "test-overflow-status"}); err == nil {
+		t.Error("expected an error registering a status past the counter table")
+	}
+}
+
+func TestDeprecatedMaps(t *testing.T) {
+	stats := core.Stats{Health: 2, Range: 1, Move: 1, Power: 1}
+
+	state := NewGameState(BoardLayout{})
+	unit := &PlayerUnit{ID: 0, OwnerID: PlayerOne, Unit: Unit{Stats: stats}}
+	state.AddUnit(unit, Position{X: 1, Y: 2})
+	state.SetObstacle(Position{X: 3, Y: 3})
+	state.SetTerrain(Position{X: 4, Y: 5}, TerrainDifficult)
+
+	if e, g := (map[UnitID]*PlayerUnit{0: unit}), state.UnitMap(); !reflect.DeepEqual(e, g) {
+		t.Errorf("UnitMap: expected %v, got %v", e, g)
+	}
+	if e, g := (map[UnitID]Position{0: {X: 1, Y: 2}}), state.Positions(); !reflect.DeepEqual(e, g) {
+		t.Errorf("Positions: expected %v, got %v", e, g)
+	}
+	if e, g := (map[string]UnitID{"1,2": 0}), state.Board(); !reflect.DeepEqual(e, g) {
+		t.Errorf("Board: expected %v, got %v", e, g)
+	}
+	if e, g := (map[string]bool{"3,3": true}), state.Obstacles(); !reflect.DeepEqual(e, g) {
+		t.Errorf("Obstacles: expected %v, got %v", e, g)
+	}
+	if e, g := (map[string]Terrain{"4,5": TerrainDifficult}), state.Terrain(); !reflect.DeepEqual(e, g) {
+		t.Errorf("Terrain: expected %v, got %v", e, g)
+	}
+}
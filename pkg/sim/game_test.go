@@ -2,7 +2,9 @@ package sim
 
 import (
 	"context"
+	"fmt"
 	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -79,3 +81,40 @@ func TestSimulation(t *testing.T) {
 	}
 
 }
+
+func TestSeededGameIsReproducible(t *testing.T) {
+	squad := func() []Unit {
+		return []Unit{
+			{Stats: core.Stats{Health: 3, Range: 1, Move: 2, Power: 2}, Abilities: core.Abilities("00000-charge")},
+			{Stats: core.Stats{Health: 2, Range: 3, Move: 1, Power: 2}, Abilities: core.Abilities("00008-guardian")},
+			{Stats: core.Stats{Health: 2, Range: 2, Move: 3, Power: 1}},
+		}
+	}
+
+	play := func() []string {
+		game := NewGame(squad(), squad(),
+			WithSeed(42),
+			WithPlayerStrategy(PlayerOne, SearchStrategy(2, 400)),
+			WithPlayerStrategy(PlayerTwo, SearchStrategy(2, 400)),
+			WithMaxTurns(12),
+		)
+
+		var buff strings.Builder
+		game.State().Print(&buff)
+		log := []string{buff.String()}
+
+		for step := range game.Run() {
+			log = append(log, fmt.Sprintf("%d P%d %v over=%t winner=%d", step.Turn, step.Player, step.Action, step.IsOver, step.Winner))
+		}
+
+		buff.Reset()
+		game.State().Print(&buff)
+		return append(log, buff.String())
+	}
+
+	first, second := play(), play()
+
+	if !slices.Equal(first, second) {
+		t.Fatalf("same seed, different games:\n%s\n---\n%s", strings.Join(first, "\n"), strings.Join(second, "\n"))
+	}
+}
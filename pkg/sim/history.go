@@ -0,0 +1,112 @@
+package sim
+
+import "github.com/pkg/errors"
+
+/* =============================================================================
+   Historique de partie.
+
+   La partie conserve un instantané après chaque décision de stratégie, en
+   plus de la position de départ : l'instantané n°k est la partie telle
+   qu'elle se présentait après k actions (état de jeu, tour, tour entamé ou
+   non). Revenir à un instantané puis relancer Run reprend la partie à cet
+   endroit, avec les stratégies en place — de quoi reprendre un coup, ou
+   explorer une variante à partir d'un moment clé (cf. SetPlayerStrategy).
+
+   Undo, Redo et JumpTo s'appellent entre deux GameStep, depuis le corps de
+   la boucle sur Run ou une fois celle-ci interrompue. Jouer une nouvelle
+   action après un retour en arrière efface les actions annulées.
+   ========================================================================== */
+
+type snapshot struct {
+	state  GameState
+	turn   uint
+	inTurn bool
+	// rolls : jets de dés de la partie à cet instant (cf. seededDice).
+	rolls uint64
+	// action : décision qui a mené à cet instantané. Sans objet pour la
+	// position de départ.
+	action RecordedAction
+	// result : issue de la partie, quand cette décision y a mis fin.
+	result *GameResult
+}
+
+// pushHistory ajoute la position courante à l'historique, après la décision
+// donnée, en remplaçant d'éventuelles actions annulées.
+func (g *Game) pushHistory(action RecordedAction) {
+	g.history = append(g.history[:g.cursor+1], snapshot{
+		state:  g.state.Copy(),
+		turn:   g.turn,
+		inTurn: g.inTurn,
+		rolls:  g.dice.rolls,
+		action: action,
+		result: g.record.Result,
+	})
+	g.cursor++
+}
+
+// restore replace la partie dans l'état de l'instantané donné. L'instantané
+// reste intact : Apply modifie l'état qu'il reçoit, on en joue une copie.
+func (g *Game) restore(step int) {
+	s := g.history[step]
+
+	g.state = s.state.Copy()
+	g.events.flush()
+	g.turn = s.turn
+	g.inTurn = s.inTurn
+	g.dice.rolls = s.rolls
+	g.record.Result = nil
+	if s.result != nil {
+		result := *s.result
+		g.record.Result = &result
+	}
+	g.cursor = step
+}
+
+// Step renvoie le nombre d'actions jouées jusqu'à la position courante.
+func (g *Game) Step() int {
+	return g.cursor
+}
+
+// History renvoie les actions de l'historique, y compris celles annulées
+// qu'un Redo rétablirait : seules les Step() premières sont jouées.
+func (g *Game) History() []RecordedAction {
+	actions := make([]RecordedAction, 0, len(g.history)-1)
+	for _, s := range g.history[1:] {
+		actions = append(actions, s.action)
+	}
+	return actions
+}
+
+// Undo annule la dernière action jouée. Renvoie false s'il n'y en a aucune.
+func (g *Game) Undo() bool {
+	if g.cursor == 0 {
+		return false
+	}
+	g.restore(g.cursor - 1)
+	return true
+}
+
+// Redo rejoue la dernière action annulée. Renvoie false s'il n'y en a aucune.
+func (g *Game) Redo() bool {
+	if g.cursor >= len(g.history)-1 {
+		return false
+	}
+	g.restore(g.cursor + 1)
+	return true
+}
+
+// JumpTo replace la partie après ses step premières actions. step peut
+// désigner une action annulée, tant qu'elle n'a pas été effacée.
+func (g *Game) JumpTo(step int) error {
+	if step < 0 || step >= len(g.history) {
+		return errors.Errorf("no step %d in history (0-%d)", step, len(g.history)-1)
+	}
+	g.restore(step)
+	return nil
+}
+
+// SetPlayerStrategy change la stratégie d'un joueur en cours de partie, par
+// exemple pour explorer une variante après un JumpTo.
+func (g *Game) SetPlayerStrategy(playerID PlayerID, strategy StrategyFunc) {
+	g.strategies[playerID] = strategy
+}
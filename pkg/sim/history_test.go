@@ -0,0 +1,124 @@
+package sim
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUndoRedo(t *testing.T) {
+	game := NewGame(recordTestSquad(), recordTestSquad(),
+		WithSeed(7),
+		WithPlayerStrategy(PlayerOne, SearchStrategy(2, 300)),
+		WithPlayerStrategy(PlayerTwo, SearchStrategy(2, 300)),
+		WithMaxTurns(10),
+	)
+
+	// Position après chaque action, telle que vue depuis la boucle de jeu.
+	// La fin de partie au temps ou à la capture n'est pas une action.
+	states := []GameState{game.State().Copy()}
+	turns := []uint{0}
+	for range game.Run() {
+		if game.Step() < len(states) {
+			continue
+		}
+		states = append(states, game.State().Copy())
+		turns = append(turns, game.Turn())
+	}
+
+	played := game.Record()
+	if played.Result == nil {
+		t.Fatal("expected the game to be over")
+	}
+	if e, g := len(states)-1, game.Step(); e != g {
+		t.Fatalf("game.Step(): expected %d, got %d", e, g)
+	}
+
+	for step := len(states) - 2; step >= 0; step-- {
+		if !game.Undo() {
+			t.Fatalf("undo to step %d: expected an action to undo", step)
+		}
+		// Le plateau initial n'a pas encore vu le début du premier tour.
+		if step > 0 && !reflect.DeepEqual(states[step], game.State()) {
+			t.Fatalf("undo to step %d: expected\n%s\ngot\n%s", step, printState(states[step]), printState(game.State()))
+		}
+		if e, g := turns[step], game.Turn(); e != g {
+			t.Fatalf("undo to step %d: turn: expected %d, got %d", step, e, g)
+		}
+		if game.Record().Result != nil {
+			t.Fatalf("undo to step %d: expected no result", step)
+		}
+	}
+
+	if game.Undo() {
+		t.Fatal("expected nothing left to undo")
+	}
+
+	for step := 1; step < len(states); step++ {
+		if !game.Redo() {
+			t.Fatalf("redo to step %d: expected an action to redo", step)
+		}
+		if !reflect.DeepEqual(states[step], game.State()) {
+			t.Fatalf("redo to step %d: expected\n%s\ngot\n%s", step, printState(states[step]), printState(game.State()))
+		}
+	}
+
+	if game.Redo() {
+		t.Fatal("expected nothing left to redo")
+	}
+
+	// La dernière action rétablie, la partie se termine comme la première
+	// fois.
+	for range game.Run() {
+	}
+
+	if !reflect.DeepEqual(played, game.Record()) {
+		t.Errorf("expected the record to be the same after undo/redo")
+	}
+}
+
+func TestJumpToBranch(t *testing.T) {
+	game := playRecordTestGame(t, 10)
+	played := game.Record()
+
+	branch := len(played.Actions) / 2
+
+	if err := game.JumpTo(branch); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := game.JumpTo(len(played.Actions) + 1); err == nil {
+		t.Error("expected an error when jumping past the history")
+	}
+
+	// Le joueur à qui revient la décision passe désormais son tour.
+	next := played.Actions[branch]
+	game.SetPlayerStrategy(next.Player, func(state GameState, playerID PlayerID) Action {
+		return nil
+	})
+
+	steps := 0
+	for range game.Run() {
+		steps++
+		if steps == 1 {
+			break
+		}
+	}
+
+	record := game.Record()
+	if e, g := branch+1, len(record.Actions); e != g {
+		t.Fatalf("len(record.Actions): expected %d, got %d", e, g)
+	}
+	if !reflect.DeepEqual(played.Actions[:branch], record.Actions[:branch]) {
+		t.Errorf("expected actions before the branch to be kept")
+	}
+	if record.Actions[branch].Type != "" {
+		t.Errorf("expected a pass after the branch, got %+v", record.Actions[branch])
+	}
+	if e, g := branch+1, len(game.History()); e != g {
+		t.Errorf("expected undone actions to be dropped: len(game.History()): expected %d, got %d", e, g)
+	}
+
+	// La variante est un relevé à part entière.
+	if _, err := Replay(record); err != nil {
+		t.Errorf("%+v", err)
+	}
+}
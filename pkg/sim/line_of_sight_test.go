@@ -49,14 +49,11 @@ func TestLineOfSight(t *testing.T) {
 
 	// Test case 1: Clear line of sight
 	t.Run("Clear line of sight", func(t *testing.T) {
-		state := GameState{
-			counters:        map[UnitID]map[string]int{},
-			Positions:       map[UnitID]Position{1: {X: 0, Y: 0}, 2: {X: 2, Y: 0}},
-			Board:           map[string]UnitID{"0,0": 1, "2,0": 2},
-			Units:           map[UnitID]*PlayerUnit{1: attackerUnit, 2: targetUnit},
-			CurrentPlayerID: PlayerOne,
-			ActionsLeft:     2,
-		}
+		state := NewGameState(BoardLayout{})
+		state.AddUnit(attackerUnit, Position{X: 0, Y: 0})
+		state.AddUnit(targetUnit, Position{X: 2, Y: 0})
+		state.CurrentPlayerID = PlayerOne
+		state.ActionsLeft = 2
 
 		reachable := getReachableOpponentUnits(state, PlayerOne, Position{X: 0, Y: 0}, 3)
 
@@ -71,14 +68,12 @@ func TestLineOfSight(t *testing.T) {
 
 	// Test case 2: Blocked line of sight
 	t.Run("Blocked line of sight", func(t *testing.T) {
-		state := GameState{
-			counters:        map[UnitID]map[string]int{},
-			Positions:       map[UnitID]Position{1: {X: 0, Y: 0}, 2: {X: 2, Y: 0}, 3: {X: 1, Y: 0}},
-			Board:           map[string]UnitID{"0,0": 1, "2,0": 2, "1,0": 3},
-			Units:           map[UnitID]*PlayerUnit{1: attackerUnit, 2: targetUnit, 3: blockingUnit},
-			CurrentPlayerID: PlayerOne,
-			ActionsLeft:     2,
-		}
+		state := NewGameState(BoardLayout{})
+		state.AddUnit(attackerUnit, Position{X: 0, Y: 0})
+		state.AddUnit(targetUnit, Position{X: 2, Y: 0})
+		state.AddUnit(blockingUnit, Position{X: 1, Y: 0})
+		state.CurrentPlayerID = PlayerOne
+		state.ActionsLeft = 2
 
 		reachable := getReachableOpponentUnits(state, PlayerOne, Position{X: 0, Y: 0}, 3)
 
@@ -104,14 +99,12 @@ func TestLineOfSight(t *testing.T) {
 
 	// Test case 3: Diagonal line of sight with blocking unit
 	t.Run("Diagonal blocked line of sight", func(t *testing.T) {
-		state := GameState{
-			counters:        map[UnitID]map[string]int{},
-			Positions:       map[UnitID]Position{1: {X: 0, Y: 0}, 2: {X: 2, Y: 2}, 3: {X: 1, Y: 1}},
-			Board:           map[string]UnitID{"0,0": 1, "2,2": 2, "1,1": 3},
-			Units:           map[UnitID]*PlayerUnit{1: attackerUnit, 2: targetUnit, 3: blockingUnit},
-			CurrentPlayerID: PlayerOne,
-			ActionsLeft:     2,
-		}
+		state := NewGameState(BoardLayout{})
+		state.AddUnit(attackerUnit, Position{X: 0, Y: 0})
+		state.AddUnit(targetUnit, Position{X: 2, Y: 2})
+		state.AddUnit(blockingUnit, Position{X: 1, Y: 1})
+		state.CurrentPlayerID = PlayerOne
+		state.ActionsLeft = 2
 
 		reachable := getReachableOpponentUnits(state, PlayerOne, Position{X: 0, Y: 0}, 3)
 
@@ -138,20 +131,15 @@ func TestLineOfSight(t *testing.T) {
 
 func TestHasLineOfSight(t *testing.T) {
 	// Test clear line of sight
-	clearState := GameState{
-		Board: map[string]UnitID{},
-	}
+	clearState := NewGameState(BoardLayout{})
 
 	if !hasLineOfSight(clearState, Position{X: 0, Y: 0}, Position{X: 2, Y: 2}) {
 		t.Error("Expected clear line of sight from (0,0) to (2,2), but got blocked")
 	}
 
 	// Test blocked line of sight
-	blockedState := GameState{
-		Board: map[string]UnitID{
-			"1,1": 1, // blocking unit at (1,1)
-		},
-	}
+	blockedState := NewGameState(BoardLayout{})
+	blockedState.AddUnit(&PlayerUnit{ID: 1}, Position{X: 1, Y: 1}) // blocking unit at (1,1)
 
 	if hasLineOfSight(blockedState, Position{X: 0, Y: 0}, Position{X: 2, Y: 2}) {
 		t.Error("Expected blocked line of sight from (0,0) to (2,2) with unit at (1,1), but got clear")
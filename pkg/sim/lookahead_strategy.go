@@ -21,7 +21,22 @@ import (
    La profondeur se mesure en actions : depth 4 = mon tour complet + la
    réponse adverse complète. Un budget de nœuds borne le temps de réponse
    (important en WASM) : l'approfondissement itératif rend le meilleur coup
-   de la dernière profondeur entièrement explorée.
+   de la dernière profondeur entièrement explorée. Une table de transposition
+   (cf. transpositionTable) évite de réexplorer une position atteinte par
+   deux ordres de coups, et place en tête le meilleur coup de l'itération
+   précédente.
+
+   Quand les attaques se tirent aux dés (cf. CombatRules), SearchStrategy
+   suppose que chaque attaque inflige ses dégâts moyens. ExpectimaxStrategy
+   raisonne sur les probabilités : une attaque devient un nœud de hasard dont
+   chaque issue est explorée puis pondérée par sa probabilité. Une attaque à
+   une chance sur deux de tuer n'y vaut plus une attaque qui tue à coup sûr.
+
+   À plus de deux joueurs, la recherche est paranoïaque : l'équipe du joueur
+   maximise, tous les autres joueurs sont supposés coalisés contre elle et
+   minimisent. L'hypothèse est pessimiste — en mêlée générale, les autres se
+   battent aussi entre eux — mais garde l'élagage alpha-beta, que max^n
+   perdrait, et la profondeur qui va avec.
    ========================================================================== */
 
 const (
@@ -40,16 +55,28 @@ func LookaheadStrategy(depth int) StrategyFunc {
 // SearchStrategy renvoie une stratégie par approfondissement itératif
 // (2, 4, … maxDepth actions) bornée par un budget de nœuds.
 func SearchStrategy(maxDepth int, nodeBudget int) StrategyFunc {
+	return newSearchStrategy(maxDepth, nodeBudget, false)
+}
+
+// ExpectimaxStrategy : SearchStrategy dont la recherche explore chaque issue
+// des attaques tirées aux dés. Sans dés, elle joue exactement comme
+// SearchStrategy ; avec, elle consomme davantage de nœuds par profondeur.
+func ExpectimaxStrategy(maxDepth int, nodeBudget int) StrategyFunc {
+	return newSearchStrategy(maxDepth, nodeBudget, true)
+}
+
+func newSearchStrategy(maxDepth int, nodeBudget int, chance bool) StrategyFunc {
 	return func(state GameState, playerID PlayerID) Action {
 		// Le moteur décrémente ActionsLeft AVANT d'appeler la stratégie :
 		// l'action que nous choisissons n'est pas comptée. La recherche
 		// raisonne en « actions restantes, celle-ci comprise ».
 		remaining := state.ActionsLeft + 1
 
-		search := &searcher{budget: nodeBudget}
+		search := &searcher{budget: nodeBudget, table: newTranspositionTable(nodeBudget), chance: chance}
 
 		var best Action
 		for depth := 2; depth <= maxDepth; depth += 2 {
+			search.table.iteration++
 			action, _, complete := search.alphabeta(
 				state, playerID, remaining, depth,
 				-math.MaxFloat64, math.MaxFloat64,
@@ -69,6 +96,11 @@ func SearchStrategy(maxDepth int, nodeBudget int) StrategyFunc {
 type searcher struct {
 	budget int
 	nodes  int
+	// table : nil = recherche sans table de transposition.
+	table *transpositionTable
+	// chance : les attaques tirées aux dés sont des nœuds de hasard (cf.
+	// ExpectimaxStrategy).
+	chance bool
 }
 
 // alphabeta explore l'arbre d'actions. `remaining` est le nombre d'actions
@@ -85,7 +117,7 @@ func (s *searcher) alphabeta(state GameState, maximizer PlayerID, remaining int,
 	// États terminaux — modulés par la profondeur restante pour préférer les
 	// victoires rapides et retarder les défaites.
 	if over, winner := terminalState(state); over {
-		if winner == maximizer {
+		if winner >= 0 && state.Allied(winner, maximizer) {
 			return nil, winScore + float64(depth), true
 		}
 		return nil, -winScore - float64(depth), true
@@ -95,10 +127,37 @@ func (s *searcher) alphabeta(state GameState, maximizer PlayerID, remaining int,
 		return nil, evaluateState(state, maximizer), true
 	}
 
+	// Les actions restantes du tour ne se lisent pas dans ActionsLeft (cf.
+	// SearchStrategy) : elles complètent l'empreinte.
+	hash := state.Hash() ^ zobristKey(zobristTurn, -1, 0, remaining)
+	bestMove := -1
+	if s.table != nil {
+		if entry, found := s.table.lookup(hash); found {
+			bestMove = int(entry.move)
+			if int(entry.depth) >= depth {
+				switch entry.bound {
+				case boundExact:
+					return nil, entry.score, true
+				case boundLower:
+					alpha = max(alpha, entry.score)
+				case boundUpper:
+					beta = min(beta, entry.score)
+				}
+				if beta <= alpha {
+					return nil, entry.score, true
+				}
+			}
+		}
+	}
+	windowAlpha, windowBeta := alpha, beta
+
 	currentPlayer := state.CurrentPlayerID
-	isMaximizing := currentPlayer == maximizer
+	isMaximizing := state.Allied(currentPlayer, maximizer)
 
 	actions := s.prunedActions(state, currentPlayer)
+	if bestMove >= len(actions) {
+		bestMove = -1
+	}
 	if len(actions) == 0 {
 		// Aucune action possible : le tour se termine de fait.
 		next := advanceTurn(state.Copy(), currentPlayer)
@@ -107,10 +166,16 @@ func (s *searcher) alphabeta(state GameState, maximizer PlayerID, remaining int,
 	}
 
 	var bestAction Action
+	bestIndex := -1
 	complete := true
 
-	step := func(action Action) (float64, bool) {
-		next := action.Apply(state.Copy())
+	// follow joue l'action, dés imposés s'il y a lieu, et explore la suite
+	// dans la fenêtre donnée.
+	follow := func(action Action, dice combatDice, alpha, beta float64) (float64, bool) {
+		next := state.Copy()
+		next.dice = dice
+		next = playAction(next, currentPlayer, action)
+		next.dice = nil
 		nextRemaining := remaining - 1
 		if nextRemaining <= 0 {
 			// Fin du tour du joueur courant : scoring de la zone puis main à
@@ -125,16 +190,37 @@ func (s *searcher) alphabeta(state GameState, maximizer PlayerID, remaining int,
 		return score, ok
 	}
 
+	step := func(action Action) (float64, bool) {
+		outcomes := []attackOutcome(nil)
+		if s.chance {
+			outcomes = attackOutcomes(state, action)
+		}
+		if len(outcomes) <= 1 {
+			return follow(action, nil, alpha, beta)
+		}
+
+		// Nœud de hasard : les bornes alpha-beta ne valent que pour
+		// l'espérance, chaque issue est donc explorée en fenêtre pleine.
+		expected, complete := 0.0, true
+		for _, outcome := range outcomes {
+			score, ok := follow(action, &forcedDice{damage: outcome.damage}, -math.MaxFloat64, math.MaxFloat64)
+			expected += score * outcome.probability
+			complete = complete && ok
+		}
+		return expected, complete
+	}
+
 	if isMaximizing {
 		bestScore := -math.MaxFloat64
-		for _, action := range actions {
-			score, ok := step(action)
+		for k := range actions {
+			i := searchOrder(k, bestMove)
+			score, ok := step(actions[i])
 			if !ok {
 				complete = false
 			}
 			if score > bestScore {
 				bestScore = score
-				bestAction = action
+				bestAction, bestIndex = actions[i], i
 			}
 			if score > alpha {
 				alpha = score
@@ -143,18 +229,20 @@ func (s *searcher) alphabeta(state GameState, maximizer PlayerID, remaining int,
 				break
 			}
 		}
+		s.remember(hash, depth, bestScore, windowAlpha, windowBeta, bestIndex, complete)
 		return bestAction, bestScore, complete
 	}
 
 	bestScore := math.MaxFloat64
-	for _, action := range actions {
-		score, ok := step(action)
+	for k := range actions {
+		i := searchOrder(k, bestMove)
+		score, ok := step(actions[i])
 		if !ok {
 			complete = false
 		}
 		if score < bestScore {
 			bestScore = score
-			bestAction = action
+			bestAction, bestIndex = actions[i], i
 		}
 		if score < beta {
 			beta = score
@@ -163,27 +251,47 @@ func (s *searcher) alphabeta(state GameState, maximizer PlayerID, remaining int,
 			break
 		}
 	}
+	s.remember(hash, depth, bestScore, windowAlpha, windowBeta, bestIndex, complete)
 	return bestAction, bestScore, complete
 }
 
-// advanceTurn ferme le tour de playerID et ouvre celui de son adversaire, en
+// remember consigne le résultat d'une exploration complète dans la table de
+// transposition. Un score obtenu à budget épuisé n'est qu'une estimation :
+// il n'est pas conservé.
+func (s *searcher) remember(hash uint64, depth int, score, alpha, beta float64, move int, complete bool) {
+	if s.table == nil || !complete {
+		return
+	}
+	s.table.store(hash, depth, score, bound(score, alpha, beta), move)
+}
+
+// searchOrder renvoie le rang du k-ième coup à explorer : le meilleur coup
+// connu d'abord, puis les autres dans l'ordre de prunedActions.
+func searchOrder(k int, first int) int {
+	switch {
+	case first < 0 || k > first:
+		return k
+	case k == 0:
+		return first
+	default:
+		return k - 1
+	}
+}
+
+// advanceTurn ferme le tour de playerID et ouvre celui du joueur suivant, en
 // réutilisant les transitions du moteur (statuts, points de contrôle).
 func advanceTurn(state GameState, playerID PlayerID) GameState {
 	state = endTurn(state, playerID)
-	return beginTurn(state, getOpponentPlayerID(playerID))
+	return beginTurn(state, nextPlayer(state, playerID))
 }
 
-// terminalState : élimination totale ou victoire par capture.
+// terminalState : élimination totale ou victoire acquise selon la condition
+// de victoire en vigueur (capture par défaut).
 func terminalState(state GameState) (bool, PlayerID) {
 	if over, winner := isGameOver(state); over {
 		return true, winner
 	}
-	for _, playerID := range []PlayerID{PlayerOne, PlayerTwo} {
-		if state.ControlPoints[playerID] >= state.pointsToWin() {
-			return true, playerID
-		}
-	}
-	return false, -1
+	return state.victory().Winner(state)
 }
 
 // prunedActions rassemble les actions du joueur en limitant les déplacements
@@ -193,7 +301,7 @@ func (s *searcher) prunedActions(state GameState, playerID PlayerID) []Action {
 	direct := make([]Action, 0)
 	moves := make([]Action, 0)
 
-	for _, unit := range getControllableUnits(state, playerID) {
+	for _, unit := range activeUnits(state, playerID) {
 		unitMoves := make([]Action, 0)
 		for _, action := range getValidActions(state, unit) {
 			if action.Type() == ActionMove {
@@ -224,13 +332,16 @@ func (s *searcher) prunedActions(state GameState, playerID PlayerID) []Action {
 // de mêlée cherchent le contact).
 func destinationScore(state GameState, unit *PlayerUnit, pos Position) float64 {
 	score := 0.0
+	board := state.board()
 
-	if InObjectiveZone(pos) {
+	if board.InObjectiveZone(pos) {
 		score += 3.0
 	} else {
-		score -= objectiveDistance(pos) * 0.4
+		score -= board.objectiveDistance(pos) * 0.4
 	}
 
+	score += terrainScore(state, unit, pos) * 2
+
 	nearest := nearestEnemyDistanceFrom(state, unit, pos)
 	if nearest < math.MaxFloat64 {
 		if unit.Stats.Range > 1 {
@@ -244,23 +355,14 @@ func destinationScore(state GameState, unit *PlayerUnit, pos Position) float64 {
 	return score
 }
 
-func objectiveDistance(pos Position) float64 {
-	best := math.MaxFloat64
-	for _, zone := range ObjectiveZone {
-		if d := distance(pos, zone); d < best {
-			best = d
-		}
-	}
-	return best
-}
-
 func nearestEnemyDistanceFrom(state GameState, unit *PlayerUnit, from Position) float64 {
 	best := math.MaxFloat64
-	for _, other := range state.Units {
-		if other.OwnerID == unit.OwnerID {
+	teams, team := &state.Teams, state.Team(unit.OwnerID)
+	for other := range state.Units() {
+		if teams.of(other.OwnerID) == team {
 			continue
 		}
-		if d := distance(from, state.Positions[other.ID]); d < best {
+		if d := distance(from, state.PositionOf(other.ID)); d < best {
 			best = d
 		}
 	}
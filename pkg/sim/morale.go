@@ -0,0 +1,163 @@
+package sim
+
+import (
+	"github.com/pkg/errors"
+)
+
+/* =============================================================================
+   Moral.
+
+   Règle optionnelle : une escouade se bat d'ordinaire jusqu'à la dernière
+   unité. Avec MoraleRules, une escouade qui a perdu une part BreakPoint de
+   son coût de départ est ébranlée : au début de chaque tour de son joueur,
+   chacune de ses unités passe un test de moral — un d6 supérieur ou égal à
+   PassOn. Un échec coûte d'autant plus cher qu'il est large :
+     - manqué d'un point : l'unité est en déroute (CounterRouted), elle
+       n'agit pas de ce tour ;
+     - de deux points : elle recule de tout son Déplacement vers son bord
+       de plateau, puis reste en déroute ;
+     - de plus : elle fuit le plateau. Elle compte dans les pertes de son
+       joueur comme une unité éliminée (cf. Kill), sans l'avoir été.
+
+   Les tests se tirent aux dés de la partie (cf. seededDice), comme le
+   combat. La recherche de l'IA joue des copies sans dés et ne les tire
+   pas : evaluateState décote à la place les unités d'une escouade ébranlée
+   du risque qu'elles courent (cf. moraleRisk).
+   ========================================================================== */
+
+// CounterRouted : unité en déroute, qui n'agit pas de son tour.
+const CounterRouted string = "routed"
+
+// MoraleRules : règle du moral. La valeur zéro reproduit la règle publiée,
+// sans moral.
+type MoraleRules struct {
+	// BreakPoint : part du coût de départ de l'escouade, entre 0 et 1, dont
+	// la perte l'ébranle. 0 = pas de moral.
+	BreakPoint float64
+	// PassOn : résultat minimal d'un d6 pour réussir le test, de 2 à 6. 0 =
+	// 4, une chance sur deux.
+	PassOn int
+}
+
+// Enabled indique si la règle du moral est en vigueur.
+func (r MoraleRules) Enabled() bool {
+	return r.BreakPoint > 0
+}
+
+func (r MoraleRules) Validate() error {
+	if r.BreakPoint < 0 || r.BreakPoint > 1 {
+		return errors.Errorf("invalid morale break point %v, expected a share between 0 and 1", r.BreakPoint)
+	}
+	if r.PassOn != 0 && (r.PassOn < 2 || r.PassOn > 6) {
+		return errors.Errorf("invalid morale roll %d, expected a d6 result from 2 to 6 or 0", r.PassOn)
+	}
+	return nil
+}
+
+// Roll renvoie le résultat minimal d'un d6 pour réussir le test.
+func (r MoraleRules) Roll() int {
+	if r.PassOn == 0 {
+		return 4
+	}
+	return r.PassOn
+}
+
+type MoraleOutcome string
+
+const (
+	MoralePassed    MoraleOutcome = "passed"
+	MoraleRouted    MoraleOutcome = "routed"
+	MoraleRetreated MoraleOutcome = "retreated"
+	MoraleFled      MoraleOutcome = "fled"
+)
+
+// Broken indique si l'escouade du joueur est ébranlée (cf. MoraleRules).
+func (s GameState) Broken(playerID PlayerID) bool {
+	if !s.Morale.Enabled() || playerID < 0 || playerID >= MaxPlayers {
+		return false
+	}
+	start := s.StartingCost[playerID]
+	return start > 0 && float64(s.Losses[playerID]) >= s.Morale.BreakPoint*float64(start)
+}
+
+// moraleRisk renvoie, pour l'IA, la part de sa valeur qu'une unité du
+// joueur risque de perdre à son prochain test de moral, 0 si son escouade
+// tient : toute sa valeur si elle fuit, un quart si elle perd son tour en
+// déroute ou en reculant.
+func (s GameState) moraleRisk(playerID PlayerID) float64 {
+	if !s.Broken(playerID) {
+		return 0
+	}
+	passOn := s.Morale.Roll()
+	fled := float64(max(passOn-3, 0)) / 6
+	shaken := float64(min(passOn-1, 2)) / 6
+	return fled + shaken*0.25
+}
+
+// checkMorale fait passer leur test de moral aux unités du joueur si son
+// escouade est ébranlée. Sans dés — sur les copies de la recherche — rien
+// n'est tiré. Mute l'état reçu.
+func checkMorale(state GameState, playerID PlayerID) GameState {
+	if state.dice == nil || !state.Broken(playerID) {
+		return state
+	}
+
+	passOn := state.Morale.Roll()
+
+	for _, unit := range getControllableUnits(state, playerID) {
+		roll := state.dice.d6()
+
+		outcome := MoralePassed
+		switch margin := passOn - roll; {
+		case margin == 1:
+			outcome = MoraleRouted
+		case margin == 2:
+			outcome = MoraleRetreated
+		case margin > 2:
+			outcome = MoraleFled
+		}
+
+		state.emit(MoraleChecked{Unit: unit.ID, Roll: roll, Outcome: outcome})
+
+		switch outcome {
+		case MoraleRetreated:
+			retreat(state, unit)
+			state.Set(unit.ID, CounterRouted, 1)
+		case MoraleRouted:
+			state.Set(unit.ID, CounterRouted, 1)
+		case MoraleFled:
+			state = state.remove(unit.ID)
+		}
+	}
+
+	return state
+}
+
+// retreat recule l'unité de tout son Déplacement vers le bord de plateau de
+// son joueur : sur la case atteignable la plus proche de sa rangée du fond.
+func retreat(state GameState, unit *PlayerUnit) {
+	board := state.board()
+	back := board.DeploymentRows(unit.OwnerID)[0]
+
+	from := state.PositionOf(unit.ID)
+	best, bestDistance := from, abs(board.rowOf(unit.OwnerID, from)-back)
+	for _, pos := range getReachablePositions(state, from, unit.Stats.Move) {
+		if d := abs(board.rowOf(unit.OwnerID, pos) - back); d < bestDistance {
+			best, bestDistance = pos, d
+		}
+	}
+
+	if best != from {
+		state.MoveUnit(unit.ID, best)
+	}
+}
+
+func init() {
+	registerStatus(Status{
+		Name:   CounterRouted,
+		Expiry: ExpiresAtTurnEnd,
+		Allows: func(GameState, UnitID, ActionType) bool {
+			return false
+		},
+	})
+}
@@ -0,0 +1,221 @@
+package sim
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+)
+
+// fixedDice tire des tests de moral imposés, dans l'ordre.
+type fixedDice struct {
+	rolls []int
+}
+
+func (d *fixedDice) d6() int {
+	roll := d.rolls[0]
+	d.rolls = d.rolls[1:]
+	return roll
+}
+
+func (d *fixedDice) roll(rules CombatRules, damage int) (int, bool) {
+	return damage, true
+}
+
+// newMoraleTestState : le joueur 1 a perdu la moitié de son escouade, le
+// joueur 2 rien.
+func newMoraleTestState() GameState {
+	state := NewGameState(BoardLayout{})
+	state.Morale = MoraleRules{BreakPoint: 0.5}
+	state.AddUnit(&PlayerUnit{ID: 0, OwnerID: PlayerOne, Unit: Unit{Stats: core.Stats{Health: 2, Range: 1, Move: 2, Power: 1}}}, Position{X: 2, Y: 4})
+	state.AddUnit(&PlayerUnit{ID: 1, OwnerID: PlayerOne, Unit: Unit{Stats: core.Stats{Health: 2, Range: 1, Move: 2, Power: 1}}}, Position{X: 5, Y: 4})
+	state.AddUnit(&PlayerUnit{ID: 2, OwnerID: PlayerTwo, Unit: Unit{Stats: core.Stats{Health: 2, Range: 1, Move: 2, Power: 1}}}, Position{X: 3, Y: 6})
+	for id := range UnitID(3) {
+		state.Set(id, CounterHealth, 2)
+	}
+	cost := state.unitCost(state.Unit(0))
+	state.StartingCost[PlayerOne] = 4 * cost
+	state.StartingCost[PlayerTwo] = cost
+	state.Losses[PlayerOne] = 2 * cost
+	return state
+}
+
+func TestBroken(t *testing.T) {
+	state := newMoraleTestState()
+
+	if !state.Broken(PlayerOne) {
+		t.Error("expected player one to be broken")
+	}
+	if state.Broken(PlayerTwo) {
+		t.Error("expected player two to hold")
+	}
+
+	state.Losses[PlayerOne]--
+	if state.Broken(PlayerOne) {
+		t.Error("expected player one to hold under the break point")
+	}
+
+	state.Losses[PlayerOne]++
+	state.Morale = MoraleRules{}
+	if state.Broken(PlayerOne) {
+		t.Error("expected no squad to break without morale")
+	}
+}
+
+func TestCheckMorale(t *testing.T) {
+	type testCase struct {
+		Name    string
+		Roll    int
+		Outcome MoraleOutcome
+	}
+
+	testCases := []testCase{
+		{Name: "passed", Roll: 4, Outcome: MoralePassed},
+		{Name: "routed", Roll: 3, Outcome: MoraleRouted},
+		{Name: "retreated", Roll: 2, Outcome: MoraleRetreated},
+		{Name: "fled", Roll: 1, Outcome: MoraleFled},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			state := newMoraleTestState()
+			sink := &eventSink{}
+			state.sink = sink
+			state.dice = &fixedDice{rolls: []int{tc.Roll, 6}}
+
+			back := state.board().DeploymentRows(PlayerOne)[0]
+			from := state.PositionOf(0)
+			losses := state.Losses[PlayerOne]
+
+			state = checkMorale(state, PlayerOne)
+
+			events := sink.flush()
+			checked := make([]MoraleChecked, 0)
+			for _, event := range events {
+				switch e := event.(type) {
+				case MoraleChecked:
+					checked = append(checked, e)
+				case UnitKilled:
+					t.Errorf("unexpected %+v", e)
+				}
+			}
+			expected := []MoraleChecked{
+				{Unit: 0, Roll: tc.Roll, Outcome: tc.Outcome},
+				{Unit: 1, Roll: 6, Outcome: MoralePassed},
+			}
+			if !reflect.DeepEqual(expected, checked) {
+				t.Fatalf("checks: expected %+v, got %+v", expected, checked)
+			}
+
+			routed := tc.Outcome == MoraleRouted || tc.Outcome == MoraleRetreated
+			if e, g := routed, state.Get(0, CounterRouted, 0) > 0; e != g {
+				t.Errorf("routed: expected %v, got %v", e, g)
+			}
+			if routed && (allowsAction(state, 0, ActionMove) || allowsAction(state, 0, ActionAttack)) {
+				t.Error("expected a routed unit to be unable to act")
+			}
+			if state.Get(1, CounterRouted, 0) > 0 {
+				t.Error("expected the unit that passed to act")
+			}
+
+			distance := func(pos Position) int {
+				return abs(state.board().rowOf(PlayerOne, pos) - back)
+			}
+			switch tc.Outcome {
+			case MoraleRetreated:
+				if e, g := distance(from)-2, distance(state.PositionOf(0)); e != g {
+					t.Errorf("distance to the back row: expected %d, got %d", e, g)
+				}
+			case MoraleFled:
+				if state.Unit(0) != nil {
+					t.Fatal("expected the unit to have fled")
+				}
+				if state.Losses[PlayerOne] <= losses {
+					t.Error("expected the unit that fled to count in losses")
+				}
+			default:
+				if e, g := from, state.PositionOf(0); e != g {
+					t.Errorf("position: expected %v, got %v", e, g)
+				}
+			}
+		})
+	}
+
+	t.Run("squad holding", func(t *testing.T) {
+		state := newMoraleTestState()
+		// Sans jet prévu : un test tiré ferait paniquer fixedDice.
+		state.dice = &fixedDice{}
+		checkMorale(state, PlayerTwo)
+	})
+
+	t.Run("no dice", func(t *testing.T) {
+		state := newMoraleTestState()
+		sink := &eventSink{}
+		state.sink = sink
+		state = checkMorale(state, PlayerOne)
+		if g := sink.flush(); len(g) != 0 {
+			t.Errorf("expected no check on a search copy, got %+v", g)
+		}
+	})
+}
+
+func TestEvaluateStateMorale(t *testing.T) {
+	state := newMoraleTestState()
+	broken := evaluateState(state, PlayerTwo)
+
+	state.Morale = MoraleRules{}
+	steady := evaluateState(state, PlayerTwo)
+
+	if broken <= steady {
+		t.Errorf("expected a broken enemy squad to be worth less: %f with morale, %f without", broken, steady)
+	}
+}
+
+func TestMoraleReplay(t *testing.T) {
+	checks := 0
+	for seed := range int64(4) {
+		game := NewGame(recordTestSquad(), recordTestSquad(),
+			WithSeed(seed),
+			WithMoraleRules(MoraleRules{BreakPoint: 0.25, PassOn: 5}),
+			WithPlayerStrategy(PlayerOne, SearchStrategy(2, 300)),
+			WithPlayerStrategy(PlayerTwo, SearchStrategy(2, 300)),
+			WithMaxTurns(20),
+		)
+
+		for step := range game.Run() {
+			for _, event := range step.Events {
+				if _, ok := event.(MoraleChecked); ok {
+					checks++
+				}
+			}
+		}
+
+		var buff bytes.Buffer
+		if err := game.Record().Write(&buff); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		record, err := ReadGameRecord(&buff)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if record.Setup.Morale == nil {
+			t.Fatalf("seed %d: expected the morale rules to be recorded", seed)
+		}
+
+		replayed, err := Replay(record)
+		if err != nil {
+			t.Fatalf("seed %d: %+v", seed, err)
+		}
+		if e, g := printState(game.State()), printState(replayed.State()); e != g {
+			t.Errorf("seed %d: final board: expected\n%s\ngot\n%s", seed, e, g)
+		}
+		if !reflect.DeepEqual(game.Record(), replayed.Record()) {
+			t.Errorf("seed %d: replayed game record differs from the original one", seed)
+		}
+	}
+
+	if checks == 0 {
+		t.Error("expected at least one morale check")
+	}
+}
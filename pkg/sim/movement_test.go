@@ -37,14 +37,10 @@ func TestMovementWithObstacles(t *testing.T) {
 
 	// Test case 1: Movement without obstacles
 	t.Run("Movement without obstacles", func(t *testing.T) {
-		state := GameState{
-			counters:        map[UnitID]map[string]int{},
-			Positions:       map[UnitID]Position{1: {X: 4, Y: 4}},
-			Board:           map[string]UnitID{"4,4": 1},
-			Units:           map[UnitID]*PlayerUnit{1: unit},
-			CurrentPlayerID: PlayerOne,
-			ActionsLeft:     2,
-		}
+		state := NewGameState(BoardLayout{})
+		state.AddUnit(unit, Position{X: 4, Y: 4})
+		state.CurrentPlayerID = PlayerOne
+		state.ActionsLeft = 2
 
 		moves := getPossibleMoves(state, unit)
 
@@ -69,14 +65,11 @@ func TestMovementWithObstacles(t *testing.T) {
 
 	// Test case 2: Movement blocked by obstacle
 	t.Run("Movement blocked by obstacle", func(t *testing.T) {
-		state := GameState{
-			counters:        map[UnitID]map[string]int{},
-			Positions:       map[UnitID]Position{1: {X: 2, Y: 2}, 2: {X: 3, Y: 2}},
-			Board:           map[string]UnitID{"2,2": 1, "3,2": 2},
-			Units:           map[UnitID]*PlayerUnit{1: unit, 2: obstacle},
-			CurrentPlayerID: PlayerOne,
-			ActionsLeft:     2,
-		}
+		state := NewGameState(BoardLayout{})
+		state.AddUnit(unit, Position{X: 2, Y: 2})
+		state.AddUnit(obstacle, Position{X: 3, Y: 2})
+		state.CurrentPlayerID = PlayerOne
+		state.ActionsLeft = 2
 
 		moves := getPossibleMoves(state, unit)
 
@@ -109,14 +102,11 @@ func TestMovementWithObstacles(t *testing.T) {
 
 	// Test case 3: Diagonal movement around obstacle
 	t.Run("Diagonal movement around obstacle", func(t *testing.T) {
-		state := GameState{
-			counters:        map[UnitID]map[string]int{},
-			Positions:       map[UnitID]Position{1: {X: 1, Y: 1}, 2: {X: 2, Y: 1}},
-			Board:           map[string]UnitID{"1,1": 1, "2,1": 2},
-			Units:           map[UnitID]*PlayerUnit{1: unit, 2: obstacle},
-			CurrentPlayerID: PlayerOne,
-			ActionsLeft:     2,
-		}
+		state := NewGameState(BoardLayout{})
+		state.AddUnit(unit, Position{X: 1, Y: 1})
+		state.AddUnit(obstacle, Position{X: 2, Y: 1})
+		state.CurrentPlayerID = PlayerOne
+		state.ActionsLeft = 2
 
 		moves := getPossibleMoves(state, unit)
 
@@ -137,11 +127,8 @@ func TestMovementWithObstacles(t *testing.T) {
 
 func TestGetRangeablePositions(t *testing.T) {
 	// Test the pathfinding function directly
-	state := GameState{
-		Board: map[string]UnitID{
-			"2,1": 1, // obstacle
-		},
-	}
+	state := NewGameState(BoardLayout{})
+	state.AddUnit(&PlayerUnit{ID: 1}, Position{X: 2, Y: 1}) // obstacle
 
 	startPos := Position{X: 1, Y: 1}
 	reachable := getReachablePositions(state, startPos, 2)
@@ -172,11 +159,8 @@ func TestGetRangeablePositions(t *testing.T) {
 }
 
 func TestCanMoveTo(t *testing.T) {
-	state := GameState{
-		Board: map[string]UnitID{
-			"2,2": 1, // obstacle
-		},
-	}
+	state := NewGameState(BoardLayout{})
+	state.AddUnit(&PlayerUnit{ID: 1}, Position{X: 2, Y: 2}) // obstacle
 
 	// Test clear path
 	if !canMoveTo(state, Position{X: 1, Y: 1}, Position{X: 3, Y: 1}) {
@@ -0,0 +1,295 @@
+package sim
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+/* =============================================================================
+   Notation textuelle des actions.
+
+   Une action s'écrit sur une ligne, unités désignées par leur identifiant et
+   cases par « x,y » :
+
+     move <unité> -> <x>,<y>          déplacement
+     attack <unité> -> <cible>        attaque
+     ability:<id> <unité>             capacité sans cible (Balayage…)
+     ability:<id> <unité> -> <cible>  capacité ciblant une unité
+     ability:<id> <unité> @ <x>,<y>   capacité ciblant une case
+     ability:<id> <unité> -> <cible> @ <x>,<y>
+                                      unité ET case (Charge : la case est la
+                                      destination, la cible l'unité frappée)
+     ability:<id> <unité> -> <cible> @ <x>,<y> ; -> <cible> @ <x>,<y>
+                                      capacité à cibles multiples, choix
+                                      dans l'ordre séparés par « ; » (Ordre
+                                      d'Avancer : chaque allié et sa
+                                      destination)
+     pass                             aucune action
+
+   Exemples : « move 3 -> 2,4 », « attack 0 -> 5 »,
+   « ability:00007-feint 2 -> 1 », « ability:00000-charge 0 -> 4 @ 3,2 »,
+   « ability:00005-command-forward 0 -> 1 @ 3,2 ; -> 2 @ 4,2 ».
+
+   Un choix porte au plus une cible et une case, dans un ordre libre ; une
+   seconde cible ou une seconde case dans le même choix est une erreur, pas
+   le début du choix suivant. Les espaces sont libres autour des
+   séparateurs. FormatAction produit la forme canonique ; ParseAction
+   accepte toute écriture équivalente et la résout parmi les actions
+   légales de l'état, si bien qu'une action lue est toujours jouable telle
+   quelle.
+   ========================================================================== */
+
+const (
+	notationPass = "pass"
+	// notationChoices sépare les choix d'une capacité à cibles multiples.
+	notationChoices = ";"
+)
+
+var coordinateSpaces = regexp.MustCompile(`\s*,\s*`)
+
+// FormatAction écrit une action dans la notation canonique.
+func FormatAction(action Action) string {
+	switch a := action.(type) {
+	case nil:
+		return notationPass
+	case *MoveAction:
+		return fmt.Sprintf("%s %d -> %s", ActionMove, a.unitID, a.targetPos)
+	case *AttackAction:
+		return fmt.Sprintf("%s %d -> %d", ActionAttack, a.unitID, a.targetID)
+	case *AbilityAction:
+		return formatAbility(a)
+	default:
+		return action.String()
+	}
+}
+
+func formatAbility(a *AbilityAction) string {
+	var sb strings.Builder
+	sb.WriteString(string(ActionAbility))
+	sb.WriteString(":")
+	sb.WriteString(a.id)
+
+	d := a.description
+	if d == nil {
+		return sb.String()
+	}
+
+	fmt.Fprintf(&sb, " %d", d.SourceUnitID)
+	if d.TargetUnitID >= 0 {
+		fmt.Fprintf(&sb, " -> %d", d.TargetUnitID)
+	}
+	if d.TargetX >= 0 && d.TargetY >= 0 {
+		fmt.Fprintf(&sb, " @ %d,%d", d.TargetX, d.TargetY)
+	}
+	for _, choice := range d.Choices {
+		sb.WriteString(" " + notationChoices)
+		if choice.UnitID >= 0 {
+			fmt.Fprintf(&sb, " -> %d", choice.UnitID)
+		}
+		if choice.X >= 0 && choice.Y >= 0 {
+			fmt.Fprintf(&sb, " @ %d,%d", choice.X, choice.Y)
+		}
+	}
+
+	return sb.String()
+}
+
+// notationChoice : cible et case d'un choix lu dans la notation.
+type notationChoice struct {
+	target *UnitID
+	cell   *Position
+}
+
+// ParseAction lit une action écrite dans la notation et la résout parmi les
+// actions légales du joueur courant (cf. GetValidActionsForPlayer). « pass »
+// rend une action nil.
+func ParseAction(state GameState, text string) (Action, error) {
+	canonical, unitID, err := normalizeNotation(text)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not parse action '%s'", text)
+	}
+
+	if canonical == notationPass {
+		return nil, nil
+	}
+
+	unit := state.Unit(unitID)
+	if unit == nil {
+		return nil, errors.Errorf("could not resolve action '%s': no unit %d", text, unitID)
+	}
+	if unit.OwnerID != state.CurrentPlayerID {
+		return nil, errors.Errorf("could not resolve action '%s': unit %d does not belong to player %d", text, unitID, state.CurrentPlayerID)
+	}
+
+	for _, action := range GetValidActionsForPlayer(state, state.CurrentPlayerID) {
+		if FormatAction(action) == canonical {
+			return action, nil
+		}
+	}
+
+	return nil, errors.Errorf("could not resolve action '%s': not a legal action", text)
+}
+
+// normalizeNotation vérifie la syntaxe d'une action et en rend la forme
+// canonique, avec l'identifiant de l'unité qui agit.
+func normalizeNotation(text string) (string, UnitID, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return "", -1, errors.New("empty action")
+	}
+
+	if len(fields) == 1 && fields[0] == notationPass {
+		return notationPass, -1, nil
+	}
+
+	kind := fields[0]
+
+	// Les séparateurs peuvent être collés aux opérandes (« 3->2,4 ») : on
+	// les isole avant de découper.
+	rest := strings.Join(fields[1:], " ")
+	rest = coordinateSpaces.ReplaceAllString(rest, ",")
+	rest = strings.ReplaceAll(rest, "->", " -> ")
+	rest = strings.ReplaceAll(rest, "@", " @ ")
+	segments := strings.Split(rest, notationChoices)
+
+	operands := strings.Fields(segments[0])
+	if len(operands) == 0 {
+		return "", -1, errors.New("missing unit")
+	}
+
+	unitID, err := parseUnitID(operands[0])
+	if err != nil {
+		return "", -1, errors.WithStack(err)
+	}
+
+	if len(segments) > 1 && !strings.HasPrefix(kind, string(ActionAbility)+":") {
+		return "", -1, errors.Errorf("unexpected '%s'", notationChoices)
+	}
+
+	choices := make([]notationChoice, 0, len(segments))
+	for i, segment := range segments {
+		operands := strings.Fields(segment)
+		if i == 0 {
+			operands = operands[1:]
+		} else if len(operands) == 0 {
+			return "", -1, errors.Errorf("empty choice #%d", i)
+		}
+
+		choice, err := parseNotationChoice(kind, operands)
+		if err != nil {
+			return "", -1, errors.WithStack(err)
+		}
+		choices = append(choices, choice)
+	}
+
+	target, cell := choices[0].target, choices[0].cell
+
+	switch {
+	case kind == string(ActionMove):
+		if cell == nil {
+			return "", -1, errors.New("move requires a destination")
+		}
+		return fmt.Sprintf("%s %d -> %s", ActionMove, unitID, *cell), unitID, nil
+
+	case kind == string(ActionAttack):
+		if target == nil || cell != nil {
+			return "", -1, errors.New("attack requires a target unit")
+		}
+		return fmt.Sprintf("%s %d -> %d", ActionAttack, unitID, *target), unitID, nil
+
+	case strings.HasPrefix(kind, string(ActionAbility)+":"):
+		if strings.TrimPrefix(kind, string(ActionAbility)+":") == "" {
+			return "", -1, errors.New("missing ability id")
+		}
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "%s %d", kind, unitID)
+		for i, choice := range choices {
+			if i > 0 {
+				sb.WriteString(" " + notationChoices)
+			}
+			if choice.target != nil {
+				fmt.Fprintf(&sb, " -> %d", *choice.target)
+			}
+			if choice.cell != nil {
+				fmt.Fprintf(&sb, " @ %s", *choice.cell)
+			}
+		}
+		return sb.String(), unitID, nil
+
+	default:
+		return "", -1, errors.Errorf("unknown action type '%s'", kind)
+	}
+}
+
+// parseNotationChoice lit la cible et la case d'un choix. Pour un
+// déplacement, la flèche désigne une case.
+func parseNotationChoice(kind string, operands []string) (notationChoice, error) {
+	var choice notationChoice
+
+	for len(operands) > 0 {
+		if len(operands) < 2 {
+			return choice, errors.Errorf("dangling '%s'", operands[0])
+		}
+
+		switch {
+		case operands[0] == "->" && kind == string(ActionMove):
+			if choice.cell != nil {
+				return choice, errors.New("unexpected '->'")
+			}
+			pos, err := parsePosition(operands[1])
+			if err != nil {
+				return choice, errors.WithStack(err)
+			}
+			choice.cell = &pos
+		case operands[0] == "->":
+			if choice.target != nil {
+				return choice, errors.Errorf("second target in one choice, separate choices with '%s'", notationChoices)
+			}
+			id, err := parseUnitID(operands[1])
+			if err != nil {
+				return choice, errors.WithStack(err)
+			}
+			choice.target = &id
+		case operands[0] == "@" && kind != string(ActionMove):
+			if choice.cell != nil {
+				return choice, errors.Errorf("second cell in one choice, separate choices with '%s'", notationChoices)
+			}
+			pos, err := parsePosition(operands[1])
+			if err != nil {
+				return choice, errors.WithStack(err)
+			}
+			choice.cell = &pos
+		default:
+			return choice, errors.Errorf("unexpected '%s'", operands[0])
+		}
+
+		operands = operands[2:]
+	}
+
+	return choice, nil
+}
+
+func parseUnitID(str string) (UnitID, error) {
+	id, err := strconv.Atoi(str)
+	if err != nil || id < 0 {
+		return -1, errors.Errorf("invalid unit id '%s'", str)
+	}
+	return UnitID(id), nil
+}
+
+func parsePosition(str string) (Position, error) {
+	xy := strings.Split(str, ",")
+	if len(xy) != 2 {
+		return Position{}, errors.Errorf("invalid position '%s'", str)
+	}
+	x, errX := strconv.Atoi(xy[0])
+	y, errY := strconv.Atoi(xy[1])
+	if errX != nil || errY != nil {
+		return Position{}, errors.Errorf("invalid position '%s'", str)
+	}
+	return Position{X: x, Y: y}, nil
+}
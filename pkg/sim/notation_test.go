@@ -0,0 +1,159 @@
+package sim
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+)
+
+func TestFormatParseActionRoundTrip(t *testing.T) {
+	abilities := core.Abilities(
+		"00000-charge", "00002-defensive-stance", "00004-tactical-retreat",
+		"00005-command-forward", "00007-feint", "00008-guardian", "00009-sweep",
+	)
+
+	game := NewGame(
+		[]Unit{
+			{Stats: core.Stats{Health: 3, Range: 2, Move: 2, Power: 2}, Abilities: abilities},
+			{Stats: core.Stats{Health: 2, Range: 1, Move: 2, Power: 1}},
+		},
+		[]Unit{
+			{Stats: core.Stats{Health: 3, Range: 1, Move: 2, Power: 2}},
+		},
+		WithSeed(1),
+		WithFirstPlayer(PlayerOne),
+		WithDeployment(map[PlayerID][]Position{
+			PlayerOne: {{X: 3, Y: 2}, {X: 4, Y: 2}},
+			PlayerTwo: {{X: 3, Y: 3}},
+		}),
+		WithObstacles(Position{X: 0, Y: 3}),
+	)
+
+	state := beginTurn(game.State(), PlayerOne)
+
+	actions := GetValidActionsForPlayer(state, PlayerOne)
+	if len(actions) == 0 {
+		t.Fatal("expected some valid actions")
+	}
+
+	seen := map[ActionType]bool{}
+	for _, action := range actions {
+		text := FormatAction(action)
+
+		parsed, err := ParseAction(state, text)
+		if err != nil {
+			t.Fatalf("ParseAction(%q): %+v", text, err)
+		}
+
+		if e, g := text, FormatAction(parsed); e != g {
+			t.Errorf("round trip: expected %q, got %q", e, g)
+		}
+
+		seen[action.Type()] = true
+	}
+
+	for _, actionType := range []ActionType{ActionMove, ActionAttack, ActionAbility} {
+		if !seen[actionType] {
+			t.Errorf("expected at least one %s action", actionType)
+		}
+	}
+}
+
+func TestParseAction(t *testing.T) {
+	unit := &PlayerUnit{ID: 1, OwnerID: PlayerOne, Unit: Unit{
+		Stats:     core.Stats{Health: 3, Range: 1, Move: 2, Power: 2},
+		Abilities: core.Abilities("00000-charge"),
+	}}
+	enemy := &PlayerUnit{ID: 2, OwnerID: PlayerTwo, Unit: Unit{
+		Stats: core.Stats{Health: 2, Range: 1, Move: 1, Power: 1},
+	}}
+
+	state := NewGameState(BoardLayout{})
+	state.AddUnit(unit, Position{X: 0, Y: 0})
+	state.AddUnit(enemy, Position{X: 3, Y: 0})
+	state.CurrentPlayerID = PlayerOne
+	state.ActionsLeft = 2
+	state.Set(1, CounterHealth, 3)
+	state.Set(2, CounterHealth, 2)
+
+	testCases := []struct {
+		Text      string
+		Canonical string
+		Err       bool
+	}{
+		{Text: "move 1 -> 1,1", Canonical: "move 1 -> 1,1"},
+		{Text: "move 1->2, 0", Canonical: "move 1 -> 2,0"},
+		{Text: "ability:00000-charge 1 -> 2 @ 2,0", Canonical: "ability:00000-charge 1 -> 2 @ 2,0"},
+		{Text: "ability:00000-charge 1 @2,1 ->2", Canonical: "ability:00000-charge 1 -> 2 @ 2,1"},
+		{Text: "pass", Canonical: "pass"},
+		// Hors de portée de mouvement
+		{Text: "move 1 -> 5,5", Err: true},
+		// Hors de portée d'attaque
+		{Text: "attack 1 -> 2", Err: true},
+		// Unité adverse
+		{Text: "move 2 -> 4,0", Err: true},
+		{Text: "jump 1 -> 1,1", Err: true},
+		{Text: "move 1 -> 1", Err: true},
+		{Text: "", Err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Text, func(t *testing.T) {
+			action, err := ParseAction(state, tc.Text)
+			if tc.Err {
+				if err == nil {
+					t.Fatalf("expected an error, got action %q", FormatAction(action))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if e, g := tc.Canonical, FormatAction(action); e != g {
+				t.Errorf("expected %q, got %q", e, g)
+			}
+		})
+	}
+}
+
+func TestParseMalformedAction(t *testing.T) {
+	state := NewGameState(BoardLayout{})
+	state.AddUnit(&PlayerUnit{ID: 1, OwnerID: PlayerOne, Unit: Unit{
+		Stats:     core.Stats{Health: 3, Range: 1, Move: 2, Power: 2},
+		Abilities: core.Abilities("00000-charge"),
+	}}, Position{X: 0, Y: 0})
+	state.CurrentPlayerID = PlayerOne
+
+	// Erreurs de syntaxe, signalées avant toute résolution.
+	texts := []string{
+		// Une seconde cible ou une seconde case n'ouvre pas un choix.
+		"ability:00000-charge 1 -> 2 -> 3",
+		"ability:00000-charge 1 @ 2,0 @ 2,1",
+		"ability:00005-command-forward 0 -> 1 @ 3,2 -> 2 @ 4,2",
+		// Choix vide
+		"ability:00005-command-forward 0 -> 1 @ 3,2 ;",
+		"ability:00005-command-forward 0 -> 1 ; ; -> 2",
+		// Seules les capacités ont plusieurs choix.
+		"move 1 -> 1,1 ; -> 2,2",
+		"attack 1 -> 2 ; -> 3",
+		"move 1 @ 1,1",
+		"attack 1 -> 2 @ 2,0",
+		"move 1 ->",
+		"move -> 1,1",
+		"ability: 1",
+		"attack 1 -> 2,0",
+	}
+
+	for _, text := range texts {
+		t.Run(text, func(t *testing.T) {
+			action, err := ParseAction(state, text)
+			if err == nil {
+				t.Fatalf("expected an error, got action %q", FormatAction(action))
+			}
+			if !strings.Contains(err.Error(), "could not parse action") {
+				t.Errorf("expected a syntax error, got '%v'", err)
+			}
+		})
+	}
+}
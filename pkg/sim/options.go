@@ -1,8 +1,17 @@
 package sim
 
+import (
+	"math/rand"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+)
+
 type Options struct {
 	Strategies map[PlayerID]StrategyFunc
-	MaxTurns   uint
+	// Reactions : décision de chaque joueur sur les réactions de ses unités
+	// (cf. Reaction). Par défaut, DefaultReaction.
+	Reactions map[PlayerID]ReactionFunc
+	MaxTurns  uint
 	// Obstacles : emplacements choisis pendant la mise en place (un par
 	// joueur). Vide = tirage aléatoire parmi les emplacements valides.
 	Obstacles []Position
@@ -17,6 +26,40 @@ type Options struct {
 	// ActionRules : économie d'actions. La valeur zéro reproduit la règle
 	// publiée (2 actions par tour, quel que soit l'effectif).
 	ActionRules ActionRules
+	// Combat : résolution des attaques. La valeur zéro reproduit la règle
+	// publiée, sans dés (cf. CombatRules).
+	Combat CombatRules
+	// Morale : règle optionnelle du moral. La valeur zéro reproduit la
+	// règle publiée, sans moral (cf. MoraleRules).
+	Morale MoraleRules
+	// DiceSeed : graine des dés de la partie. 0 = tirée de Rand.
+	DiceSeed int64
+	// FogOfWar : chaque joueur ne voit que les ennemis en ligne de vue de ses
+	// unités (cf. View).
+	FogOfWar bool
+	// Teams : joueurs alliés, par équipe (cf. WithTeams). Vide = chacun pour
+	// soi.
+	Teams [][]PlayerID
+	// FirstPlayer : joueur qui ouvre la partie. -1 = tirage au sort.
+	FirstPlayer PlayerID
+	// Terrain : cases typées (cf. Terrain). Vide = plateau dégagé.
+	Terrain []TerrainCell
+	// Victory : condition de victoire. nil = capture (règle publiée).
+	Victory VictoryCondition
+	// Board : géométrie du plateau. Valeur zéro = plateau publié (8×8).
+	Board BoardLayout
+	// Abilities : implémentations des capacités. nil = registre par défaut,
+	// rempli par les capacités du moteur (cf. DefaultAbilityRegistry).
+	Abilities *AbilityRegistry
+	// Costs : barème du coût des unités (cf. GameState.Costs). nil = barème
+	// publié.
+	Costs *core.Costs
+	// Observers : destinataires des événements de la partie (cf. Event).
+	Observers []ObserverFunc
+	// Rand : source de tous les tirages de la partie (placement par défaut,
+	// obstacles aléatoires, premier joueur, graine des dés). nil = source fraîche, tirée de
+	// la source globale.
+	Rand *rand.Rand
 }
 
 type OptionFunc func(opts *Options)
@@ -24,17 +67,31 @@ type OptionFunc func(opts *Options)
 func NewOptions(funcs ...OptionFunc) *Options {
 	opts := &Options{
 		Strategies: map[PlayerID]StrategyFunc{
-			PlayerOne: DefaultStrategy,
-			PlayerTwo: DefaultStrategy,
+			PlayerOne:   DefaultStrategy,
+			PlayerTwo:   DefaultStrategy,
+			PlayerThree: DefaultStrategy,
+			PlayerFour:  DefaultStrategy,
+		},
+		Reactions: map[PlayerID]ReactionFunc{
+			PlayerOne:   DefaultReaction,
+			PlayerTwo:   DefaultReaction,
+			PlayerThree: DefaultReaction,
+			PlayerFour:  DefaultReaction,
 		},
 		// L'objectif de capture termine les parties bien avant : 60 tours
 		// est une borne de sécurité, plus un temps de jeu attendu.
 		MaxTurns:     60,
 		CaptureRules: DefaultCaptureRules,
+		FirstPlayer:  -1,
 	}
 	for _, fn := range funcs {
 		fn(opts)
 	}
+	// Le plateau est normalisé par NewMultiplayerGame, qui connaît le
+	// nombre d'escouades.
+	if opts.Rand == nil {
+		opts.Rand = rand.New(rand.NewSource(rand.Int63()))
+	}
 	return opts
 }
 
@@ -69,6 +126,101 @@ func WithActionRules(rules ActionRules) OptionFunc {
 	}
 }
 
+// WithAlternatingActivation fait jouer la partie en activation alternée (cf.
+// ActionRules.Alternating) plutôt qu'au budget d'actions de la règle publiée.
+func WithAlternatingActivation() OptionFunc {
+	return func(opts *Options) {
+		opts.ActionRules = ActionRules{Alternating: true}
+	}
+}
+
+// WithCombatRules fait tirer les attaques aux dés (cf. CombatRules).
+func WithCombatRules(rules CombatRules) OptionFunc {
+	return func(opts *Options) {
+		opts.Combat = rules
+	}
+}
+
+// WithMoraleRules met en vigueur la règle du moral (cf. MoraleRules).
+func WithMoraleRules(rules MoraleRules) OptionFunc {
+	return func(opts *Options) {
+		opts.Morale = rules
+	}
+}
+
+// WithDiceSeed impose la graine des dés — nécessaire pour rejouer une
+// partie enregistrée.
+func WithDiceSeed(seed int64) OptionFunc {
+	return func(opts *Options) {
+		opts.DiceSeed = seed
+	}
+}
+
+// WithFogOfWar active le brouillard de guerre : stratégies et réactions ne
+// reçoivent plus que la vue de leur joueur (cf. View, BeliefStrategy).
+func WithFogOfWar(enabled bool) OptionFunc {
+	return func(opts *Options) {
+		opts.FogOfWar = enabled
+	}
+}
+
+// WithTeams regroupe des joueurs en équipes : ils ne s'attaquent pas,
+// partagent leur vue et gagnent ensemble (cf. Allied). Les joueurs absents
+// des équipes données jouent seuls. Par exemple, pour un 2 contre 2 où
+// chaque joueur fait face à un adversaire :
+//
+//	WithTeams([]PlayerID{PlayerOne, PlayerThree}, []PlayerID{PlayerTwo, PlayerFour})
+func WithTeams(teams ...[]PlayerID) OptionFunc {
+	return func(opts *Options) {
+		opts.Teams = teams
+	}
+}
+
+// WithSeed rend la partie reproductible : mêmes escouades, mêmes stratégies
+// et même graine rejouent la même partie, coup pour coup. C'est ce qui permet
+// de relancer à l'identique une partie signalée depuis la Caserne.
+func WithSeed(seed int64) OptionFunc {
+	return WithRand(rand.New(rand.NewSource(seed)))
+}
+
+// WithRand fait passer tous les tirages de la partie par la source fournie.
+func WithRand(r *rand.Rand) OptionFunc {
+	return func(opts *Options) {
+		opts.Rand = r
+	}
+}
+
+// WithFirstPlayer impose le joueur qui ouvre la partie au lieu de le tirer
+// au sort — nécessaire pour rejouer une partie enregistrée.
+func WithFirstPlayer(playerID PlayerID) OptionFunc {
+	return func(opts *Options) {
+		opts.FirstPlayer = playerID
+	}
+}
+
+// WithBoardLayout change la géométrie du plateau : dimensions, zones de
+// capture et zones de déploiement (cf. BoardLayout.Validate).
+func WithBoardLayout(layout BoardLayout) OptionFunc {
+	return func(opts *Options) {
+		opts.Board = layout
+	}
+}
+
+// WithVictoryCondition change la mission : assassinat, escorte… (cf.
+// VictoryCondition). L'IA la joue sans réglage supplémentaire.
+func WithVictoryCondition(condition VictoryCondition) OptionFunc {
+	return func(opts *Options) {
+		opts.Victory = condition
+	}
+}
+
+// WithTerrain type les cases données (terrain difficile, couvert…).
+func WithTerrain(cells ...TerrainCell) OptionFunc {
+	return func(opts *Options) {
+		opts.Terrain = cells
+	}
+}
+
 // WithObstacles fixe les obstacles posés pendant la mise en place.
 func WithObstacles(positions ...Position) OptionFunc {
 	return func(opts *Options) {
@@ -76,18 +228,54 @@ func WithObstacles(positions ...Position) OptionFunc {
 	}
 }
 
+// WithObserver abonne un observateur aux événements de la partie : il les
+// reçoit au fil de l'eau, avant même que Run ne rende le GameStep qui les
+// porte.
+func WithObserver(observer ObserverFunc) OptionFunc {
+	return func(opts *Options) {
+		opts.Observers = append(opts.Observers, observer)
+	}
+}
+
+// WithCosts fait compter les unités au barème donné plutôt qu'au barème
+// publié : le balancer mesure ainsi chaque barème candidat sous les règles
+// qui en dépendent (pertes, départage, moral).
+func WithCosts(costs core.Costs) OptionFunc {
+	return func(opts *Options) {
+		opts.Costs = &costs
+	}
+}
+
+// WithAbilityRegistry fait jouer la partie avec ses propres implémentations
+// de capacités : des variantes expérimentales peuvent ainsi s'affronter dans
+// un même processus sans toucher au registre par défaut.
+func WithAbilityRegistry(registry *AbilityRegistry) OptionFunc {
+	return func(opts *Options) {
+		opts.Abilities = registry
+	}
+}
+
 func WithPlayerStrategy(playerID PlayerID, strategy StrategyFunc) OptionFunc {
 	return func(opts *Options) {
 		opts.Strategies[playerID] = strategy
 	}
 }
 
-// WithLookaheadDepth configures both players to use alpha-beta minimax at the given depth.
+// WithPlayerReaction change la façon dont le joueur décide des réactions de
+// ses unités (cf. Reaction), AlwaysReact par exemple.
+func WithPlayerReaction(playerID PlayerID, reaction ReactionFunc) OptionFunc {
+	return func(opts *Options) {
+		opts.Reactions[playerID] = reaction
+	}
+}
+
+// WithLookaheadDepth configures all players to use alpha-beta minimax at the given depth.
 // depth=1 is fast (one action ahead), depth=2 is the default (full response lookahead).
 func WithLookaheadDepth(depth int) OptionFunc {
 	strategy := LookaheadStrategy(depth)
 	return func(opts *Options) {
-		opts.Strategies[PlayerOne] = strategy
-		opts.Strategies[PlayerTwo] = strategy
+		for playerID := range PlayerID(MaxPlayers) {
+			opts.Strategies[playerID] = strategy
+		}
 	}
 }
@@ -0,0 +1,188 @@
+package sim
+
+import (
+	"slices"
+
+	"github.com/pkg/errors"
+)
+
+/* =============================================================================
+   Joueurs et équipes.
+
+   Une partie se joue à 2, 3 ou 4 joueurs : autant que le plateau a de zones
+   de déploiement (cf. BoardLayout.Players), identifiés de PlayerOne à
+   PlayerCount()-1. Les tours tournent dans cet ordre à partir du premier
+   joueur tiré au sort ; un joueur qui n'a plus d'unité est sauté.
+
+   Sans équipe, chacun joue pour soi (mêlée générale). WithTeams regroupe des
+   joueurs : ils ne s'attaquent pas, partagent leur vue sous le brouillard de
+   guerre et gagnent ensemble. Une équipe est désignée par son plus petit
+   PlayerID : c'est ce PlayerID que renvoient isGameOver, les conditions de
+   victoire et Game.Run comme vainqueur.
+
+   Hostilité et contrôle sont deux notions distinctes : une unité alliée
+   n'est pas une cible (cf. Allied), mais seul son propriétaire la commande
+   (cf. getControllableUnits) — les capacités qui visent un allié ne visent
+   que les unités de leur joueur.
+   ========================================================================== */
+
+// Teams porte l'équipe de chaque joueur, indexée par PlayerID : le plus
+// petit PlayerID de l'équipe plus un, 0 quand le joueur fait équipe seul.
+type Teams [MaxPlayers]int8
+
+// of renvoie l'équipe du joueur (cf. GameState.Team).
+func (t *Teams) of(playerID PlayerID) PlayerID {
+	if playerID < 0 || playerID >= MaxPlayers || t[playerID] == 0 {
+		return playerID
+	}
+	return PlayerID(t[playerID] - 1)
+}
+
+// PlayerCount renvoie le nombre de joueurs de la partie : un par zone de
+// déploiement du plateau.
+func (s GameState) PlayerCount() int {
+	// Sur le chemin de la recherche : le plateau d'un état construit par
+	// NewGameState est déjà normalisé (cf. board).
+	if players := len(s.Layout.DeploymentZones); players > 0 {
+		return players
+	}
+	return len(DefaultBoardLayout.DeploymentZones)
+}
+
+// Team renvoie l'équipe du joueur : le plus petit PlayerID de son équipe,
+// lui-même s'il joue seul.
+func (s GameState) Team(playerID PlayerID) PlayerID {
+	return s.Teams.of(playerID)
+}
+
+// Allied indique si les deux joueurs sont dans la même équipe — un joueur
+// est toujours son propre allié.
+func (s GameState) Allied(a, b PlayerID) bool {
+	return s.Teams.of(a) == s.Teams.of(b)
+}
+
+// nextPlayer renvoie le joueur qui suit playerID dans l'ordre des tours, en
+// sautant ceux qui n'ont plus d'unité — en activation alternée, plus
+// d'unité à activer. À deux joueurs, l'adversaire.
+func nextPlayer(state GameState, playerID PlayerID) PlayerID {
+	count := PlayerID(state.PlayerCount())
+
+	var remaining PlayerScores
+	for unit := range state.Units() {
+		if state.Get(unit.ID, CounterActivated, 0) > 0 {
+			continue
+		}
+		remaining[unit.OwnerID]++
+	}
+
+	// Le joueur lui-même en dernier : en activation alternée, il enchaîne
+	// si lui seul a encore des unités à activer.
+	for offset := PlayerID(1); offset <= count; offset++ {
+		next := (playerID + offset) % count
+		if remaining[next] > 0 {
+			return next
+		}
+	}
+
+	return (playerID + 1) % count
+}
+
+// teamScores regroupe des valeurs par joueur en valeurs par équipe, portées
+// par l'indice de l'équipe (cf. Team).
+func (s GameState) teamScores(values PlayerScores) PlayerScores {
+	var teams PlayerScores
+	for playerID := range PlayerID(s.PlayerCount()) {
+		teams[s.Team(playerID)] += values[playerID]
+	}
+	return teams
+}
+
+// rivalScore renvoie la plus haute valeur d'une équipe ennemie du joueur,
+// valeurs déjà regroupées par équipe (cf. teamScores).
+func (s GameState) rivalScore(teams PlayerScores, playerID PlayerID) int {
+	best, found := 0, false
+	for team := range PlayerID(s.PlayerCount()) {
+		if s.Team(team) != team || s.Allied(team, playerID) {
+			continue
+		}
+		if !found || teams[team] > best {
+			best, found = teams[team], true
+		}
+	}
+	return best
+}
+
+// scoreLead renvoie l'avance de l'équipe du joueur sur la meilleure équipe
+// ennemie. À deux joueurs, la différence avec l'adversaire.
+func (s GameState) scoreLead(values PlayerScores, playerID PlayerID) int {
+	teams := s.teamScores(values)
+	return teams[s.Team(playerID)] - s.rivalScore(teams, playerID)
+}
+
+// enemyLosses renvoie le coût cumulé des unités perdues par les ennemis du
+// joueur (cf. GameState.Losses).
+func (s GameState) enemyLosses(playerID PlayerID) int {
+	losses := 0
+	for other := range PlayerID(s.PlayerCount()) {
+		if !s.Allied(other, playerID) {
+			losses += s.Losses[other]
+		}
+	}
+	return losses
+}
+
+// newTeams traduit des équipes données par leurs joueurs (cf. WithTeams).
+func newTeams(teams [][]PlayerID) Teams {
+	var encoded Teams
+	for _, members := range teams {
+		if len(members) == 0 {
+			continue
+		}
+		leader := slices.Min(members)
+		for _, playerID := range members {
+			if playerID >= 0 && playerID < MaxPlayers {
+				encoded[playerID] = int8(leader) + 1
+			}
+		}
+	}
+	return encoded
+}
+
+// teamList renvoie les équipes de plus d'un joueur, chacune par ordre de
+// PlayerID — la forme que reçoit WithTeams.
+func (s GameState) teamList() [][]PlayerID {
+	var teams [][]PlayerID
+	for team := range PlayerID(s.PlayerCount()) {
+		if s.Team(team) != team {
+			continue
+		}
+		members := []PlayerID{}
+		for playerID := range PlayerID(s.PlayerCount()) {
+			if s.Team(playerID) == team {
+				members = append(members, playerID)
+			}
+		}
+		if len(members) > 1 {
+			teams = append(teams, members)
+		}
+	}
+	return teams
+}
+
+// ValidateTeams vérifie que chaque joueur d'une équipe est dans la partie et
+// n'appartient qu'à une équipe.
+func ValidateTeams(teams [][]PlayerID, players int) error {
+	seen := map[PlayerID]bool{}
+	for _, members := range teams {
+		for _, playerID := range members {
+			if playerID < 0 || int(playerID) >= players {
+				return errors.Errorf("player %d of a team is not in a %d-player game", playerID, players)
+			}
+			if seen[playerID] {
+				return errors.Errorf("player %d is in more than one team", playerID)
+			}
+			seen[playerID] = true
+		}
+	}
+	return nil
+}
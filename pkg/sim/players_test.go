@@ -0,0 +1,208 @@
+package sim
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+)
+
+func TestDeploymentEdges(t *testing.T) {
+	layout := BoardLayout{Width: 10, Height: 10, Players: 4}.Normalize()
+	if err := layout.Validate(); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	if e, g := 4, len(layout.DeploymentZones); e != g {
+		t.Fatalf("len(DeploymentZones): expected %d, got %d", e, g)
+	}
+
+	type testCase struct {
+		Player   PlayerID
+		Rows     []int
+		Position Position
+	}
+
+	testCases := []testCase{
+		{Player: PlayerOne, Rows: []int{0, 1}, Position: Position{X: 0, Y: 0}},
+		{Player: PlayerTwo, Rows: []int{9, 8}, Position: Position{X: 0, Y: 9}},
+		{Player: PlayerThree, Rows: []int{0, 1}, Position: Position{X: 0, Y: 2}},
+		{Player: PlayerFour, Rows: []int{9, 8}, Position: Position{X: 9, Y: 2}},
+	}
+
+	for _, tc := range testCases {
+		if e, g := tc.Rows, layout.DeploymentRows(tc.Player); !reflect.DeepEqual(e, g) {
+			t.Errorf("player %d: deployment rows: expected %v, got %v", tc.Player, e, g)
+		}
+		if e, g := tc.Position, layout.DeploymentPositions(tc.Player)[0]; e != g {
+			t.Errorf("player %d: first deployment position: expected %s, got %s", tc.Player, e, g)
+		}
+	}
+
+	if err := (BoardLayout{Players: 5}).Validate(); err == nil {
+		t.Error("expected a 5-player board to be rejected")
+	}
+	if err := (BoardLayout{Players: 1}).Validate(); err == nil {
+		t.Error("expected a 1-player board to be rejected")
+	}
+}
+
+func TestTeams(t *testing.T) {
+	stats := core.Stats{Health: 2, Range: 2, Move: 1, Power: 1}
+
+	state := NewGameState(BoardLayout{Width: 10, Height: 10, Players: 4})
+	state.Teams = newTeams([][]PlayerID{{PlayerOne, PlayerThree}, {PlayerTwo, PlayerFour}})
+	state.AddUnit(&PlayerUnit{ID: 0, OwnerID: PlayerOne, Unit: Unit{Stats: stats}}, Position{X: 4, Y: 4})
+	state.AddUnit(&PlayerUnit{ID: 1, OwnerID: PlayerThree, Unit: Unit{Stats: stats}}, Position{X: 5, Y: 4})
+	state.AddUnit(&PlayerUnit{ID: 2, OwnerID: PlayerTwo, Unit: Unit{Stats: stats}}, Position{X: 4, Y: 5})
+	for id := range UnitID(3) {
+		state.Set(id, CounterHealth, 2)
+	}
+
+	if !state.Allied(PlayerOne, PlayerThree) || state.Allied(PlayerOne, PlayerFour) {
+		t.Errorf("unexpected alliances")
+	}
+	if e, g := PlayerTwo, state.Team(PlayerFour); e != g {
+		t.Errorf("Team(PlayerFour): expected %d, got %d", e, g)
+	}
+	if e, g := [][]PlayerID{{PlayerOne, PlayerThree}, {PlayerTwo, PlayerFour}}, state.teamList(); !reflect.DeepEqual(e, g) {
+		t.Errorf("teamList(): expected %v, got %v", e, g)
+	}
+
+	// Un allié n'est pas une cible.
+	targets := getReachableOpponentUnits(state, PlayerOne, state.PositionOf(0), 2)
+	if e, g := []UnitID{2}, targets; !reflect.DeepEqual(e, g) {
+		t.Errorf("targets: expected %v, got %v", e, g)
+	}
+
+	// Les alliés tiennent la zone ensemble.
+	if e, g := 0, controlledObjectives(state, PlayerOne); e != g {
+		t.Errorf("contested zone: expected %d, got %d", e, g)
+	}
+	state.Kill(2)
+	if e, g := 1, controlledObjectives(state, PlayerOne); e != g {
+		t.Errorf("zone held with an ally: expected %d, got %d", e, g)
+	}
+
+	// Seule l'équipe de PlayerOne a encore des unités : elle l'emporte.
+	if over, winner := isGameOver(state); !over || winner != PlayerOne {
+		t.Errorf("isGameOver(): expected the team of player %d to win, got %v, %d", PlayerOne, over, winner)
+	}
+
+	if err := ValidateTeams([][]PlayerID{{PlayerOne, PlayerTwo}, {PlayerTwo}}, 4); err == nil {
+		t.Error("expected a player in two teams to be rejected")
+	}
+	if err := ValidateTeams([][]PlayerID{{PlayerOne, PlayerThree}}, 2); err == nil {
+		t.Error("expected a team with an absent player to be rejected")
+	}
+}
+
+func TestNextPlayer(t *testing.T) {
+	stats := core.Stats{Health: 2, Range: 1, Move: 1, Power: 1}
+
+	state := NewGameState(BoardLayout{Width: 10, Height: 10, Players: 4})
+	for id, owner := range []PlayerID{PlayerOne, PlayerTwo, PlayerFour} {
+		state.AddUnit(&PlayerUnit{ID: UnitID(id), OwnerID: owner, Unit: Unit{Stats: stats}}, Position{X: id, Y: 4})
+	}
+
+	// PlayerThree n'a plus d'unité : son tour est sauté.
+	for _, tc := range [][2]PlayerID{{PlayerOne, PlayerTwo}, {PlayerTwo, PlayerFour}, {PlayerFour, PlayerOne}} {
+		if e, g := tc[1], nextPlayer(state, tc[0]); e != g {
+			t.Errorf("nextPlayer(%d): expected %d, got %d", tc[0], e, g)
+		}
+	}
+}
+
+func TestMultiplayerGame(t *testing.T) {
+	squad := func() []Unit {
+		return []Unit{
+			{Stats: core.Stats{Health: 3, Range: 1, Move: 2, Power: 2}},
+			{Stats: core.Stats{Health: 2, Range: 3, Move: 1, Power: 2}},
+		}
+	}
+
+	type testCase struct {
+		Name    string
+		Players int
+		Teams   [][]PlayerID
+	}
+
+	testCases := []testCase{
+		{Name: "three players", Players: 3},
+		{Name: "free-for-all", Players: 4},
+		{Name: "two against two", Players: 4, Teams: [][]PlayerID{{PlayerOne, PlayerThree}, {PlayerTwo, PlayerFour}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			squads := make([][]Unit, tc.Players)
+			opts := []OptionFunc{
+				WithSeed(11),
+				WithBoardLayout(BoardLayout{Width: 10, Height: 10}),
+				WithTeams(tc.Teams...),
+				WithMaxTurns(24),
+			}
+			for i := range squads {
+				squads[i] = squad()
+				opts = append(opts, WithPlayerStrategy(PlayerID(i), SearchStrategy(2, 300)))
+			}
+
+			game := NewMultiplayerGame(squads, opts...)
+			if err := game.Validate(); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if e, g := tc.Players, game.State().PlayerCount(); e != g {
+				t.Fatalf("PlayerCount(): expected %d, got %d", e, g)
+			}
+
+			previous := PlayerID(-1)
+			played := map[PlayerID]bool{}
+			for step := range game.Run() {
+				state := game.State()
+				if step.Action != nil {
+					played[step.Player] = true
+					if attack, ok := step.Action.(*AttackAction); ok {
+						if target := state.Unit(attack.TargetID()); target != nil && state.Allied(target.OwnerID, step.Player) {
+							t.Fatalf("player %d attacked an ally", step.Player)
+						}
+					}
+				}
+				// Les tours tournent dans l'ordre des joueurs : seuls les
+				// joueurs éliminés sont sautés.
+				if previous >= 0 && step.Player != previous {
+					count := PlayerID(tc.Players)
+					for skipped := (previous + 1) % count; skipped != step.Player; skipped = (skipped + 1) % count {
+						if len(getControllableUnits(state, skipped)) > 0 {
+							t.Errorf("turn %d: player %d skipped after player %d", step.Turn, skipped, previous)
+						}
+					}
+				}
+				previous = step.Player
+			}
+			if e, g := tc.Players, len(played); e != g {
+				t.Errorf("players who acted: expected %d, got %d", e, g)
+			}
+
+			var buff bytes.Buffer
+			if err := game.Record().Write(&buff); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			record, err := ReadGameRecord(&buff)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if e, g := tc.Teams, record.Setup.Teams; len(e) > 0 && !reflect.DeepEqual(e, g) {
+				t.Errorf("recorded teams: expected %v, got %v", e, g)
+			}
+
+			replayed, err := Replay(record)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if e, g := printState(game.State()), printState(replayed.State()); e != g {
+				t.Errorf("final board: expected\n%s\ngot\n%s", e, g)
+			}
+		})
+	}
+}
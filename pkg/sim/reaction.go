@@ -0,0 +1,251 @@
+package sim
+
+import (
+	"fmt"
+	"slices"
+)
+
+/* =============================================================================
+   Réactions.
+
+   Tout se jouait jusqu'ici pendant le tour du joueur actif : rien ne
+   répondait à un déplacement ou à une attaque adverse. Un statut peut
+   désormais offrir une réaction à son porteur (cf. Status.React) quand une
+   unité ennemie agit :
+     - TriggerMove : elle achève un déplacement (Tir de Couverture), par une
+       action de mouvement ou par une capacité qui la déplace (Charge,
+       Ordre d'avancer, effets move et swap, move d'un script) ;
+     - TriggerAttack : elle attaque (Riposte), par une action d'attaque.
+
+   La fenêtre de réaction s'ouvre une fois l'action résolue : une capacité
+   qui déplace plusieurs unités en ouvre une par unité déplacée, dans
+   l'ordre de leurs identifiants. Les porteurs sont consultés dans l'ordre
+   de leurs identifiants, chacun par la ReactionFunc de son propriétaire
+   (cf. WithPlayerReaction) ; une réaction n'est offerte que si elle tient
+   encore après les précédentes, et la jouer consomme le statut qui
+   l'offrait. Une réaction n'ouvre pas d'autre fenêtre.
+
+   La recherche de l'IA résout les réactions des deux camps avec
+   DefaultReaction (cf. playAction) : elle voit venir le tir de couverture
+   adverse, et suppose que l'adversaire joue les siennes comme le moteur par
+   défaut.
+   ========================================================================== */
+
+const ActionReaction ActionType = "reaction"
+
+type TriggerType string
+
+const (
+	TriggerMove   TriggerType = "move"
+	TriggerAttack TriggerType = "attack"
+)
+
+// Trigger : action qui ouvre une fenêtre de réaction.
+type Trigger struct {
+	Type   TriggerType
+	Player PlayerID
+	// UnitID : unité qui a agi.
+	UnitID UnitID
+	// TargetID : cible de l'attaque, -1 pour un déplacement.
+	TargetID UnitID
+	// From : position de l'unité avant l'action.
+	From Position
+}
+
+// newTriggers relève l'état avant l'action et renvoie de quoi en tirer les
+// déclencheurs, une fois l'action appliquée. Une capacité déclenche un
+// déplacement pour chaque unité du joueur qu'elle a changée de case.
+func newTriggers(state GameState, playerID PlayerID, action Action) func(applied GameState) []Trigger {
+	switch a := action.(type) {
+	case *MoveAction:
+		trigger := Trigger{Type: TriggerMove, Player: playerID, UnitID: a.UnitID(), TargetID: -1, From: state.PositionOf(a.UnitID())}
+		return func(GameState) []Trigger { return []Trigger{trigger} }
+
+	case *AttackAction:
+		trigger := Trigger{Type: TriggerAttack, Player: playerID, UnitID: a.UnitID(), TargetID: a.TargetID(), From: state.PositionOf(a.UnitID())}
+		return func(GameState) []Trigger { return []Trigger{trigger} }
+
+	case *AbilityAction:
+		from := make([]Trigger, 0)
+		for unit := range state.Units() {
+			if unit.OwnerID == playerID {
+				from = append(from, Trigger{Type: TriggerMove, Player: playerID, UnitID: unit.ID, TargetID: -1, From: state.PositionOf(unit.ID)})
+			}
+		}
+		return func(applied GameState) []Trigger {
+			return slices.DeleteFunc(from, func(trigger Trigger) bool {
+				return applied.Unit(trigger.UnitID) == nil || applied.PositionOf(trigger.UnitID) == trigger.From
+			})
+		}
+
+	default:
+		return func(GameState) []Trigger { return nil }
+	}
+}
+
+// ReactionFunc décide si le joueur joue la réaction offerte à l'une de ses
+// unités. state est l'état juste après l'action qui la déclenche.
+type ReactionFunc func(state GameState, playerID PlayerID, reaction *Reaction) bool
+
+// React implémente Reactor.
+func (fn ReactionFunc) React(state GameState, playerID PlayerID, reaction *Reaction) bool {
+	return fn(state, playerID, reaction)
+}
+
+// AlwaysReact joue toutes les réactions offertes.
+func AlwaysReact(GameState, PlayerID, *Reaction) bool {
+	return true
+}
+
+// DefaultReaction joue une réaction si elle ne dégrade pas l'évaluation de
+// l'état pour le joueur (cf. evaluateState) : le statut consommé a une
+// valeur, une réaction sans effet le gaspillerait.
+func DefaultReaction(state GameState, playerID PlayerID, reaction *Reaction) bool {
+	return evaluateState(reaction.Apply(state.Copy()), playerID) >= evaluateState(state, playerID)
+}
+
+// Reaction : réaction offerte à une unité. Elle s'applique comme une action.
+type Reaction struct {
+	unitID  UnitID
+	status  string
+	trigger Trigger
+	effect  func(state GameState) GameState
+}
+
+// Apply implements Action.
+func (r *Reaction) Apply(state GameState) GameState {
+	state.Del(r.unitID, r.status)
+	state.emit(UnitReacted{Unit: r.unitID, Status: r.status, Trigger: r.trigger.UnitID})
+	return r.effect(state)
+}
+
+// Type implements Action.
+func (r *Reaction) Type() ActionType {
+	return ActionReaction
+}
+
+// String implements Action.
+func (r *Reaction) String() string {
+	return fmt.Sprintf("react %d (%s) -> %d", r.unitID, r.status, r.trigger.UnitID)
+}
+
+func (r *Reaction) UnitID() UnitID   { return r.unitID }
+func (r *Reaction) Status() string   { return r.status }
+func (r *Reaction) Trigger() Trigger { return r.trigger }
+
+var _ Action = &Reaction{}
+
+// offerReaction renvoie la réaction que le déclencheur offre à l'unité, nil
+// si aucun de ses statuts n'en offre. Un seul statut réagit par unité.
+func offerReaction(state GameState, unitID UnitID, trigger Trigger) *Reaction {
+	if !state.holdsReaction(unitID) || !allowsAction(state, unitID, ActionReaction) {
+		return nil
+	}
+
+	for _, status := range state.statusesOf(unitID) {
+		if status.React == nil {
+			continue
+		}
+		if effect := status.React(state, unitID, trigger); effect != nil {
+			return &Reaction{unitID: unitID, status: status.Name, trigger: trigger, effect: effect}
+		}
+	}
+
+	return nil
+}
+
+// resolveReactions ouvre la fenêtre de réaction du déclencheur, l'action
+// étant déjà appliquée à state. decide est consulté pour chaque réaction
+// offerte. Renvoie les unités qui ont réagi, dans l'ordre. Mute l'état reçu
+// — cf. la convention décrite sur Kill.
+func resolveReactions(state GameState, trigger Trigger, decide ReactionFunc) (GameState, []UnitID) {
+	holders := make([]UnitID, 0)
+	for unit := range state.Units() {
+		if !state.Allied(unit.OwnerID, trigger.Player) && state.holdsReaction(unit.ID) {
+			holders = append(holders, unit.ID)
+		}
+	}
+
+	var reacted []UnitID
+	for _, unitID := range holders {
+		unit := state.Unit(unitID)
+		if unit == nil {
+			continue
+		}
+		reaction := offerReaction(state, unitID, trigger)
+		if reaction == nil || !decide(state, unit.OwnerID, reaction) {
+			continue
+		}
+		state = reaction.Apply(state)
+		reacted = append(reacted, unitID)
+	}
+
+	return state, reacted
+}
+
+// applyAction applique l'action du joueur puis ouvre les fenêtres de
+// réaction qu'elle déclenche (cf. newTriggers), decide étant consulté pour
+// chaque réaction offerte. Renvoie les unités qui ont réagi, dans l'ordre.
+// Mute l'état reçu.
+func applyAction(state GameState, playerID PlayerID, action Action, decide ReactionFunc) (GameState, []UnitID) {
+	triggers := newTriggers(state, playerID, action)
+	state = action.Apply(state)
+
+	var reacted []UnitID
+	for _, trigger := range triggers(state) {
+		var units []UnitID
+		state, units = resolveReactions(state, trigger, decide)
+		reacted = append(reacted, units...)
+	}
+
+	return state, reacted
+}
+
+// playAction applique l'action du joueur puis les réactions qu'elle
+// déclenche, décidées par DefaultReaction. C'est ainsi que la recherche de
+// l'IA modélise les réactions. Mute l'état reçu.
+func playAction(state GameState, playerID PlayerID, action Action) GameState {
+	state, _ = applyAction(state, playerID, action, DefaultReaction)
+	return state
+}
+
+// overwatch : « quand une unité ennemie entre à portée, attaquez-la ». Le
+// déplacement doit s'achever à portée et en ligne de vue du porteur, d'une
+// case d'où l'ennemi n'y était pas.
+func overwatch(state GameState, unitID UnitID, trigger Trigger) func(GameState) GameState {
+	if trigger.Type != TriggerMove || state.Unit(trigger.UnitID) == nil {
+		return nil
+	}
+
+	unit := state.Unit(unitID)
+	pos := state.PositionOf(unitID)
+	reach := state.attackRange(unit)
+
+	if int(distance(pos, trigger.From)) <= reach {
+		return nil
+	}
+	if !slices.Contains(getReachableOpponentUnits(state, unit.OwnerID, pos, reach), trigger.UnitID) {
+		return nil
+	}
+
+	return func(state GameState) GameState {
+		return resolveAttack(state, unit, trigger.UnitID)
+	}
+}
+
+// counterStrike : « quand cette unité survit à une attaque au corps à corps,
+// frappez l'attaquant ».
+func counterStrike(state GameState, unitID UnitID, trigger Trigger) func(GameState) GameState {
+	if trigger.Type != TriggerAttack || trigger.TargetID != unitID || state.Unit(trigger.UnitID) == nil {
+		return nil
+	}
+
+	unit := state.Unit(unitID)
+	if int(distance(state.PositionOf(unitID), trigger.From)) > 1 {
+		return nil
+	}
+
+	return func(state GameState) GameState {
+		return resolveAttack(state, unit, trigger.UnitID)
+	}
+}
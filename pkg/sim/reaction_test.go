@@ -0,0 +1,272 @@
+package sim
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+)
+
+func newReactionTestState() GameState {
+	state := NewGameState(BoardLayout{})
+	// Tireur et combattant de mêlée du joueur 1, face à deux ennemis.
+	state.AddUnit(&PlayerUnit{ID: 0, OwnerID: PlayerOne, Unit: Unit{Stats: core.Stats{Health: 3, Range: 3, Move: 1, Power: 2}}}, Position{X: 3, Y: 0})
+	state.AddUnit(&PlayerUnit{ID: 1, OwnerID: PlayerOne, Unit: Unit{Stats: core.Stats{Health: 3, Range: 1, Move: 1, Power: 1}}}, Position{X: 6, Y: 3})
+	state.AddUnit(&PlayerUnit{ID: 2, OwnerID: PlayerTwo, Unit: Unit{Stats: core.Stats{Health: 3, Range: 1, Move: 2, Power: 1}}}, Position{X: 3, Y: 5})
+	state.AddUnit(&PlayerUnit{ID: 3, OwnerID: PlayerTwo, Unit: Unit{Stats: core.Stats{Health: 3, Range: 3, Move: 1, Power: 1}}}, Position{X: 6, Y: 4})
+	for id := range UnitID(4) {
+		state.Set(id, CounterHealth, 3)
+	}
+	state.CurrentPlayerID = PlayerTwo
+	state.ActionsLeft = 2
+	return state
+}
+
+func TestOverwatch(t *testing.T) {
+	type testCase struct {
+		Name     string
+		Action   Action
+		Decide   ReactionFunc
+		Reacted  bool
+		Expected int
+	}
+
+	// Capacités qui amènent l'unité 2 à portée du tireur, en (3, 3).
+	abilityMove := func(actions []Action) Action {
+		for _, action := range actions {
+			if desc := action.(*AbilityAction).Description(); desc.TargetX == 3 && desc.TargetY == 3 {
+				return action
+			}
+		}
+		t.Fatal("expected an ability action to (3, 3)")
+		return nil
+	}
+
+	registry := NewAbilityRegistry()
+	err := registry.RegisterScript("test-leap", `
+function getValidActions(state, unit) { return [{ x: 3, y: 3 }] }
+function apply(state, action) { state.move(action.unit, action.x, action.y) }
+`)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	initial := newReactionTestState()
+	leap := core.Ability{ID: "test-leap", Targeting: &core.Targeting{Type: core.TargetCell}, Effects: []core.Effect{{Type: core.EffectMove}}}
+
+	testCases := []testCase{
+		{
+			Name:     "enemy moving into range",
+			Action:   NewMoveAction(2, Position{X: 3, Y: 3}),
+			Decide:   AlwaysReact,
+			Reacted:  true,
+			Expected: 1,
+		},
+		{
+			Name:     "declined reaction",
+			Action:   NewMoveAction(2, Position{X: 3, Y: 3}),
+			Decide:   func(GameState, PlayerID, *Reaction) bool { return false },
+			Expected: 3,
+		},
+		{
+			Name:     "enemy staying out of range",
+			Action:   NewMoveAction(2, Position{X: 3, Y: 4}),
+			Decide:   AlwaysReact,
+			Expected: 3,
+		},
+		{
+			Name:     "attack",
+			Action:   NewAttackAction(3, 1),
+			Decide:   AlwaysReact,
+			Expected: 3,
+		},
+		{
+			Name:     "declared move effect",
+			Action:   abilityMove(declaredAbility(leap)(initial, initial.Unit(2))),
+			Decide:   AlwaysReact,
+			Reacted:  true,
+			Expected: 1,
+		},
+		{
+			Name:     "scripted move",
+			Action:   abilityMove(registry.abilities["test-leap"](initial, initial.Unit(2))),
+			Decide:   AlwaysReact,
+			Reacted:  true,
+			Expected: 1,
+		},
+		{
+			Name:     "command forward",
+			Action:   newCommandForward(initial.Unit(3), []AbilityChoice{{UnitID: 2, X: 3, Y: 3}}),
+			Decide:   AlwaysReact,
+			Reacted:  true,
+			Expected: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			state := newReactionTestState()
+			state.Set(0, CounterOverwatch, 1)
+
+			state, reacted := applyAction(state, PlayerTwo, tc.Action, tc.Decide)
+
+			if e, g := tc.Reacted, slices.Equal(reacted, []UnitID{0}); e != g {
+				t.Errorf("reacted: expected %v, got %v", e, reacted)
+			}
+			if e, g := !tc.Reacted, state.Get(0, CounterOverwatch, 0) > 0; e != g {
+				t.Errorf("overwatch kept: expected %v, got %v", e, g)
+			}
+			if e, g := tc.Expected, state.Get(2, CounterHealth, 0); e != g {
+				t.Errorf("unit 2 health: expected %d, got %d", e, g)
+			}
+		})
+	}
+}
+
+func TestCounterStrike(t *testing.T) {
+	type testCase struct {
+		Name     string
+		Attacker UnitID
+		Health   int
+		Expected int
+	}
+
+	testCases := []testCase{
+		{Name: "melee attack", Attacker: 3, Health: 3, Expected: 2},
+		{Name: "killing blow", Attacker: 3, Health: 1, Expected: 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			state := newReactionTestState()
+			state.Set(1, CounterCounterStrike, 1)
+			state.Set(1, CounterHealth, tc.Health)
+
+			state = playAction(state, PlayerTwo, NewAttackAction(tc.Attacker, 1))
+
+			if e, g := tc.Expected, state.Get(tc.Attacker, CounterHealth, 0); e != g {
+				t.Errorf("attacker health: expected %d, got %d", e, g)
+			}
+		})
+	}
+
+	// Une attaque à distance ne permet pas la riposte.
+	state := newReactionTestState()
+	state.MoveUnit(3, Position{X: 6, Y: 6})
+	state.Set(1, CounterCounterStrike, 1)
+
+	state = playAction(state, PlayerTwo, NewAttackAction(3, 1))
+	if e, g := 3, state.Get(3, CounterHealth, 0); e != g {
+		t.Errorf("ranged attacker health: expected %d, got %d", e, g)
+	}
+	if state.Get(1, CounterCounterStrike, 0) == 0 {
+		t.Errorf("expected counter-strike to be kept after a ranged attack")
+	}
+}
+
+func TestReactionReplay(t *testing.T) {
+	squad := func() []Unit {
+		return []Unit{
+			{Stats: core.Stats{Health: 3, Range: 3, Move: 1, Power: 2}, Abilities: core.Abilities("00012-overwatch")},
+			{Stats: core.Stats{Health: 3, Range: 1, Move: 2, Power: 2}, Abilities: core.Abilities("00013-counter-strike")},
+			{Stats: core.Stats{Health: 2, Range: 2, Move: 2, Power: 1}},
+		}
+	}
+
+	reactions := 0
+	for seed := range int64(4) {
+		game := NewGame(squad(), squad(),
+			WithSeed(seed),
+			WithPlayerStrategy(PlayerOne, SearchStrategy(2, 300)),
+			WithPlayerStrategy(PlayerTwo, SearchStrategy(2, 300)),
+			WithMaxTurns(12),
+		)
+		for step := range game.Run() {
+			for _, event := range step.Events {
+				if _, ok := event.(UnitReacted); ok {
+					reactions++
+				}
+			}
+		}
+
+		var buff bytes.Buffer
+		if err := game.Record().Write(&buff); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		record, err := ReadGameRecord(&buff)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+
+		replayed, err := Replay(record)
+		if err != nil {
+			t.Fatalf("seed %d: %+v", seed, err)
+		}
+		if e, g := printState(game.State()), printState(replayed.State()); e != g {
+			t.Errorf("seed %d: final board: expected\n%s\ngot\n%s", seed, e, g)
+		}
+	}
+
+	if reactions == 0 {
+		t.Errorf("expected at least one reaction to be played")
+	}
+}
+
+func TestReactionReplayWithRegistry(t *testing.T) {
+	// Variantes hors catalogue du Tir de Couverture et de la Riposte : leurs
+	// fenêtres de réaction ne s'ouvrent qu'avec le registre de la partie.
+	variants := make([]core.Ability, 0, 2)
+	registry := DefaultAbilityRegistry()
+	for _, id := range []string{"00012-overwatch", "00013-counter-strike"} {
+		ability := core.Abilities(id)[0]
+		ability.ID, ability.Cost = "test-"+id, ability.Cost-1
+		registry.RegisterDeclared(ability)
+		variants = append(variants, ability)
+	}
+
+	squad := func() []Unit {
+		return []Unit{
+			{Stats: core.Stats{Health: 3, Range: 3, Move: 1, Power: 2}, Abilities: variants[:1]},
+			{Stats: core.Stats{Health: 3, Range: 1, Move: 2, Power: 2}, Abilities: variants[1:]},
+			{Stats: core.Stats{Health: 2, Range: 2, Move: 2, Power: 1}},
+		}
+	}
+
+	reactions := 0
+	for seed := range int64(4) {
+		game := NewGame(squad(), squad(),
+			WithSeed(seed),
+			WithAbilityRegistry(registry),
+			WithPlayerStrategy(PlayerOne, SearchStrategy(2, 300)),
+			WithPlayerStrategy(PlayerTwo, SearchStrategy(2, 300)),
+			WithMaxTurns(12),
+		)
+		for range game.Run() {
+		}
+		for _, action := range game.Record().Actions {
+			reactions += len(action.Reactions)
+		}
+
+		var buff bytes.Buffer
+		if err := game.Record().Write(&buff); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		record, err := ReadGameRecord(&buff)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+
+		replayed, err := Replay(record)
+		if err != nil {
+			t.Fatalf("seed %d: %+v", seed, err)
+		}
+		if e, g := printState(game.State()), printState(replayed.State()); e != g {
+			t.Errorf("seed %d: final board: expected\n%s\ngot\n%s", seed, e, g)
+		}
+	}
+
+	if reactions == 0 {
+		t.Errorf("expected at least one reaction to be played")
+	}
+}
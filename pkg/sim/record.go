@@ -0,0 +1,566 @@
+package sim
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"slices"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+	"github.com/pkg/errors"
+)
+
+/* =============================================================================
+   Relevé de partie.
+
+   Un relevé capture la mise en place complète (unités, déploiement,
+   obstacles, premier joueur, règles) puis la liste ordonnée des décisions
+   prises à chaque GameStep. Il suffit à reconstruire la partie : Replay
+   rejoue chaque action par Action.Apply, à travers la même boucle de jeu,
+   après avoir vérifié qu'elle figurait parmi les actions légales du moment.
+
+   Les capacités jouées sont consignées avec leur implémentation : les
+   capacités déclarées et scriptées sont rejouées telles qu'elles ont été
+   jouées, même si le catalogue a changé depuis ; une capacité fournie en Go
+   par l'appelant (cf. AbilityRegistry.Register) ne se sérialise pas, et le
+   rejeu exige alors le registre de la partie (cf. WithAbilityRegistry).
+
+   Usage : archiver les parties intéressantes du balancer, joindre une partie
+   fautive de la Caserne à un rapport de bogue.
+   ========================================================================== */
+
+type GameRecord struct {
+	Setup   GameSetup        `json:"setup"`
+	Actions []RecordedAction `json:"actions"`
+	// Result : issue de la partie. nil = relevé d'une partie inachevée.
+	Result *GameResult `json:"result,omitempty"`
+}
+
+type GameSetup struct {
+	// Units : unités dans l'ordre des identifiants attribués par NewGame.
+	Units        []RecordedUnit `json:"units"`
+	Obstacles    []Position     `json:"obstacles"`
+	Terrain      []TerrainCell  `json:"terrain,omitempty"`
+	Board        BoardLayout    `json:"board"`
+	FirstPlayer  PlayerID       `json:"firstPlayer"`
+	CaptureRules CaptureRules   `json:"captureRules"`
+	ActionRules  ActionRules    `json:"actionRules"`
+	Combat       CombatRules    `json:"combat"`
+	// Morale : règle du moral (cf. MoraleRules). Absente = sans moral.
+	Morale *MoraleRules `json:"morale,omitempty"`
+	// DiceSeed : graine des dés de la partie (cf. CombatRules, MoraleRules).
+	DiceSeed int64 `json:"diceSeed,omitempty"`
+	// FogOfWar : partie jouée au brouillard de guerre (cf. WithFogOfWar).
+	FogOfWar bool `json:"fogOfWar,omitempty"`
+	// Teams : équipes de la partie (cf. WithTeams). Vide = chacun pour soi.
+	Teams [][]PlayerID `json:"teams,omitempty"`
+	// Victory : condition de victoire. nil = capture.
+	Victory *RecordedVictory `json:"victory,omitempty"`
+	// Costs : barème du coût des unités (cf. WithCosts). nil = barème publié.
+	Costs *core.Costs `json:"costs,omitempty"`
+	// Abilities : capacités des unités, avec leur implémentation dans le
+	// registre de la partie. Vide (relevé antérieur) = catalogue et
+	// registre par défaut.
+	Abilities []RecordedAbility `json:"abilities,omitempty"`
+	MaxTurns  uint              `json:"maxTurns"`
+}
+
+// RecordedAbility décrit une capacité jouée : ses données et la provenance
+// de son implémentation (cf. AbilityOrigin), avec de quoi la reconstruire
+// pour une capacité déclarée ou scriptée.
+type RecordedAbility struct {
+	ID          string          `json:"id"`
+	Origin      AbilityOrigin   `json:"origin"`
+	Label       core.Text       `json:"label,omitempty"`
+	Description core.Text       `json:"description,omitempty"`
+	Cost        float64         `json:"cost"`
+	Targeting   *core.Targeting `json:"targeting,omitempty"`
+	Effects     []core.Effect   `json:"effects,omitempty"`
+	Script      string          `json:"script,omitempty"`
+}
+
+func (a RecordedAbility) ability() core.Ability {
+	return core.Ability{
+		ID:          a.ID,
+		Label:       a.Label,
+		Description: a.Description,
+		Cost:        a.Cost,
+		Targeting:   a.Targeting,
+		Effects:     a.Effects,
+		Script:      a.Script,
+	}
+}
+
+// RecordedVictory décrit une condition de victoire par son nom et ses
+// paramètres (cf. RegisterVictoryCondition).
+type RecordedVictory struct {
+	Name   string          `json:"name"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+type RecordedUnit struct {
+	ID        UnitID     `json:"id"`
+	Owner     PlayerID   `json:"owner"`
+	Stats     core.Stats `json:"stats"`
+	Abilities []string   `json:"abilities"`
+	Position  Position   `json:"position"`
+}
+
+// RecordedAction est une décision de stratégie. Type vide : le joueur n'a
+// rien joué (stratégie sans action possible). Les champs sans objet pour le
+// type d'action valent -1.
+type RecordedAction struct {
+	Turn    uint       `json:"turn"`
+	Player  PlayerID   `json:"player"`
+	Type    ActionType `json:"type,omitempty"`
+	Ability string     `json:"ability,omitempty"`
+	Unit    UnitID     `json:"unit"`
+	Target  UnitID     `json:"target"`
+	X       int        `json:"x"`
+	Y       int        `json:"y"`
+	// Choices : choix suivants d'une capacité à cibles multiples (cf.
+	// AbilityActionDescription.Choices).
+	Choices []RecordedChoice `json:"choices,omitempty"`
+	// Reactions : unités adverses qui ont réagi à l'action (cf. Reaction),
+	// dans l'ordre où elles l'ont fait.
+	Reactions []UnitID `json:"reactions,omitempty"`
+}
+
+type RecordedChoice struct {
+	Target UnitID `json:"target"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+}
+
+// equal compare deux actions consignées, choix compris. Les réactions, qui
+// ne sont pas la décision du joueur, n'y entrent pas.
+func (a RecordedAction) equal(b RecordedAction) bool {
+	return a.Turn == b.Turn && a.Player == b.Player && a.Type == b.Type &&
+		a.Ability == b.Ability && a.Unit == b.Unit && a.Target == b.Target &&
+		a.X == b.X && a.Y == b.Y && slices.Equal(a.Choices, b.Choices)
+}
+
+type GameResult struct {
+	Turn   uint     `json:"turn"`
+	Winner PlayerID `json:"winner"`
+}
+
+// Record renvoie le relevé de la partie jouée jusqu'à la position courante
+// (cf. Undo).
+func (g *Game) Record() *GameRecord {
+	record := g.record
+	record.Actions = g.History()[:g.cursor]
+	if g.record.Result != nil {
+		result := *g.record.Result
+		record.Result = &result
+	}
+	return &record
+}
+
+func newGameSetup(state GameState, firstPlayer PlayerID, maxTurns uint, diceSeed int64) GameSetup {
+	setup := GameSetup{
+		Units:        make([]RecordedUnit, 0, state.UnitCount()),
+		Obstacles:    make([]Position, 0),
+		Board:        state.board(),
+		FirstPlayer:  firstPlayer,
+		CaptureRules: state.Rules,
+		ActionRules:  state.ActionRules,
+		Combat:       state.Combat,
+		Costs:        state.Costs,
+		MaxTurns:     maxTurns,
+	}
+
+	// Sans dés, la graine ne sert pas : le relevé l'omet. Les tests de moral
+	// se tirent aux mêmes dés que le combat.
+	if state.Combat.IsRandom() || state.Morale.Enabled() {
+		setup.DiceSeed = diceSeed
+	}
+	if state.Morale.Enabled() {
+		morale := state.Morale
+		setup.Morale = &morale
+	}
+
+	// Une condition qui ne se sérialise pas est consignée sans paramètres :
+	// Replay la reconstruit alors avec ses valeurs par défaut.
+	if state.Victory != nil {
+		setup.Victory = &RecordedVictory{Name: state.Victory.Name()}
+		if params, err := json.Marshal(state.Victory); err == nil {
+			setup.Victory.Params = params
+		}
+	}
+
+	registry := state.abilityRegistry()
+	for unit := range state.Units() {
+		abilities := make([]string, 0, len(unit.Abilities))
+		for _, a := range unit.Abilities {
+			abilities = append(abilities, a.ID)
+			if !slices.ContainsFunc(setup.Abilities, func(r RecordedAbility) bool { return r.ID == a.ID }) {
+				setup.Abilities = append(setup.Abilities, recordAbility(registry, a))
+			}
+		}
+		setup.Units = append(setup.Units, RecordedUnit{
+			ID:        unit.ID,
+			Owner:     unit.OwnerID,
+			Stats:     unit.Stats,
+			Abilities: abilities,
+			Position:  state.PositionOf(unit.ID),
+		})
+	}
+
+	for y := 0; y < setup.Board.Height; y++ {
+		for x := 0; x < setup.Board.Width; x++ {
+			pos := Position{X: x, Y: y}
+			if state.HasObstacle(pos) {
+				setup.Obstacles = append(setup.Obstacles, pos)
+			}
+			if terrain := state.TerrainAt(pos); terrain != TerrainOpen {
+				setup.Terrain = append(setup.Terrain, TerrainCell{Position: pos, Type: terrain})
+			}
+		}
+	}
+
+	return setup
+}
+
+func recordAbility(registry *AbilityRegistry, ability core.Ability) RecordedAbility {
+	recorded := RecordedAbility{
+		ID:          ability.ID,
+		Label:       ability.Label,
+		Description: ability.Description,
+		Cost:        ability.Cost,
+	}
+
+	origin := registry.origins[ability.ID]
+	recorded.Origin = origin.kind
+	switch origin.kind {
+	case AbilityDeclared:
+		recorded.Targeting, recorded.Effects = origin.ability.Targeting, origin.ability.Effects
+	case AbilityScripted:
+		recorded.Script = origin.ability.Script
+	}
+
+	return recorded
+}
+
+// replayAbilities reconstruit les capacités consignées et un registre qui
+// les implémente comme pendant la partie. Une capacité de l'appelant n'y
+// figure pas : seul le registre de la partie peut la fournir.
+func replayAbilities(recorded []RecordedAbility) (map[string]core.Ability, *AbilityRegistry, error) {
+	abilities := make(map[string]core.Ability, len(recorded))
+	registry := DefaultAbilityRegistry()
+
+	for _, r := range recorded {
+		abilities[r.ID] = r.ability()
+
+		switch r.Origin {
+		case AbilityNative:
+			if origin, _ := registry.Origin(r.ID); origin != AbilityNative {
+				return nil, nil, errors.Errorf("ability '%s': no native implementation in this engine", r.ID)
+			}
+		case AbilityDeclared:
+			if r.Targeting == nil {
+				return nil, nil, errors.Errorf("ability '%s': recorded without targeting", r.ID)
+			}
+			registry.RegisterDeclared(r.ability())
+		case AbilityScripted:
+			if err := registry.RegisterScript(r.ID, r.Script); err != nil {
+				return nil, nil, errors.Wrapf(err, "ability '%s'", r.ID)
+			}
+		case AbilityCustom:
+			delete(registry.abilities, r.ID)
+			delete(registry.origins, r.ID)
+		default:
+			return nil, nil, errors.Errorf("ability '%s': unknown origin '%s'", r.ID, r.Origin)
+		}
+	}
+
+	return abilities, registry, nil
+}
+
+// checkAbilities vérifie que le registre implémente les capacités consignées
+// comme pendant la partie.
+func (r *AbilityRegistry) checkAbilities(recorded []RecordedAbility) error {
+	for _, ability := range recorded {
+		origin, exists := r.origins[ability.ID]
+		switch {
+		case !exists && ability.Origin == AbilityCustom:
+			return errors.Errorf("ability '%s' was played with a custom implementation: replay the game with its registry", ability.ID)
+		case !exists:
+			return errors.Errorf("no registered implementation for ability '%s'", ability.ID)
+		case origin.kind != ability.Origin:
+			return errors.Errorf("ability '%s': played as %s, registered as %s", ability.ID, ability.Origin, origin.kind)
+		}
+
+		same := true
+		switch origin.kind {
+		case AbilityDeclared:
+			same = reflect.DeepEqual(origin.ability.Targeting, ability.Targeting) && reflect.DeepEqual(origin.ability.Effects, ability.Effects)
+		case AbilityScripted:
+			same = origin.ability.Script == ability.Script
+		}
+		if !same {
+			return errors.Errorf("ability '%s': registered implementation differs from the recorded one", ability.ID)
+		}
+	}
+
+	return nil
+}
+
+func recordAction(turn uint, playerID PlayerID, action Action) RecordedAction {
+	recorded := RecordedAction{
+		Turn:   turn,
+		Player: playerID,
+		Unit:   -1,
+		Target: -1,
+		X:      -1,
+		Y:      -1,
+	}
+
+	switch a := action.(type) {
+	case *MoveAction:
+		recorded.Type = ActionMove
+		recorded.Unit = a.UnitID()
+		recorded.X, recorded.Y = a.TargetPos().X, a.TargetPos().Y
+	case *AttackAction:
+		recorded.Type = ActionAttack
+		recorded.Unit = a.UnitID()
+		recorded.Target = a.TargetID()
+	case *AbilityAction:
+		recorded.Type = ActionAbility
+		recorded.Ability = a.ID()
+		if d := a.Description(); d != nil {
+			recorded.Unit = d.SourceUnitID
+			recorded.Target = d.TargetUnitID
+			recorded.X, recorded.Y = d.TargetX, d.TargetY
+			for _, choice := range d.Choices {
+				recorded.Choices = append(recorded.Choices, RecordedChoice{Target: choice.UnitID, X: choice.X, Y: choice.Y})
+			}
+		}
+	}
+
+	return recorded
+}
+
+// Write sérialise le relevé en JSON.
+func (r *GameRecord) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(r); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// ReadGameRecord lit un relevé produit par GameRecord.Write.
+func ReadGameRecord(r io.Reader) (*GameRecord, error) {
+	record := &GameRecord{}
+	if err := json.NewDecoder(r).Decode(record); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return record, nil
+}
+
+// Replay reconstruit la partie décrite par le relevé et y rejoue chacune de
+// ses actions. Une action qui n'est pas légale au moment où elle est jouée
+// interrompt le rejeu avec une erreur.
+//
+// Pour un relevé de partie terminée, l'issue obtenue doit être celle
+// consignée. Pour un relevé inachevé, la partie est rendue juste après la
+// dernière action : Run la poursuit avec les stratégies passées en options.
+func Replay(record *GameRecord, funcs ...OptionFunc) (*Game, error) {
+	setup := record.Setup
+
+	if err := setup.Board.Validate(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, cell := range setup.Terrain {
+		if err := cell.Type.Validate(); err != nil {
+			return nil, errors.Wrapf(err, "cell %s", cell.Position)
+		}
+	}
+
+	var morale MoraleRules
+	if setup.Morale != nil {
+		morale = *setup.Morale
+	}
+
+	var victory VictoryCondition
+	if setup.Victory != nil {
+		condition, err := NewVictoryCondition(setup.Victory.Name, setup.Victory.Params)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		victory = condition
+	}
+
+	recorded, registry, err := replayAbilities(setup.Abilities)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	squads := make([][]Unit, setup.Board.Normalize().Players)
+	deployment := map[PlayerID][]Position{}
+	for i, u := range setup.Units {
+		if u.ID != UnitID(i) {
+			return nil, errors.Errorf("unexpected unit id %d at index %d", u.ID, i)
+		}
+		if u.Owner < 0 || int(u.Owner) >= len(squads) {
+			return nil, errors.Errorf("unit %d belongs to player %d, not in a %d-player game", u.ID, u.Owner, len(squads))
+		}
+		abilities, err := lookupRecordedAbilities(recorded, u.Abilities)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unit %d", u.ID)
+		}
+		squads[u.Owner] = append(squads[u.Owner], Unit{Stats: u.Stats, Abilities: abilities})
+		deployment[u.Owner] = append(deployment[u.Owner], u.Position)
+	}
+
+	var (
+		game      *Game
+		cursor    int
+		replayErr error
+	)
+
+	scripted := StrategyFunc(func(state GameState, playerID PlayerID) Action {
+		if cursor >= len(record.Actions) {
+			replayErr = errors.New("record ends before the game does")
+			return nil
+		}
+
+		expected := record.Actions[cursor]
+		cursor++
+
+		if expected.Turn != game.turn || expected.Player != playerID {
+			replayErr = errors.Errorf("action %d: expected turn %d for player %d, got turn %d for player %d",
+				cursor-1, expected.Turn, expected.Player, game.turn, playerID)
+			return nil
+		}
+
+		if expected.Type == "" {
+			return nil
+		}
+
+		for _, action := range GetValidActionsForPlayer(state, playerID) {
+			if recordAction(game.turn, playerID, action).equal(expected) {
+				return action
+			}
+		}
+
+		replayErr = errors.Errorf("action %d: illegal %s by unit %d on turn %d", cursor-1, expected.Type, expected.Unit, expected.Turn)
+		return nil
+	})
+
+	// Les réactions consignées avec l'action en cours sont jouées, les autres
+	// déclinées.
+	scriptedReaction := ReactionFunc(func(state GameState, playerID PlayerID, reaction *Reaction) bool {
+		return cursor > 0 && slices.Contains(record.Actions[cursor-1].Reactions, reaction.UnitID())
+	})
+
+	options := []OptionFunc{
+		WithDeployment(deployment),
+		WithBoardLayout(setup.Board),
+		WithObstacles(setup.Obstacles...),
+		WithTerrain(setup.Terrain...),
+		WithFirstPlayer(setup.FirstPlayer),
+		WithCaptureRules(setup.CaptureRules),
+		WithActionRules(setup.ActionRules),
+		WithCombatRules(setup.Combat),
+		WithMoraleRules(morale),
+		WithDiceSeed(setup.DiceSeed),
+		WithFogOfWar(setup.FogOfWar),
+		WithTeams(setup.Teams...),
+		WithVictoryCondition(victory),
+		WithMaxTurns(setup.MaxTurns),
+	}
+	if len(setup.Abilities) > 0 {
+		options = append(options, WithAbilityRegistry(registry))
+	}
+	if setup.Costs != nil {
+		options = append(options, WithCosts(*setup.Costs))
+	}
+	options = append(options, funcs...)
+
+	game = NewMultiplayerGame(squads, options...)
+
+	if err := game.state.abilityRegistry().checkAbilities(setup.Abilities); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := game.Validate(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Les stratégies passées en options ne servent qu'à poursuivre la partie
+	// après le rejeu : on les met de côté le temps de rejouer.
+	// Le brouillard aussi : les actions consignées sont légales sur l'état
+	// réel, pas forcément sur la vue.
+	strategies, reactions, fog := game.strategies, game.reactions, game.fog
+	game.strategies = map[PlayerID]StrategyFunc{}
+	game.reactions = map[PlayerID]ReactionFunc{}
+	for playerID := range PlayerID(len(squads)) {
+		game.strategies[playerID] = scripted
+		game.reactions[playerID] = scriptedReaction
+	}
+	game.fog = false
+	defer func() {
+		game.strategies, game.reactions, game.fog = strategies, reactions, fog
+	}()
+
+	if len(record.Actions) == 0 && record.Result == nil {
+		return game, nil
+	}
+
+	for step := range game.Run() {
+		if replayErr != nil {
+			return nil, replayErr
+		}
+
+		// Une réaction consignée qui ne s'est pas offerte trahit un relevé
+		// qui ne correspond pas à la partie.
+		if step.Action != nil {
+			played := game.History()[cursor-1]
+			if expected := record.Actions[cursor-1]; !slices.Equal(played.Reactions, expected.Reactions) {
+				return nil, errors.Errorf("action %d: expected reactions %v, got %v", cursor-1, expected.Reactions, played.Reactions)
+			}
+		}
+
+		if step.IsOver {
+			if record.Result == nil {
+				return nil, errors.Errorf("game ends on turn %d but the record does not", step.Turn)
+			}
+			if step.Winner != record.Result.Winner || step.Turn != record.Result.Turn {
+				return nil, errors.Errorf("game ends on turn %d with winner %d, record says turn %d with winner %d",
+					step.Turn, step.Winner, record.Result.Turn, record.Result.Winner)
+			}
+			return game, nil
+		}
+
+		if cursor == len(record.Actions) && record.Result == nil {
+			break
+		}
+	}
+
+	if replayErr != nil {
+		return nil, replayErr
+	}
+
+	return game, nil
+}
+
+// lookupRecordedAbilities renvoie les capacités demandées parmi celles du
+// relevé, ou dans le catalogue pour un relevé qui n'en consigne pas.
+func lookupRecordedAbilities(recorded map[string]core.Ability, ids []string) ([]core.Ability, error) {
+	if len(recorded) == 0 {
+		return core.LookupAbilities(ids...)
+	}
+
+	abilities := make([]core.Ability, 0, len(ids))
+	for _, id := range ids {
+		ability, exists := recorded[id]
+		if !exists {
+			return nil, errors.Errorf("ability '%s' is not recorded", id)
+		}
+		abilities = append(abilities, ability)
+	}
+
+	return abilities, nil
+}
@@ -0,0 +1,181 @@
+package sim
+
+import (
+	"bytes"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+)
+
+func recordTestSquad() []Unit {
+	return []Unit{
+		{Stats: core.Stats{Health: 3, Range: 1, Move: 2, Power: 2}, Abilities: core.Abilities("00000-charge")},
+		{Stats: core.Stats{Health: 2, Range: 3, Move: 1, Power: 2}, Abilities: core.Abilities("00003-suppressing-fire")},
+		{Stats: core.Stats{Health: 2, Range: 2, Move: 3, Power: 1}, Abilities: core.Abilities("00007-feint")},
+	}
+}
+
+func playRecordTestGame(t *testing.T, maxTurns uint) *Game {
+	t.Helper()
+
+	game := NewGame(recordTestSquad(), recordTestSquad(),
+		WithSeed(7),
+		WithPlayerStrategy(PlayerOne, SearchStrategy(2, 300)),
+		WithPlayerStrategy(PlayerTwo, SearchStrategy(2, 300)),
+		WithMaxTurns(maxTurns),
+	)
+	for range game.Run() {
+	}
+
+	return game
+}
+
+func printState(state GameState) string {
+	var buff strings.Builder
+	state.Print(&buff)
+	return buff.String()
+}
+
+func TestRecordReplay(t *testing.T) {
+	game := playRecordTestGame(t, 10)
+
+	var buff bytes.Buffer
+	if err := game.Record().Write(&buff); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	record, err := ReadGameRecord(&buff)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	if record.Result == nil {
+		t.Fatal("expected a finished game to carry its result")
+	}
+
+	replayed, err := Replay(record)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	if e, g := printState(game.State()), printState(replayed.State()); e != g {
+		t.Errorf("final board: expected\n%s\ngot\n%s", e, g)
+	}
+
+	if !reflect.DeepEqual(game.Record(), replayed.Record()) {
+		t.Errorf("replayed game record differs from the original one")
+	}
+}
+
+func TestReplayPartialRecord(t *testing.T) {
+	game := playRecordTestGame(t, 10)
+
+	record := game.Record()
+	record.Actions = record.Actions[:5]
+	record.Result = nil
+
+	replayed, err := Replay(record)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	if e, g := 5, len(replayed.Record().Actions); e != g {
+		t.Errorf("len(replayed.Record().Actions): expected %d, got %d", e, g)
+	}
+
+	// La partie reprend là où le relevé s'arrête.
+	for step := range replayed.Run() {
+		if step.IsOver {
+			break
+		}
+	}
+
+	if replayed.Record().Result == nil {
+		t.Error("expected the resumed game to reach its end")
+	}
+}
+
+func TestReplayRejectsIllegalAction(t *testing.T) {
+	game := playRecordTestGame(t, 4)
+
+	record := game.Record()
+	for i, action := range record.Actions {
+		if action.Type == ActionMove {
+			// Une case hors du plateau n'est jamais une destination légale.
+			record.Actions[i].X = BoardSize + 1
+			break
+		}
+	}
+
+	if _, err := Replay(record); err == nil {
+		t.Fatal("expected an error when replaying an illegal action")
+	}
+}
+
+func TestReplayAbilityRegistry(t *testing.T) {
+	drain := core.Ability{ID: "test-drain", Label: core.Text{core.LanguageFR: "Drain"}, Cost: 2}
+	squad := func() []Unit {
+		units := recordTestSquad()
+		units[0].Abilities = append(units[0].Abilities, drain)
+		return units
+	}
+
+	registry := DefaultAbilityRegistry()
+	if err := registry.RegisterScript(drain.ID, drainScript); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	play := func(registry *AbilityRegistry) *GameRecord {
+		game := NewGame(squad(), squad(),
+			WithSeed(7),
+			WithAbilityRegistry(registry),
+			WithPlayerStrategy(PlayerOne, SearchStrategy(2, 300)),
+			WithPlayerStrategy(PlayerTwo, SearchStrategy(2, 300)),
+			WithMaxTurns(10),
+		)
+		for range game.Run() {
+		}
+
+		var buff bytes.Buffer
+		if err := game.Record().Write(&buff); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		record, err := ReadGameRecord(&buff)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		return record
+	}
+
+	// Le script est consigné : le relevé se rejoue sans le registre.
+	record := play(registry)
+	if !slices.ContainsFunc(record.Actions, func(a RecordedAction) bool { return a.Ability == drain.ID }) {
+		t.Fatal("expected the scripted ability to be played")
+	}
+	if _, err := Replay(record); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	// Un script modifié depuis n'est pas rejoué en silence.
+	changed := DefaultAbilityRegistry()
+	if err := changed.RegisterScript(drain.ID, drainScript+"\n// v2\n"); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if _, err := Replay(record, WithAbilityRegistry(changed)); err == nil {
+		t.Error("expected an error when replaying with another script")
+	}
+
+	// Une capacité en Go ne se rejoue qu'avec son registre.
+	custom := DefaultAbilityRegistry()
+	custom.Register(drain.ID, func(GameState, *PlayerUnit) []Action { return nil })
+	record = play(custom)
+	if _, err := Replay(record); err == nil || !strings.Contains(err.Error(), "custom implementation") {
+		t.Errorf("expected a custom implementation error, got %v", err)
+	}
+	if _, err := Replay(record, WithAbilityRegistry(custom)); err != nil {
+		t.Errorf("%+v", err)
+	}
+}
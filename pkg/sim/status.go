@@ -0,0 +1,296 @@
+package sim
+
+import "github.com/pkg/errors"
+
+/* =============================================================================
+   Statuts.
+
+   Un statut est un compteur d'unité doté d'une durée et de crochets. Jusqu'ici
+   chaque statut était un compteur brut dont l'expiration était codée à la
+   main dans beginTurn/endTurn, et dont les effets étaient dispersés entre
+   applyDamage, getReachableOpponentUnits et getValidActions — les
+   commentaires de ces fonctions gardent la trace de plusieurs expirations
+   mal placées.
+
+   Désormais un statut déclare :
+     - sa durée (cf. StatusExpiry), que la boucle de tour applique seule ;
+     - Then, le statut qui prend le relais à l'expiration ;
+     - ses crochets : dégâts reçus (Damage), dégâts interceptés pour un allié
+       (Intercept), ciblage (Targetable), légalité des actions (Allows) et
+       réaction aux actions adverses (React, cf. Reaction).
+
+   Une nouvelle capacité pose un statut enregistré (cf. RegisterStatus) avec
+   GameState.Set et n'a plus à toucher à la boucle de tour. La valeur du
+   compteur reste libre : le Gardien y range l'allié qu'il protège.
+   ========================================================================== */
+
+type StatusExpiry int
+
+const (
+	// ExpiresWhenConsumed : le statut ne s'use pas avec le temps, un de ses
+	// crochets le consomme (« le prochain point de dégât est annulé »).
+	ExpiresWhenConsumed StatusExpiry = iota
+	// ExpiresAtTurnStart : « jusqu'au début de votre prochain tour » — le
+	// statut expire au début du tour du propriétaire de l'unité.
+	ExpiresAtTurnStart
+	// ExpiresAtTurnEnd : le statut expire à la fin du tour du joueur
+	// affecté, propriétaire de l'unité (« lors de son prochain tour »).
+	ExpiresAtTurnEnd
+)
+
+type Status struct {
+	// Name : nom du compteur porté par l'unité.
+	Name   string
+	Expiry StatusExpiry
+	// Then : statut posé (valeur 1) à l'expiration de celui-ci, "" pour
+	// aucun. Une consommation par un crochet ne déclenche pas le relais.
+	Then string
+	// Damage reçoit les dégâts destinés au porteur et renvoie ceux qui
+	// restent ; consumed lève le statut.
+	Damage func(state GameState, unitID UnitID, damage int) (remaining int, consumed bool)
+	// Intercept indique si le porteur prend à sa place les dégâts destinés à
+	// l'allié targetID. Une interception consomme le statut.
+	Intercept func(state GameState, unitID UnitID, targetID UnitID) bool
+	// Targetable indique si l'adversaire peut cibler le porteur.
+	Targetable func(state GameState, unitID UnitID) bool
+	// Allows indique si le porteur peut entreprendre une action du type
+	// donné.
+	Allows func(state GameState, unitID UnitID, actionType ActionType) bool
+	// React renvoie l'effet de la réaction que le déclencheur offre au
+	// porteur, nil s'il n'en offre pas (cf. Reaction). Une réaction jouée
+	// consomme le statut.
+	React func(state GameState, unitID UnitID, trigger Trigger) func(GameState) GameState
+}
+
+// statuses : statuts enregistrés, dans l'ordre d'enregistrement — c'est
+// l'ordre dans lequel leurs crochets s'appliquent. statusMask marque les
+// indices de compteur des statuts (cf. unitSlot.present), reactionMask ceux
+// des statuts qui offrent une réaction.
+var (
+	statuses     []Status
+	statusByName = map[string]int{}
+	statusMask   uint32
+	reactionMask uint32
+)
+
+// RegisterStatus enregistre un statut, ou remplace celui de même nom. À
+// appeler depuis une fonction init, comme registerAbility. Un statut a
+// besoin d'un indice de compteur (cf. maxCounters) : quand la table est
+// pleine, il n'est pas enregistré.
+func RegisterStatus(status Status) error {
+	index, err := registerCounter(status.Name)
+	if err != nil {
+		return errors.Wrapf(err, "could not register status '%s'", status.Name)
+	}
+	statusMask |= 1 << index
+	if status.React != nil {
+		reactionMask |= 1 << index
+	} else {
+		reactionMask &^= 1 << index
+	}
+
+	if i, exists := statusByName[status.Name]; exists {
+		statuses[i] = status
+		return nil
+	}
+
+	statusByName[status.Name] = len(statuses)
+	statuses = append(statuses, status)
+
+	return nil
+}
+
+// registerStatus enregistre un statut du moteur. Enregistrés à
+// l'initialisation du paquet, avant tout autre, ils ont toujours leur
+// indice.
+func registerStatus(status Status) {
+	if err := RegisterStatus(status); err != nil {
+		panic(errors.WithStack(err))
+	}
+}
+
+// LookupStatus renvoie le statut enregistré sous ce nom.
+func LookupStatus(name string) (Status, bool) {
+	i, exists := statusByName[name]
+	if !exists {
+		return Status{}, false
+	}
+	return statuses[i], true
+}
+
+func init() {
+	registerStatus(Status{
+		Name:   CounterDefensiveStance,
+		Expiry: ExpiresWhenConsumed,
+		Damage: func(_ GameState, _ UnitID, damage int) (int, bool) {
+			if damage <= 0 {
+				return damage, false
+			}
+			return damage - 1, true
+		},
+	})
+
+	// Suppression : « ne peut effectuer qu'une seule action à son prochain
+	// tour » (texte de la carte). L'unité garde donc UNE action, pas zéro.
+	registerStatus(Status{
+		Name:   CounterSuppressed,
+		Expiry: ExpiresAtTurnEnd,
+		Allows: func(state GameState, unitID UnitID, _ ActionType) bool {
+			return state.Get(unitID, CounterRoundActions, 0) < 1
+		},
+	})
+
+	registerStatus(Status{
+		Name:   CounterUntargetable,
+		Expiry: ExpiresAtTurnStart,
+		Targetable: func(GameState, UnitID) bool {
+			return false
+		},
+	})
+
+	// Surcharge : « pending » n'a aucun effet ce tour-ci et devient « lock »
+	// au début du prochain tour du propriétaire, où il interdit l'attaque
+	// normale (cf. CounterOverchargePending).
+	registerStatus(Status{
+		Name:   CounterOverchargePending,
+		Expiry: ExpiresAtTurnStart,
+		Then:   CounterOverchargeLock,
+	})
+
+	registerStatus(Status{
+		Name:   CounterOverchargeLock,
+		Expiry: ExpiresAtTurnEnd,
+		Allows: func(_ GameState, _ UnitID, actionType ActionType) bool {
+			return actionType != ActionAttack
+		},
+	})
+
+	registerStatus(Status{
+		Name:   CounterGuardianOf,
+		Expiry: ExpiresAtTurnStart,
+		Intercept: func(state GameState, unitID UnitID, targetID UnitID) bool {
+			return UnitID(state.Get(unitID, CounterGuardianOf, -1)) == targetID
+		},
+	})
+
+	registerStatus(Status{
+		Name:   CounterOverwatch,
+		Expiry: ExpiresAtTurnStart,
+		React:  overwatch,
+	})
+
+	registerStatus(Status{
+		Name:   CounterCounterStrike,
+		Expiry: ExpiresAtTurnStart,
+		React:  counterStrike,
+	})
+}
+
+// isStatus indique si le compteur d'indice index est un statut.
+func isStatus(index int) bool {
+	return statusMask&(1<<index) != 0
+}
+
+// statusesOf renvoie les statuts portés par l'unité, dans l'ordre
+// d'enregistrement.
+func (s GameState) statusesOf(unitID UnitID) []*Status {
+	slot := s.slot(unitID)
+	if slot == nil || slot.present&statusMask == 0 {
+		return nil
+	}
+
+	carried := make([]*Status, 0, 2)
+	for i := range statuses {
+		if index, exists := counterIndex(statuses[i].Name); exists && slot.present&(1<<index) != 0 {
+			carried = append(carried, &statuses[i])
+		}
+	}
+	return carried
+}
+
+// expireStatuses lève les statuts de durée expiry portés par les unités du
+// joueur et pose leurs relais.
+func expireStatuses(state GameState, playerID PlayerID, expiry StatusExpiry) {
+	for unit := range state.Units() {
+		if unit.OwnerID != playerID {
+			continue
+		}
+		// Les relais posés pendant la boucle ne sont pas revisités :
+		// statusesOf est évalué une seule fois.
+		for _, status := range state.statusesOf(unit.ID) {
+			if status.Expiry != expiry {
+				continue
+			}
+			state.Del(unit.ID, status.Name)
+			if status.Then != "" {
+				state.Set(unit.ID, status.Then, 1)
+			}
+		}
+	}
+}
+
+// holdsReaction indique si l'unité porte un statut qui offre une réaction.
+func (s GameState) holdsReaction(unitID UnitID) bool {
+	slot := s.slot(unitID)
+	return slot != nil && slot.present&reactionMask != 0
+}
+
+// isTargetable indique si l'adversaire peut cibler l'unité.
+func isTargetable(state GameState, unitID UnitID) bool {
+	for _, status := range state.statusesOf(unitID) {
+		if status.Targetable != nil && !status.Targetable(state, unitID) {
+			return false
+		}
+	}
+	return true
+}
+
+// allowsAction indique si les statuts de l'unité l'autorisent à
+// entreprendre une action du type donné.
+func allowsAction(state GameState, unitID UnitID, actionType ActionType) bool {
+	for _, status := range state.statusesOf(unitID) {
+		if status.Allows != nil && !status.Allows(state, unitID, actionType) {
+			return false
+		}
+	}
+	return true
+}
+
+// interceptDamage cherche un allié de la cible qui prend les coups à sa
+// place, et consomme le statut qui l'y engage.
+func interceptDamage(state GameState, targetID UnitID) (UnitID, bool) {
+	target := state.Unit(targetID)
+	if target == nil {
+		return -1, false
+	}
+
+	for ally := range state.Units() {
+		if ally.OwnerID != target.OwnerID || ally.ID == targetID {
+			continue
+		}
+		for _, status := range state.statusesOf(ally.ID) {
+			if status.Intercept != nil && status.Intercept(state, ally.ID, targetID) {
+				state.Del(ally.ID, status.Name)
+				return ally.ID, true
+			}
+		}
+	}
+
+	return -1, false
+}
+
+// absorbDamage fait passer les dégâts destinés à l'unité par les crochets de
+// ses statuts et renvoie ceux qui restent.
+func absorbDamage(state GameState, unitID UnitID, damage int) int {
+	for _, status := range state.statusesOf(unitID) {
+		if status.Damage == nil {
+			continue
+		}
+		var consumed bool
+		damage, consumed = status.Damage(state, unitID, damage)
+		if consumed {
+			state.Del(unitID, status.Name)
+		}
+	}
+	return damage
+}
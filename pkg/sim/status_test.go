@@ -0,0 +1,104 @@
+package sim
+
+import (
+	"testing"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+)
+
+func statusTestState() GameState {
+	stats := core.Stats{Health: 3, Range: 2, Move: 1, Power: 1}
+
+	state := NewGameState(BoardLayout{})
+	state.AddUnit(&PlayerUnit{ID: 0, OwnerID: PlayerOne, Unit: Unit{Stats: stats}}, Position{X: 1, Y: 1})
+	state.AddUnit(&PlayerUnit{ID: 1, OwnerID: PlayerTwo, Unit: Unit{Stats: stats}}, Position{X: 2, Y: 1})
+	state.Set(0, CounterHealth, 3)
+	state.Set(1, CounterHealth, 3)
+
+	return state
+}
+
+func TestStatusExpiry(t *testing.T) {
+	type testCase struct {
+		Status string
+		// Turns : transitions jouées après la pose du statut par l'unité 0,
+		// au cours du tour de PlayerOne.
+		Turns    []func(GameState) GameState
+		Expected map[string]bool
+	}
+
+	endOne := func(s GameState) GameState { return endTurn(s, PlayerOne) }
+	beginTwo := func(s GameState) GameState { return beginTurn(s, PlayerTwo) }
+	endTwo := func(s GameState) GameState { return endTurn(s, PlayerTwo) }
+	beginOne := func(s GameState) GameState { return beginTurn(s, PlayerOne) }
+
+	testCases := []testCase{
+		{
+			Status:   CounterUntargetable,
+			Turns:    []func(GameState) GameState{endOne, beginTwo, endTwo},
+			Expected: map[string]bool{CounterUntargetable: true},
+		},
+		{
+			Status:   CounterUntargetable,
+			Turns:    []func(GameState) GameState{endOne, beginTwo, endTwo, beginOne},
+			Expected: map[string]bool{CounterUntargetable: false},
+		},
+		{
+			Status:   CounterOverchargePending,
+			Turns:    []func(GameState) GameState{endOne, beginTwo, endTwo, beginOne},
+			Expected: map[string]bool{CounterOverchargePending: false, CounterOverchargeLock: true},
+		},
+		{
+			Status:   CounterOverchargePending,
+			Turns:    []func(GameState) GameState{endOne, beginTwo, endTwo, beginOne, endOne},
+			Expected: map[string]bool{CounterOverchargePending: false, CounterOverchargeLock: false},
+		},
+		{
+			Status:   CounterDefensiveStance,
+			Turns:    []func(GameState) GameState{endOne, beginTwo, endTwo, beginOne, endOne},
+			Expected: map[string]bool{CounterDefensiveStance: true},
+		},
+	}
+
+	for i, tc := range testCases {
+		state := beginTurn(statusTestState(), PlayerOne)
+		state.Set(0, tc.Status, 1)
+		for _, turn := range tc.Turns {
+			state = turn(state)
+		}
+		for name, expected := range tc.Expected {
+			if g := state.Get(0, name, 0) > 0; g != expected {
+				t.Errorf("#%d %s: expected '%s' present = %v, got %v", i, tc.Status, name, expected, g)
+			}
+		}
+	}
+}
+
+func TestStatusHooks(t *testing.T) {
+	state := statusTestState()
+	state.Set(1, CounterUntargetable, 1)
+	state.Set(0, CounterOverchargeLock, 1)
+	state.Set(0, CounterSuppressed, 1)
+
+	if isTargetable(state, 1) || !isTargetable(state, 0) {
+		t.Error("expected only unit 1 to be untargetable")
+	}
+	if allowsAction(state, 0, ActionAttack) || !allowsAction(state, 0, ActionMove) {
+		t.Error("expected the overcharge lock to forbid attacks only")
+	}
+
+	state.Inc(0, CounterRoundActions, 1)
+	if allowsAction(state, 0, ActionMove) || allowsAction(state, 0, ActionAbility) {
+		t.Error("expected the suppressed unit to be limited to one action")
+	}
+	if actions := getValidActions(state, state.Unit(0)); len(actions) != 0 {
+		t.Errorf("expected no valid action, got %v", actions)
+	}
+
+	if status, exists := LookupStatus(CounterDefensiveStance); !exists || status.Expiry != ExpiresWhenConsumed {
+		t.Errorf("unexpected defensive stance status %+v", status)
+	}
+	if _, exists := LookupStatus(CounterHealth); exists {
+		t.Error("expected health not to be a status")
+	}
+}
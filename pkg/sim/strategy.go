@@ -4,6 +4,12 @@ type Strategy interface {
 	NextAction(state GameState, playerID PlayerID) Action
 }
 
+// Reactor : stratégie consultée sur les réactions de ses unités pendant le
+// tour adverse (cf. Reaction, ReactionFunc).
+type Reactor interface {
+	React(state GameState, playerID PlayerID, reaction *Reaction) bool
+}
+
 type StrategyFunc func(state GameState, playerID PlayerID) Action
 
 func (fn StrategyFunc) NextAction(state GameState, playerID PlayerID) Action {
@@ -23,13 +29,13 @@ func findBestAction(state GameState, playerID PlayerID) Action {
 	var bestAction Action
 	bestScore := -1e9
 
-	possibleUnits := getControllableUnits(state, playerID)
+	possibleUnits := activeUnits(state, playerID)
 
 	for _, unit := range possibleUnits {
 		possibleActions := getValidActions(state, unit)
 
 		for _, action := range possibleActions {
-			futureState := action.Apply(state.Copy())
+			futureState := playAction(state.Copy(), playerID, action)
 			score := evaluateState(futureState, playerID)
 
 			if score > bestScore {
@@ -45,7 +51,7 @@ func findBestAction(state GameState, playerID PlayerID) Action {
 func getControllableUnits(state GameState, playerID PlayerID) []*PlayerUnit {
 	units := make([]*PlayerUnit, 0)
 
-	for _, u := range state.Units {
+	for u := range state.Units() {
 		if u.OwnerID == playerID {
 			units = append(units, u)
 		}
@@ -57,31 +63,48 @@ func getControllableUnits(state GameState, playerID PlayerID) []*PlayerUnit {
 // evaluateState note un état du point de vue de playerID.
 //
 // Hiérarchie des poids, du dominant au marginal :
-//  1. points de contrôle de la zone centrale (la condition de victoire) ;
-//  2. matériel — santé et menace résiduelle des unités ;
+//  1. progression vers la victoire — par défaut les points de contrôle (cf.
+//     VictoryCondition.Score) ;
+//  2. matériel — santé, armure et menace résiduelle des unités ;
 //  3. position — présence dans la zone, distance de combat adaptée au profil
 //     (les tireurs veulent tenir leur portée SANS être à portée adverse,
 //     l'ancienne évaluation poussait tout le monde au corps-à-corps, snipers
 //     compris) ;
 //  4. exposition — pénalité quand une unité fragile est dans la zone de
 //     mise à mort d'un ennemi.
+//
+// Sous la règle du moral, le matériel d'une escouade ébranlée est décoté de
+// ce que ses tests risquent de lui coûter (cf. moraleRisk) : la recherche ne
+// les tire pas, elle en escompte l'effet.
 func evaluateState(state GameState, playerID PlayerID) float64 {
-	opponent := getOpponentPlayerID(playerID)
-
-	score := float64(state.ControlPoints[playerID]-state.ControlPoints[opponent]) * 60.0
+	score := state.victory().Score(state, playerID)
+	board := state.board()
+	teams, team := &state.Teams, state.Team(playerID)
+
+	var risks [MaxPlayers]float64
+	if state.Morale.Enabled() {
+		for other := range PlayerID(state.PlayerCount()) {
+			risks[other] = state.moraleRisk(other)
+		}
+	}
 
-	for _, unit := range state.Units {
+	for unit := range state.Units() {
 		sign := 1.0
-		if unit.OwnerID != playerID {
+		if teams.of(unit.OwnerID) != team {
 			sign = -1.0
 		}
 
-		health := float64(state.Get(unit.ID, CounterHealth, 0))
+		// Sous les dés, la recherche compte les dégâts moyens au millième
+		// près (cf. resolveExpectedAttack).
+		health := float64(state.Get(unit.ID, CounterHealth, 0)) - state.woundsOf(unit.ID)
+		// Un point de Défense retranche un point à chaque coup : il vaut un
+		// peu moins qu'un point de vie par coup attendu.
+		armor := float64(unit.Stats.Defense) * 0.8
 		threat := float64(unit.Stats.Power)*0.8 +
 			float64(unit.Stats.Range)*0.3 +
 			float64(unit.Stats.Move)*0.3
 
-		score += sign * (health + threat)
+		score += sign * (health + armor + threat) * (1 - risks[unit.OwnerID])
 
 		// Une Posture Défensive active vaut presque un point de vie : elle
 		// annulera le prochain point de dégât reçu.
@@ -89,13 +112,20 @@ func evaluateState(state GameState, playerID PlayerID) float64 {
 			score += sign * 0.8
 		}
 
-		pos := state.Positions[unit.ID]
+		// Une réaction en attente menace l'ennemi qui agit à sa portée.
+		if state.holdsReaction(unit.ID) {
+			score += sign * 0.5
+		}
+
+		pos := state.PositionOf(unit.ID)
+
+		score += sign * terrainScore(state, unit, pos)
 
 		// Contrôle de la zone : y être vaut cher, s'en approcher un peu.
-		if InObjectiveZone(pos) {
+		if board.InObjectiveZone(pos) {
 			score += sign * 2.5
 		} else {
-			score -= sign * objectiveDistance(pos) * 0.15
+			score -= sign * board.objectiveDistance(pos) * 0.15
 		}
 
 		// Distance de combat selon le profil.
@@ -110,8 +140,8 @@ func evaluateState(state GameState, playerID PlayerID) float64 {
 		}
 
 		// Zone de mise à mort : à portée d'un ennemi capable de nous achever.
-		if nearestDist <= float64(nearestEnemy.Stats.Range)+float64(nearestEnemy.Stats.Move) &&
-			int(health) <= nearestEnemy.Stats.Power {
+		if nearestDist <= float64(state.attackRange(nearestEnemy))+float64(nearestEnemy.Stats.Move) &&
+			int(health) <= state.armor(unit.ID, nearestEnemy.Stats.Power) {
 			score -= sign * 1.5
 		}
 	}
@@ -122,11 +152,12 @@ func evaluateState(state GameState, playerID PlayerID) float64 {
 func nearestEnemyFrom(state GameState, unit *PlayerUnit, from Position) (float64, *PlayerUnit) {
 	best := 1e9
 	var enemy *PlayerUnit
-	for _, other := range state.Units {
-		if other.OwnerID == unit.OwnerID {
+	teams, team := &state.Teams, state.Team(unit.OwnerID)
+	for other := range state.Units() {
+		if teams.of(other.OwnerID) == team {
 			continue
 		}
-		if d := distance(from, state.Positions[other.ID]); d < best {
+		if d := distance(from, state.PositionOf(other.ID)); d < best {
 			best = d
 			enemy = other
 		}
@@ -154,18 +185,10 @@ func isProvidingCover(coverUnit, protectedUnit, enemyUnit Position) bool {
 		(distCoverToProtected+distCoverToEnemy) <= (distProtectedToEnemy+1.5)
 }
 
-// Helper function to get opponent player ID
-func getOpponentPlayerID(playerID PlayerID) PlayerID {
-	if playerID == PlayerOne {
-		return PlayerTwo
-	}
-	return PlayerOne
-}
-
 // GetValidActionsForPlayer returns all valid actions for all controllable units of a player.
 func GetValidActionsForPlayer(state GameState, playerID PlayerID) []Action {
 	var actions []Action
-	for _, unit := range getControllableUnits(state, playerID) {
+	for _, unit := range activeUnits(state, playerID) {
 		actions = append(actions, getValidActions(state, unit)...)
 	}
 	return actions
@@ -48,14 +48,12 @@ func TestEvaluateStateDefensiveActions(t *testing.T) {
 	}
 
 	// Test state without defensive stance
-	stateWithoutDefense := GameState{
-		counters:        map[UnitID]map[string]int{},
-		Positions:       map[UnitID]Position{1: {X: 2, Y: 2}, 2: {X: 3, Y: 2}, 3: {X: 5, Y: 2}},
-		Board:           map[string]UnitID{"2,2": 1, "3,2": 2, "5,2": 3},
-		Units:           map[UnitID]*PlayerUnit{1: unit1, 2: unit2, 3: enemyUnit},
-		CurrentPlayerID: PlayerOne,
-		ActionsLeft:     2,
-	}
+	stateWithoutDefense := NewGameState(BoardLayout{})
+	stateWithoutDefense.AddUnit(unit1, Position{X: 2, Y: 2})
+	stateWithoutDefense.AddUnit(unit2, Position{X: 3, Y: 2})
+	stateWithoutDefense.AddUnit(enemyUnit, Position{X: 5, Y: 2})
+	stateWithoutDefense.CurrentPlayerID = PlayerOne
+	stateWithoutDefense.ActionsLeft = 2
 
 	// Set initial health
 	stateWithoutDefense.Set(1, CounterHealth, 3)
@@ -80,3 +78,28 @@ func TestEvaluateStateDefensiveActions(t *testing.T) {
 	t.Logf("Score with defensive stance: %.2f", scoreWithDefense)
 	t.Logf("Defensive bonus: %.2f", scoreWithDefense-scoreWithoutDefense)
 }
+
+// BenchmarkSearchStrategy mesure une décision de l'IA « difficile » de la
+// Caserne (profondeur 6, 30000 nœuds) en milieu de partie.
+func BenchmarkSearchStrategy(b *testing.B) {
+	game := NewGame(recordTestSquad(), recordTestSquad(),
+		WithSeed(7),
+		WithPlayerStrategy(PlayerOne, SearchStrategy(2, 300)),
+		WithPlayerStrategy(PlayerTwo, SearchStrategy(2, 300)),
+		WithMaxTurns(60),
+	)
+	for range game.Run() {
+		if game.Turn() >= 4 {
+			break
+		}
+	}
+
+	state := game.State()
+	strategy := SearchStrategy(6, 30000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		strategy(state.Copy(), state.CurrentPlayerID)
+	}
+}
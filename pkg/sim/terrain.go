@@ -0,0 +1,130 @@
+package sim
+
+import (
+	"slices"
+
+	"github.com/pkg/errors"
+)
+
+/* =============================================================================
+   Terrain.
+
+   Les obstacles restent des murs : infranchissables, ils coupent la ligne de
+   vue. Le terrain type les autres cases, comme sur la table :
+
+     difficult    terrain difficile — y entrer coûte 2 points de mouvement ;
+     light-cover  couvert léger — une attaque à distance (portée > 1) contre
+                  l'unité qui s'y tient perd 1 dégât, sans descendre sous 1.
+                  Il ne coupe pas la ligne de vue ;
+     high-ground  hauteur — l'unité qui s'y tient attaque à +1 de portée ;
+     hazardous    terrain dangereux — 1 dégât à l'unité qui s'y trouve à la
+                  fin du tour de son propriétaire.
+
+   Seule l'attaque normale profite du couvert et de la hauteur : les
+   capacités décrivent leur propre portée et leurs propres dégâts.
+   ========================================================================== */
+
+type Terrain string
+
+const (
+	TerrainOpen       Terrain = ""
+	TerrainDifficult  Terrain = "difficult"
+	TerrainLightCover Terrain = "light-cover"
+	TerrainHighGround Terrain = "high-ground"
+	TerrainHazardous  Terrain = "hazardous"
+)
+
+// Validate vérifie qu'un type de terrain est connu.
+func (t Terrain) Validate() error {
+	switch t {
+	case TerrainOpen, TerrainDifficult, TerrainLightCover, TerrainHighGround, TerrainHazardous:
+		return nil
+	default:
+		return errors.Errorf("unknown terrain '%s'", t)
+	}
+}
+
+// TerrainCell type une case du plateau.
+type TerrainCell struct {
+	Position Position `json:"position"`
+	Type     Terrain  `json:"type"`
+}
+
+// terrainTypes : types de terrain, dans l'ordre de leur indice dans les
+// cases de l'état (cf. cell.terrain).
+var terrainTypes = []Terrain{TerrainOpen, TerrainDifficult, TerrainLightCover, TerrainHighGround, TerrainHazardous}
+
+func terrainIndex(t Terrain) uint8 {
+	if index := slices.Index(terrainTypes, t); index > 0 {
+		return uint8(index)
+	}
+	return 0
+}
+
+// TerrainAt renvoie le terrain d'une case.
+func (s GameState) TerrainAt(pos Position) Terrain {
+	if i := s.cellIndex(pos); i >= 0 {
+		return terrainTypes[s.cells[i].terrain]
+	}
+	return TerrainOpen
+}
+
+// moveCost renvoie le coût, en points de mouvement, de l'entrée dans une case.
+func (s GameState) moveCost(pos Position) int {
+	if s.TerrainAt(pos) == TerrainDifficult {
+		return 2
+	}
+	return 1
+}
+
+// attackRange renvoie la portée d'attaque effective d'une unité, hauteur
+// comprise.
+func (s GameState) attackRange(unit *PlayerUnit) int {
+	if s.TerrainAt(s.PositionOf(unit.ID)) == TerrainHighGround {
+		return unit.Stats.Range + 1
+	}
+	return unit.Stats.Range
+}
+
+// attackDamage renvoie les dégâts d'une attaque normale, couvert de la cible
+// compris.
+func (s GameState) attackDamage(attacker *PlayerUnit, targetID UnitID) int {
+	damage := attacker.Stats.Power
+	targetPos := s.PositionOf(targetID)
+	if s.TerrainAt(targetPos) == TerrainLightCover &&
+		distance(s.PositionOf(attacker.ID), targetPos) > 1 && damage > 1 {
+		damage--
+	}
+	return damage
+}
+
+// applyHazards inflige ses dégâts de fin de tour aux unités du joueur qui se
+// tiennent sur un terrain dangereux. Mute l'état reçu.
+func applyHazards(state GameState, playerID PlayerID) GameState {
+	for unit := range state.Units() {
+		if unit.OwnerID != playerID {
+			continue
+		}
+		if state.TerrainAt(state.PositionOf(unit.ID)) == TerrainHazardous {
+			state, _ = applyDamage(state, unit.ID, 1)
+		}
+	}
+	return state
+}
+
+// terrainScore évalue, pour l'IA, l'intérêt pour une unité de se tenir sur
+// une case : le danger coûte un point de vie par tour, couvert et hauteur
+// ne servent qu'à qui en tire parti.
+func terrainScore(state GameState, unit *PlayerUnit, pos Position) float64 {
+	switch state.TerrainAt(pos) {
+	case TerrainHazardous:
+		return -1.2
+	case TerrainLightCover:
+		return 0.4
+	case TerrainHighGround:
+		if unit.Stats.Range > 1 {
+			return 0.6
+		}
+	}
+	return 0
+}
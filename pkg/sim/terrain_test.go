@@ -0,0 +1,134 @@
+package sim
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+)
+
+func terrainTestState(terrain ...TerrainCell) (GameState, *PlayerUnit, *PlayerUnit) {
+	unit := &PlayerUnit{ID: 1, OwnerID: PlayerOne, Unit: Unit{Stats: core.Stats{Health: 3, Range: 2, Move: 2, Power: 2}}}
+	enemy := &PlayerUnit{ID: 2, OwnerID: PlayerTwo, Unit: Unit{Stats: core.Stats{Health: 3, Range: 1, Move: 1, Power: 1}}}
+
+	state := NewGameState(BoardLayout{})
+	state.AddUnit(unit, Position{X: 0, Y: 0})
+	state.AddUnit(enemy, Position{X: 3, Y: 0})
+	state.Set(1, CounterHealth, 3)
+	state.Set(2, CounterHealth, 3)
+	for _, cell := range terrain {
+		state.SetTerrain(cell.Position, cell.Type)
+	}
+	state.CurrentPlayerID = PlayerOne
+	state.ActionsLeft = 2
+
+	return state, unit, enemy
+}
+
+func TestDifficultTerrain(t *testing.T) {
+	state, unit, _ := terrainTestState()
+
+	if !slices.Contains(getReachablePositions(state, Position{X: 0, Y: 0}, unit.Stats.Move), Position{X: 2, Y: 0}) {
+		t.Fatal("expected 2,0 to be reachable on open ground")
+	}
+
+	state.SetTerrain(Position{X: 1, Y: 0}, TerrainDifficult)
+	reachable := getReachablePositions(state, Position{X: 0, Y: 0}, unit.Stats.Move)
+
+	if !slices.Contains(reachable, Position{X: 1, Y: 0}) {
+		t.Error("expected difficult terrain to remain enterable")
+	}
+	if slices.Contains(reachable, Position{X: 2, Y: 0}) {
+		t.Error("expected 2,0 to be out of reach through difficult terrain")
+	}
+	if !slices.Contains(reachable, Position{X: 2, Y: 1}) {
+		t.Error("expected 2,1 to be reachable around difficult terrain")
+	}
+}
+
+func TestLightCover(t *testing.T) {
+	state, unit, enemy := terrainTestState(TerrainCell{Position: Position{X: 3, Y: 0}, Type: TerrainLightCover})
+
+	// Tir à distance : un dégât de moins.
+	state.MoveUnit(unit.ID, Position{X: 1, Y: 0})
+	if e, g := unit.Stats.Power-1, state.attackDamage(unit, enemy.ID); e != g {
+		t.Errorf("ranged attack: expected %d damage, got %d", e, g)
+	}
+
+	// Corps-à-corps : le couvert ne protège pas.
+	state.MoveUnit(unit.ID, Position{X: 2, Y: 0})
+	if e, g := unit.Stats.Power, state.attackDamage(unit, enemy.ID); e != g {
+		t.Errorf("melee attack: expected %d damage, got %d", e, g)
+	}
+
+	// Jamais moins d'un dégât.
+	state.MoveUnit(enemy.ID, Position{X: 0, Y: 0})
+	state.SetTerrain(Position{X: 0, Y: 0}, TerrainLightCover)
+	state.MoveUnit(unit.ID, Position{X: 3, Y: 0})
+	if e, g := 1, state.attackDamage(enemy, unit.ID); e != g {
+		t.Errorf("weak attack: expected %d damage, got %d", e, g)
+	}
+}
+
+func TestHighGround(t *testing.T) {
+	state, unit, enemy := terrainTestState()
+
+	if len(getPossiblePowers(state, unit)) != 0 {
+		t.Fatal("expected the enemy to be out of range on open ground")
+	}
+
+	state.SetTerrain(Position{X: 0, Y: 0}, TerrainHighGround)
+
+	attacks := getPossiblePowers(state, unit)
+	if len(attacks) != 1 || attacks[0].(*AttackAction).TargetID() != enemy.ID {
+		t.Errorf("expected high ground to bring the enemy in range, got %v", attacks)
+	}
+}
+
+func TestHazardousTerrain(t *testing.T) {
+	state, unit, enemy := terrainTestState(TerrainCell{Position: Position{X: 0, Y: 0}, Type: TerrainHazardous}, TerrainCell{Position: Position{X: 3, Y: 0}, Type: TerrainHazardous})
+
+	state = endTurn(state, PlayerOne)
+
+	if e, g := 2, state.Get(unit.ID, CounterHealth, 0); e != g {
+		t.Errorf("unit on hazardous ground: expected %d health, got %d", e, g)
+	}
+	if e, g := 3, state.Get(enemy.ID, CounterHealth, 0); e != g {
+		t.Errorf("hazard only hurts at the end of its owner's turn: expected %d health, got %d", e, g)
+	}
+}
+
+func TestHazardousTerrainEndsGame(t *testing.T) {
+	pass := func(state GameState, playerID PlayerID) Action { return nil }
+
+	game := NewGame(
+		[]Unit{{Stats: core.Stats{Health: 1, Range: 1, Move: 1, Power: 1}}},
+		[]Unit{{Stats: core.Stats{Health: 3, Range: 1, Move: 1, Power: 1}}},
+		WithSeed(1),
+		WithFirstPlayer(PlayerOne),
+		WithDeployment(map[PlayerID][]Position{
+			PlayerOne: {{X: 0, Y: 0}},
+			PlayerTwo: {{X: 0, Y: 7}},
+		}),
+		WithTerrain(TerrainCell{Position: Position{X: 0, Y: 0}, Type: TerrainHazardous}),
+		WithPlayerStrategy(PlayerOne, pass),
+		WithPlayerStrategy(PlayerTwo, pass),
+	)
+
+	var last GameStep
+	for step := range game.Run() {
+		last = step
+	}
+
+	if !last.IsOver || last.Winner != PlayerTwo || last.Turn != 0 {
+		t.Fatalf("expected player two to win on turn 0, got %+v", last)
+	}
+
+	record := game.Record()
+	if e, g := 1, len(record.Setup.Terrain); e != g {
+		t.Fatalf("len(record.Setup.Terrain): expected %d, got %d", e, g)
+	}
+	if _, err := Replay(record); err != nil {
+		t.Errorf("%+v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package sim
+
+/* =============================================================================
+   Table de transposition.
+
+   Mémorise, par empreinte de position (cf. GameState.Hash), le résultat de
+   la dernière exploration : profondeur, score, nature du score et indice du
+   meilleur coup. Elle vit le temps d'une décision : l'approfondissement
+   itératif y retrouve, à chaque itération, le meilleur coup de la
+   précédente, et la recherche n'explore qu'une fois une position atteinte
+   par deux ordres de coups différents.
+
+   Le score d'une recherche alpha-beta dans la fenêtre ]alpha, beta[ n'est
+   exact que s'il tombe dans la fenêtre : en deçà, c'est un majorant (aucun
+   coup ne faisait mieux), au-delà un minorant (la coupe a interrompu
+   l'exploration).
+
+   Le meilleur coup est consigné par son rang dans prunedActions : la
+   génération des coups est déterministe, le même rang désigne donc le même
+   coup dans la même position.
+
+   La table est de taille fixe, indexée par les bits de poids faible de
+   l'empreinte ; une collision d'indice remplace l'entrée, sauf si celle-ci
+   provient d'une exploration plus profonde de la même itération.
+   ========================================================================== */
+
+type boundType uint8
+
+const (
+	boundExact boundType = iota + 1
+	boundLower
+	boundUpper
+)
+
+type transposition struct {
+	hash  uint64
+	score float64
+	depth int16
+	// move : rang du meilleur coup dans prunedActions, -1 si aucun.
+	move      int16
+	bound     boundType
+	iteration uint8
+}
+
+// maxTranspositions borne la table (≈ 6 Mo).
+const maxTranspositions = 1 << 18
+
+type transpositionTable struct {
+	entries   []transposition
+	mask      uint64
+	iteration uint8
+}
+
+// newTranspositionTable dimensionne la table au budget de nœuds de la
+// recherche : au-delà, elle ne se remplirait pas.
+func newTranspositionTable(nodeBudget int) *transpositionTable {
+	size := 1 << 10
+	for size < nodeBudget && size < maxTranspositions {
+		size <<= 1
+	}
+	return &transpositionTable{
+		entries: make([]transposition, size),
+		mask:    uint64(size - 1),
+	}
+}
+
+func (t *transpositionTable) lookup(hash uint64) (transposition, bool) {
+	entry := t.entries[hash&t.mask]
+	if entry.bound == 0 || entry.hash != hash {
+		return transposition{}, false
+	}
+	return entry, true
+}
+
+func (t *transpositionTable) store(hash uint64, depth int, score float64, bound boundType, move int) {
+	slot := &t.entries[hash&t.mask]
+	if slot.bound != 0 && slot.hash != hash && slot.iteration == t.iteration && int(slot.depth) > depth {
+		return
+	}
+	*slot = transposition{
+		hash:      hash,
+		score:     score,
+		depth:     int16(depth),
+		move:      int16(move),
+		bound:     bound,
+		iteration: t.iteration,
+	}
+}
+
+// bound qualifie un score obtenu dans la fenêtre ]alpha, beta[.
+func bound(score, alpha, beta float64) boundType {
+	switch {
+	case score <= alpha:
+		return boundUpper
+	case score >= beta:
+		return boundLower
+	default:
+		return boundExact
+	}
+}
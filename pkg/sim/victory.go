@@ -0,0 +1,549 @@
+package sim
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+	"github.com/pkg/errors"
+)
+
+/* =============================================================================
+   Conditions de victoire.
+
+   L'élimination totale reste toujours une victoire (cf. isGameOver). Le reste
+   — marquage de fin de tour, victoire acquise, départage à la limite de
+   tours — est confié à la VictoryCondition portée par le GameState : endTurn,
+   terminalState et GetWinnerOnTimeout la consultent tous. La recherche
+   alpha-beta réutilisant ces transitions, l'IA joue la mission en vigueur
+   sans code supplémentaire ; Score lui donne en plus une boussole au-delà de
+   son horizon.
+
+   Une VictoryCondition est une valeur immuable : tout ce qui évolue pendant
+   la partie (points, pertes, unités marquées) vit dans le GameState, pour
+   être copié avec lui.
+
+   À plus de deux joueurs, les valeurs de chaque joueur s'additionnent par
+   équipe (cf. teamScores) et le vainqueur désigne une équipe (cf. Team).
+
+   Missions fournies :
+     capture         la règle publiée (cf. CaptureRules), valeur par défaut ;
+     assassination   tuer le chef adverse ;
+     escort          amener son escorte sur le bord adverse ;
+     zone-control    tenir au moins Required zones de capture à la fin de
+                     son tour marque un point de contrôle ;
+     kill-points     points de victoire au coût des unités adverses tuées.
+   ========================================================================== */
+
+type VictoryCondition interface {
+	// Name identifie la condition dans un relevé (cf. RegisterVictoryCondition).
+	Name() string
+	// Setup prépare l'état initial : marquage du chef, de l'escorte…
+	Setup(state GameState) GameState
+	// EndTurn applique le marquage de fin de tour du joueur. Mute l'état
+	// reçu.
+	EndTurn(state GameState, playerID PlayerID) GameState
+	// Winner renvoie la victoire acquise, élimination totale mise à part, et
+	// l'équipe qui l'emporte.
+	Winner(state GameState) (bool, PlayerID)
+	// TimeoutWinner départage une partie qui atteint la limite de tours.
+	TimeoutWinner(state GameState) PlayerID
+	// Score évalue, pour l'IA, la progression de playerID vers la victoire.
+	Score(state GameState, playerID PlayerID) float64
+}
+
+const (
+	// CounterLeader marque le chef d'un joueur (mission assassination).
+	CounterLeader string = "leader"
+	// CounterEscort marque l'unité à escorter (mission escort).
+	CounterEscort string = "escort"
+)
+
+// victory renvoie la condition de victoire de la partie : la capture par
+// défaut.
+func (s GameState) victory() VictoryCondition {
+	if s.Victory == nil {
+		return CaptureVictory{}
+	}
+	return s.Victory
+}
+
+type victoryFactory func(params json.RawMessage) (VictoryCondition, error)
+
+var victoryConditions = map[string]victoryFactory{}
+
+// RegisterVictoryCondition déclare une condition de victoire sous son nom,
+// pour que Replay (et les scénarios) puissent la reconstruire à partir de ses
+// paramètres JSON.
+func RegisterVictoryCondition[T VictoryCondition](name string) {
+	victoryConditions[name] = func(params json.RawMessage) (VictoryCondition, error) {
+		var condition T
+		if len(params) > 0 {
+			if err := json.Unmarshal(params, &condition); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+		return condition, nil
+	}
+}
+
+func init() {
+	RegisterVictoryCondition[CaptureVictory]("capture")
+	RegisterVictoryCondition[AssassinationVictory]("assassination")
+	RegisterVictoryCondition[EscortVictory]("escort")
+	RegisterVictoryCondition[ZoneControlVictory]("zone-control")
+	RegisterVictoryCondition[KillPointsVictory]("kill-points")
+}
+
+// NewVictoryCondition reconstruit une condition de victoire déclarée.
+func NewVictoryCondition(name string, params json.RawMessage) (VictoryCondition, error) {
+	factory, exists := victoryConditions[name]
+	if !exists {
+		return nil, errors.Errorf("unknown victory condition '%s'", name)
+	}
+
+	condition, err := factory(params)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not parse victory condition '%s'", name)
+	}
+
+	return condition, nil
+}
+
+// leadingPlayer renvoie l'équipe dont le total des valeurs est le plus
+// haut, -1 à égalité en tête.
+func leadingPlayer(state GameState, values PlayerScores) PlayerID {
+	teams := state.teamScores(values)
+
+	var leader PlayerID = -1
+	tied := false
+	for team := range PlayerID(state.PlayerCount()) {
+		if state.Team(team) != team {
+			continue
+		}
+		switch {
+		case leader < 0 || teams[team] > teams[leader]:
+			leader, tied = team, false
+		case teams[team] == teams[leader]:
+			tied = true
+		}
+	}
+	if tied {
+		return -1
+	}
+	return leader
+}
+
+// scoreWinner renvoie l'équipe qui atteint pointsToWin, le cas échéant.
+func scoreWinner(state GameState) (bool, PlayerID) {
+	teams := state.teamScores(state.ControlPoints)
+	for team := range PlayerID(state.PlayerCount()) {
+		if state.Team(team) == team && teams[team] >= state.pointsToWin() {
+			return true, team
+		}
+	}
+	return false, -1
+}
+
+// scoreTimeoutWinner départage aux points de contrôle, puis à la santé.
+func scoreTimeoutWinner(state GameState) PlayerID {
+	if leader := leadingPlayer(state, state.ControlPoints); leader >= 0 {
+		return leader
+	}
+	return GetHealthWinner(state)
+}
+
+/* ── Capture ─────────────────────────────────────────────────────────────── */
+
+// CaptureVictory est la règle publiée : chaque zone de capture tenue seul à
+// la fin de son tour rapporte un marqueur, selon les CaptureRules du
+// GameState.
+type CaptureVictory struct{}
+
+func (CaptureVictory) Name() string { return "capture" }
+
+func (CaptureVictory) Setup(state GameState) GameState { return state }
+
+func (CaptureVictory) EndTurn(state GameState, playerID PlayerID) GameState {
+	if state.TurnsPlayed[playerID] <= state.Rules.HoldOffRounds {
+		return state
+	}
+
+	for range controlledObjectives(state, playerID) {
+		state.ControlPoints[playerID] = state.ControlPoints[playerID] + 1
+		state.emit(ControlPointScored{Player: playerID, Total: state.ControlPoints[playerID]})
+
+		if state.Rules.ContestSteals {
+			opponent := richestEnemy(state, playerID)
+			if opponent >= 0 && state.ControlPoints[opponent] > 0 {
+				state.ControlPoints[opponent] = state.ControlPoints[opponent] - 1
+				state.emit(ControlPointStolen{Player: opponent, By: playerID, Total: state.ControlPoints[opponent]})
+			}
+		}
+	}
+
+	return state
+}
+
+func (CaptureVictory) Winner(state GameState) (bool, PlayerID) {
+	return scoreWinner(state)
+}
+
+func (CaptureVictory) TimeoutWinner(state GameState) PlayerID {
+	return scoreTimeoutWinner(state)
+}
+
+func (CaptureVictory) Score(state GameState, playerID PlayerID) float64 {
+	return float64(state.scoreLead(state.ControlPoints, playerID)) * 60.0
+}
+
+// richestEnemy renvoie le joueur ennemi qui a le plus de marqueurs — le
+// premier dans l'ordre des joueurs à égalité —, -1 s'il n'y en a pas.
+func richestEnemy(state GameState, playerID PlayerID) PlayerID {
+	var richest PlayerID = -1
+	for other := range PlayerID(state.PlayerCount()) {
+		if state.Allied(other, playerID) {
+			continue
+		}
+		if richest < 0 || state.ControlPoints[other] > state.ControlPoints[richest] {
+			richest = other
+		}
+	}
+	return richest
+}
+
+/* ── Assassinat ──────────────────────────────────────────────────────────── */
+
+// AssassinationVictory : tuer le chef adverse l'emporte. Leaders désigne le
+// chef de chaque joueur ; à défaut, la première unité de son escouade.
+type AssassinationVictory struct {
+	Leaders map[PlayerID]UnitID `json:"leaders,omitempty"`
+}
+
+func (AssassinationVictory) Name() string { return "assassination" }
+
+func (v AssassinationVictory) Setup(state GameState) GameState {
+	return markUnits(state, CounterLeader, v.Leaders)
+}
+
+func (AssassinationVictory) EndTurn(state GameState, playerID PlayerID) GameState {
+	return state
+}
+
+func (AssassinationVictory) Winner(state GameState) (bool, PlayerID) {
+	return markedUnitLost(state, CounterLeader)
+}
+
+func (AssassinationVictory) TimeoutWinner(state GameState) PlayerID {
+	var health PlayerScores
+	for _, marked := range markedUnits(state, CounterLeader) {
+		health[marked.owner] = state.Get(marked.unit, CounterHealth, 0)
+	}
+	if leader := leadingPlayer(state, health); leader >= 0 {
+		return leader
+	}
+	return GetHealthWinner(state)
+}
+
+func (AssassinationVictory) Score(state GameState, playerID PlayerID) float64 {
+	score := 0.0
+	for _, marked := range markedUnits(state, CounterLeader) {
+		sign := 1.0
+		if !state.Allied(marked.owner, playerID) {
+			sign = -1.0
+		}
+		// Chaque point de vie du chef compte triple, et l'ennemi le plus
+		// proche du chef le menace.
+		score += sign * float64(state.Get(marked.unit, CounterHealth, 0)) * 3.0
+		if unit := state.Unit(marked.unit); unit != nil {
+			if d, enemy := nearestEnemyFrom(state, unit, state.PositionOf(marked.unit)); enemy != nil {
+				score += sign * math.Min(d, 4) * 0.5
+			}
+		}
+	}
+	return score
+}
+
+/* ── Escorte ─────────────────────────────────────────────────────────────── */
+
+// EscortVictory : amener son escorte sur le bord adverse (la rangée de
+// déploiement la plus reculée du joueur d'en face, cf. facingPlayer)
+// l'emporte, la perdre fait perdre.
+// Escorts désigne l'escorte de chaque joueur ; à défaut, la première unité
+// de son escouade.
+type EscortVictory struct {
+	Escorts map[PlayerID]UnitID `json:"escorts,omitempty"`
+}
+
+func (EscortVictory) Name() string { return "escort" }
+
+func (v EscortVictory) Setup(state GameState) GameState {
+	return markUnits(state, CounterEscort, v.Escorts)
+}
+
+func (EscortVictory) EndTurn(state GameState, playerID PlayerID) GameState {
+	return state
+}
+
+func (EscortVictory) Winner(state GameState) (bool, PlayerID) {
+	for _, marked := range markedUnits(state, CounterEscort) {
+		if state.Unit(marked.unit) != nil && escortDistance(state, marked.owner, state.PositionOf(marked.unit)) == 0 {
+			return true, state.Team(marked.owner)
+		}
+	}
+	return markedUnitLost(state, CounterEscort)
+}
+
+func (EscortVictory) TimeoutWinner(state GameState) PlayerID {
+	// Le plus avancé l'emporte : on compare l'opposé des distances restantes.
+	var progress PlayerScores
+	for _, marked := range markedUnits(state, CounterEscort) {
+		progress[marked.owner] = -escortDistance(state, marked.owner, state.PositionOf(marked.unit))
+	}
+	if leader := leadingPlayer(state, progress); leader >= 0 {
+		return leader
+	}
+	return GetHealthWinner(state)
+}
+
+func (EscortVictory) Score(state GameState, playerID PlayerID) float64 {
+	score := 0.0
+	for _, marked := range markedUnits(state, CounterEscort) {
+		sign := 1.0
+		if !state.Allied(marked.owner, playerID) {
+			sign = -1.0
+		}
+		score -= sign * float64(escortDistance(state, marked.owner, state.PositionOf(marked.unit))) * 8.0
+		score += sign * float64(state.Get(marked.unit, CounterHealth, 0)) * 3.0
+	}
+	return score
+}
+
+// escortDistance renvoie le nombre de rangées qui séparent la case du bord
+// adverse.
+func escortDistance(state GameState, playerID PlayerID, pos Position) int {
+	board := state.board()
+	facing := facingPlayer(playerID)
+	rows := board.DeploymentRows(facing)
+	if len(rows) == 0 {
+		return 0
+	}
+	return abs(rows[0] - board.rowOf(facing, pos))
+}
+
+/* ── Contrôle de zones ───────────────────────────────────────────────────── */
+
+// ZoneControlVictory : tenir au moins Required zones de capture à la fin de
+// son tour marque un point de contrôle ; le premier à PointsToWin (cf.
+// CaptureRules) l'emporte. Required = 0 vaut la majorité des zones du
+// plateau.
+type ZoneControlVictory struct {
+	Required int `json:"required,omitempty"`
+}
+
+func (ZoneControlVictory) Name() string { return "zone-control" }
+
+func (ZoneControlVictory) Setup(state GameState) GameState { return state }
+
+func (v ZoneControlVictory) EndTurn(state GameState, playerID PlayerID) GameState {
+	if state.TurnsPlayed[playerID] <= state.Rules.HoldOffRounds {
+		return state
+	}
+	if controlledObjectives(state, playerID) >= v.required(state) {
+		state.ControlPoints[playerID] = state.ControlPoints[playerID] + 1
+		state.emit(ControlPointScored{Player: playerID, Total: state.ControlPoints[playerID]})
+	}
+	return state
+}
+
+func (ZoneControlVictory) Winner(state GameState) (bool, PlayerID) {
+	return scoreWinner(state)
+}
+
+func (ZoneControlVictory) TimeoutWinner(state GameState) PlayerID {
+	return scoreTimeoutWinner(state)
+}
+
+func (v ZoneControlVictory) Score(state GameState, playerID PlayerID) float64 {
+	var controlled PlayerScores
+	for other := range PlayerID(state.PlayerCount()) {
+		controlled[other] = controlledObjectives(state, other)
+	}
+	score := float64(state.scoreLead(state.ControlPoints, playerID)) * 60.0
+	score += float64(state.scoreLead(controlled, playerID)) * 6.0
+	return score
+}
+
+func (v ZoneControlVictory) required(state GameState) int {
+	if v.Required > 0 {
+		return v.Required
+	}
+	return len(state.board().ObjectiveZones)/2 + 1
+}
+
+/* ── Points de victoire ──────────────────────────────────────────────────── */
+
+// KillPointsVictory : chaque unité tuée rapporte son coût en points de
+// victoire (cf. GameState.Losses). Le premier à détruire Share du coût total
+// de l'armée adverse l'emporte ; Share = 0 vaut la moitié. Les pertes ne
+// retiennent pas le tueur : à plus de deux équipes, chacune marque les
+// pertes de toutes ses ennemies (cf. enemyLosses).
+type KillPointsVictory struct {
+	Share float64 `json:"share,omitempty"`
+}
+
+func (KillPointsVictory) Name() string { return "kill-points" }
+
+func (KillPointsVictory) Setup(state GameState) GameState { return state }
+
+func (KillPointsVictory) EndTurn(state GameState, playerID PlayerID) GameState {
+	return state
+}
+
+func (v KillPointsVictory) Winner(state GameState) (bool, PlayerID) {
+	share := v.Share
+	if share <= 0 {
+		share = 0.5
+	}
+
+	var armies PlayerScores
+	for unit := range state.Units() {
+		armies[unit.OwnerID] += state.unitCost(unit)
+	}
+
+	for team := range PlayerID(state.PlayerCount()) {
+		if state.Team(team) != team {
+			continue
+		}
+		total := 0
+		for other := range PlayerID(state.PlayerCount()) {
+			if !state.Allied(other, team) {
+				total += armies[other] + state.Losses[other]
+			}
+		}
+		if total > 0 && float64(state.enemyLosses(team)) >= share*float64(total) {
+			return true, team
+		}
+	}
+
+	return false, -1
+}
+
+func (KillPointsVictory) TimeoutWinner(state GameState) PlayerID {
+	// Points de victoire : les pertes infligées aux ennemis, portées par
+	// l'équipe.
+	var points PlayerScores
+	for team := range PlayerID(state.PlayerCount()) {
+		if state.Team(team) == team {
+			points[team] = state.enemyLosses(team)
+		}
+	}
+	if leader := leadingPlayer(state, points); leader >= 0 {
+		return leader
+	}
+	return GetHealthWinner(state)
+}
+
+func (KillPointsVictory) Score(state GameState, playerID PlayerID) float64 {
+	return float64(state.enemyLosses(playerID)-state.teamScores(state.Losses)[state.Team(playerID)]) * 2.0
+}
+
+// unitCost renvoie le coût d'une unité au barème de la partie (cf. Costs).
+func (s GameState) unitCost(unit *PlayerUnit) int {
+	costs := core.DefaultCosts
+	if s.Costs != nil {
+		costs = *s.Costs
+	}
+	return int(core.CalculateTotalCost(unit.Stats, unit.Abilities, costs))
+}
+
+/* ── Unités marquées ─────────────────────────────────────────────────────── */
+
+// markUnits pose le marqueur counter sur l'unité désignée de chaque joueur,
+// ou à défaut sur la première unité de son escouade.
+func markUnits(state GameState, counter string, designated map[PlayerID]UnitID) GameState {
+	for playerID := range PlayerID(state.PlayerCount()) {
+		unitID, exists := designated[playerID]
+		if !exists {
+			units := getControllableUnits(state, playerID)
+			if len(units) == 0 {
+				continue
+			}
+			unitID = units[0].ID
+		}
+		// Le marqueur porte le propriétaire (+1) : il doit survivre à l'unité.
+		if unit := state.Unit(unitID); unit != nil && unit.OwnerID == playerID {
+			state.Set(unitID, counter, int(playerID)+1)
+		}
+	}
+	return state
+}
+
+// markedUnit : unité qui porte le marqueur de son propriétaire.
+type markedUnit struct {
+	owner PlayerID
+	unit  UnitID
+}
+
+// markedUnits renvoie l'unité marquée de chaque joueur, dans l'ordre des
+// joueurs — morte ou vive : Kill laisse les marqueurs en place. L'ordre
+// fixe rend le départage et l'évaluation reproductibles.
+func markedUnits(state GameState, counter string) []markedUnit {
+	var units [MaxPlayers]UnitID
+	for i := range units {
+		units[i] = -1
+	}
+
+	for i := range state.units {
+		unitID := UnitID(i)
+		mark := state.Get(unitID, counter, 0)
+		if mark <= 0 || mark > MaxPlayers {
+			continue
+		}
+		if owner := mark - 1; units[owner] < 0 {
+			units[owner] = unitID
+		}
+	}
+
+	marked := make([]markedUnit, 0, MaxPlayers)
+	for owner, unitID := range units {
+		if unitID >= 0 {
+			marked = append(marked, markedUnit{owner: PlayerID(owner), unit: unitID})
+		}
+	}
+
+	return marked
+}
+
+// markedUnitLost : dès qu'une unité marquée est morte, l'équipe qui est
+// seule à garder une unité marquée en vie l'emporte — l'adversaire, à deux
+// joueurs. Si toutes tombent ensemble, l'équipe du joueur actif l'emporte.
+func markedUnitLost(state GameState, counter string) (bool, PlayerID) {
+	lost := false
+	var alive PlayerScores
+	for _, marked := range markedUnits(state, counter) {
+		if state.Unit(marked.unit) == nil {
+			lost = true
+			continue
+		}
+		alive[state.Team(marked.owner)]++
+	}
+	if !lost {
+		return false, -1
+	}
+
+	var survivor PlayerID = -1
+	for team, count := range alive {
+		if count == 0 {
+			continue
+		}
+		if survivor >= 0 {
+			return false, -1
+		}
+		survivor = PlayerID(team)
+	}
+	if survivor < 0 {
+		return true, state.Team(state.CurrentPlayerID)
+	}
+	return true, survivor
+}
@@ -0,0 +1,189 @@
+package sim
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+)
+
+// victoryTestState place une unité du joueur un (1) au contact de deux unités
+// adverses fragiles (2 et 3).
+func victoryTestState(victory VictoryCondition) GameState {
+	stats := core.Stats{Health: 1, Range: 1, Move: 1, Power: 1}
+	state := NewGameState(BoardLayout{})
+	state.AddUnit(&PlayerUnit{ID: 1, OwnerID: PlayerOne, Unit: Unit{Stats: core.Stats{Health: 3, Range: 1, Move: 1, Power: 1}}}, Position{X: 3, Y: 1})
+	state.AddUnit(&PlayerUnit{ID: 2, OwnerID: PlayerTwo, Unit: Unit{Stats: stats}}, Position{X: 2, Y: 2})
+	state.AddUnit(&PlayerUnit{ID: 3, OwnerID: PlayerTwo, Unit: Unit{Stats: stats}}, Position{X: 4, Y: 2})
+	state.Set(1, CounterHealth, 3)
+	state.Set(2, CounterHealth, 1)
+	state.Set(3, CounterHealth, 1)
+	state.Victory = victory
+	state.CurrentPlayerID = PlayerOne
+	state.ActionsLeft = 1
+	return victory.Setup(state)
+}
+
+func TestAssassination(t *testing.T) {
+	state := victoryTestState(AssassinationVictory{Leaders: map[PlayerID]UnitID{PlayerTwo: 3}})
+
+	if over, _ := terminalState(state); over {
+		t.Fatal("expected the game not to be over")
+	}
+
+	killed, _ := applyDamage(state.Copy(), 2, 1)
+	if over, _ := terminalState(killed); over {
+		t.Error("expected the game to go on after the death of a soldier")
+	}
+
+	killed, _ = applyDamage(state.Copy(), 3, 1)
+	if over, winner := terminalState(killed); !over || winner != PlayerOne {
+		t.Errorf("expected player one to win with the death of the leader, got %v, %d", over, winner)
+	}
+
+	// L'IA voit la mission : elle abat le chef plutôt que le soldat.
+	state.ActionsLeft = 0
+	action := SearchStrategy(2, 1000)(state, PlayerOne)
+	attack, ok := action.(*AttackAction)
+	if !ok || attack.TargetID() != 3 {
+		t.Errorf("expected an attack on the leader, got %v", action)
+	}
+}
+
+func TestEscort(t *testing.T) {
+	state := victoryTestState(EscortVictory{})
+
+	// Première unité de chaque escouade par défaut.
+	if e, g := []markedUnit{{owner: PlayerOne, unit: 1}, {owner: PlayerTwo, unit: 2}}, markedUnits(state, CounterEscort); !reflect.DeepEqual(e, g) {
+		t.Fatalf("escorts: expected %v, got %v", e, g)
+	}
+
+	if e, g := 6, escortDistance(state, PlayerOne, state.PositionOf(1)); e != g {
+		t.Errorf("escort distance: expected %d, got %d", e, g)
+	}
+
+	moved := state.Copy()
+	moved = NewMoveAction(1, Position{X: 3, Y: 7}).Apply(moved)
+	if over, winner := terminalState(moved); !over || winner != PlayerOne {
+		t.Errorf("expected player one to win on the enemy edge, got %v, %d", over, winner)
+	}
+
+	killed, _ := applyDamage(state.Copy(), 2, 1)
+	if over, winner := terminalState(killed); !over || winner != PlayerOne {
+		t.Errorf("expected player two to lose with its escort, got %v, %d", over, winner)
+	}
+}
+
+func TestZoneControl(t *testing.T) {
+	state := victoryTestState(ZoneControlVictory{})
+	state.Layout = BoardLayout{
+		Width:  8,
+		Height: 8,
+		ObjectiveZones: []Area{
+			{X: 2, Y: 2, Width: 1, Height: 1},
+			{X: 4, Y: 2, Width: 1, Height: 1},
+			{X: 3, Y: 5, Width: 1, Height: 1},
+		},
+	}
+	state.Rules = DefaultCaptureRules
+
+	// Le joueur deux tient deux zones sur trois.
+	scored := endTurn(state.Copy(), PlayerTwo)
+	if e, g := 1, scored.ControlPoints[PlayerTwo]; e != g {
+		t.Errorf("two zones out of three: expected %d point, got %d", e, g)
+	}
+
+	killed, _ := applyDamage(state.Copy(), 2, 1)
+	killed = endTurn(killed, PlayerTwo)
+	if e, g := 0, killed.ControlPoints[PlayerTwo]; e != g {
+		t.Errorf("one zone out of three: expected %d point, got %d", e, g)
+	}
+}
+
+func TestKillPoints(t *testing.T) {
+	state := victoryTestState(KillPointsVictory{Share: 0.5})
+
+	cost := state.unitCost(state.Unit(2))
+
+	killed, _ := applyDamage(state.Copy(), 2, 1)
+	if e, g := cost, killed.Losses[PlayerTwo]; e != g {
+		t.Errorf("losses: expected %d, got %d", e, g)
+	}
+	if over, winner := terminalState(killed); !over || winner != PlayerOne {
+		t.Errorf("expected half of the enemy army to be enough, got %v, %d", over, winner)
+	}
+
+	if e, g := PlayerOne, GetWinnerOnTimeout(killed); e != g {
+		t.Errorf("timeout winner: expected %d, got %d", e, g)
+	}
+}
+
+// Les pertes se comptent au barème de la partie, que le relevé conserve.
+func TestGameCosts(t *testing.T) {
+	costs := core.DefaultCosts
+	costs.HealthFactor *= 2
+
+	published := NewGame(recordTestSquad(), recordTestSquad(), WithSeed(1))
+	game := NewGame(recordTestSquad(), recordTestSquad(), WithSeed(1), WithCosts(costs))
+
+	if p, g := published.State().StartingCost[PlayerOne], game.State().StartingCost[PlayerOne]; g <= p {
+		t.Errorf("starting cost: expected more than %d with doubled health, got %d", p, g)
+	}
+
+	unit := game.State().Unit(0)
+	if e, g := int(core.CalculateTotalCost(unit.Stats, unit.Abilities, costs)), game.State().unitCost(unit); e != g {
+		t.Errorf("unit cost: expected %d, got %d", e, g)
+	}
+
+	replayed, err := Replay(game.Record())
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if e, g := game.State().StartingCost, replayed.State().StartingCost; e != g {
+		t.Errorf("replayed starting cost: expected %v, got %v", e, g)
+	}
+}
+
+func TestMissionRecords(t *testing.T) {
+	missions := []VictoryCondition{
+		AssassinationVictory{},
+		EscortVictory{Escorts: map[PlayerID]UnitID{PlayerOne: 2, PlayerTwo: 4}},
+		ZoneControlVictory{Required: 1},
+		KillPointsVictory{Share: 0.3},
+	}
+
+	for _, mission := range missions {
+		game := NewGame(recordTestSquad(), recordTestSquad(),
+			WithSeed(11),
+			WithVictoryCondition(mission),
+			WithPlayerStrategy(PlayerOne, SearchStrategy(2, 300)),
+			WithPlayerStrategy(PlayerTwo, SearchStrategy(2, 300)),
+			WithMaxTurns(16),
+		)
+		for range game.Run() {
+		}
+
+		var buff bytes.Buffer
+		if err := game.Record().Write(&buff); err != nil {
+			t.Fatalf("%s: %+v", mission.Name(), err)
+		}
+
+		record, err := ReadGameRecord(&buff)
+		if err != nil {
+			t.Fatalf("%s: %+v", mission.Name(), err)
+		}
+
+		if record.Setup.Victory == nil || record.Setup.Victory.Name != mission.Name() {
+			t.Fatalf("%s: expected the mission to be recorded, got %+v", mission.Name(), record.Setup.Victory)
+		}
+
+		replayed, err := Replay(record)
+		if err != nil {
+			t.Fatalf("%s: %+v", mission.Name(), err)
+		}
+		if !reflect.DeepEqual(mission, replayed.State().Victory) {
+			t.Errorf("%s: expected the replayed mission to be %+v, got %+v", mission.Name(), mission, replayed.State().Victory)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package sim
+
+import "hash/fnv"
+
+/* =============================================================================
+   Empreinte de Zobrist.
+
+   La recherche retombe souvent sur une même position par des chemins
+   différents (déplacer A puis B, ou B puis A). L'empreinte identifie la
+   position pour la table de transposition (cf. transpositionTable).
+
+   Chaque élément de l'état — une unité sur une case, un compteur à une
+   valeur — a sa clé pseudo-aléatoire ; l'empreinte est le XOR des clés des
+   éléments présents. Les mutateurs de GameState la tiennent à jour par XOR
+   de la clé retirée et de la clé ajoutée : aucun recalcul à chaque nœud.
+   Plutôt qu'une table de clés tirées au hasard, bornée par le nombre
+   d'unités, de cases et de valeurs, chaque clé est dérivée de son élément
+   par splitmix64 : même qualité de dispersion, aucune borne.
+
+   Les scalaires (joueur courant, actions restantes, marqueurs par joueur)
+   sont assignés directement par les appelants : Hash les mêle au moment du
+   calcul. Obstacles, terrain et règles ne changent pas en cours de partie
+   et n'entrent pas dans l'empreinte.
+   ========================================================================== */
+
+const (
+	zobristUnit uint64 = iota + 1
+	zobristCounter
+	zobristTurn
+	zobristPlayer
+	zobristOverflow
+)
+
+// Hash renvoie l'empreinte de Zobrist de l'état : deux états de même
+// empreinte ont, à une collision près, mêmes unités aux mêmes places, mêmes
+// compteurs, même joueur courant, mêmes actions restantes et mêmes scores.
+func (s GameState) Hash() uint64 {
+	hash := zobristKey(zobristTurn, int(s.CurrentPlayerID), 0, s.ActionsLeft)
+	if s.zobrist != nil {
+		hash ^= *s.zobrist
+	}
+	for playerID := range MaxPlayers {
+		hash ^= zobristKey(zobristPlayer, playerID, s.ControlPoints[playerID], s.TurnsPlayed[playerID])
+		hash ^= zobristKey(zobristPlayer, playerID, -1, s.Losses[playerID])
+	}
+	return hash
+}
+
+// toggle ajoute ou retire une clé de l'empreinte. Sans effet sur un état
+// qui n'a pas été construit par NewGameState.
+func (s GameState) toggle(key uint64) {
+	if s.zobrist != nil {
+		*s.zobrist ^= key
+	}
+}
+
+func unitKey(unitID UnitID, pos Position) uint64 {
+	return zobristKey(zobristUnit, int(unitID), pos.X, pos.Y)
+}
+
+func counterKey(unitID UnitID, index int, value int) uint64 {
+	return zobristKey(zobristCounter, int(unitID), index, value)
+}
+
+// overflowKey : clé d'un compteur hors table (cf. unitSlot.overflow),
+// dérivée de son nom.
+func overflowKey(unitID UnitID, name string, value int) uint64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(name))
+	return splitmix64(zobristKey(zobristOverflow, int(unitID), 0, value) ^ hash.Sum64())
+}
+
+// zobristKey dérive la clé d'un élément : 4 bits de nature, 12 bits
+// d'identifiant, 16 bits et 32 bits de valeurs.
+func zobristKey(kind uint64, id int, a int, b int) uint64 {
+	x := kind<<60 | uint64(uint16(id)&0xfff)<<48 | uint64(uint16(a))<<32 | uint64(uint32(b))
+	return splitmix64(x)
+}
+
+// splitmix64 : finaliseur de SplitMix64 (Steele, Lea, Flood), bijection
+// dont chaque bit de sortie dépend de tous les bits d'entrée.
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
@@ -0,0 +1,111 @@
+package sim
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bornholm/escarmouche/pkg/core"
+)
+
+func TestZobristHash(t *testing.T) {
+	stats := core.Stats{Health: 3, Range: 1, Move: 2, Power: 1}
+	a := &PlayerUnit{ID: 0, OwnerID: PlayerOne, Unit: Unit{Stats: stats}}
+	b := &PlayerUnit{ID: 1, OwnerID: PlayerOne, Unit: Unit{Stats: stats}}
+	enemy := &PlayerUnit{ID: 2, OwnerID: PlayerTwo, Unit: Unit{Stats: stats}}
+
+	state := NewGameState(BoardLayout{})
+	state.AddUnit(a, Position{X: 1, Y: 0})
+	state.AddUnit(b, Position{X: 2, Y: 0})
+	state.AddUnit(enemy, Position{X: 2, Y: 7})
+	for unit := range state.Units() {
+		state.Set(unit.ID, CounterHealth, 3)
+	}
+	state.CurrentPlayerID = PlayerOne
+
+	moveA := NewMoveAction(a.ID, Position{X: 1, Y: 2})
+	moveB := NewMoveAction(b.ID, Position{X: 2, Y: 2})
+
+	ab := moveB.Apply(moveA.Apply(state.Copy()))
+	ba := moveA.Apply(moveB.Apply(state.Copy()))
+
+	if ab.Hash() != ba.Hash() {
+		t.Error("expected both move orders to reach the same hash")
+	}
+	if ab.Hash() == state.Hash() {
+		t.Error("expected the moves to change the hash")
+	}
+
+	// L'empreinte tenue à jour vaut celle d'un état construit directement.
+	rebuilt := NewGameState(BoardLayout{})
+	rebuilt.AddUnit(a, Position{X: 1, Y: 2})
+	rebuilt.AddUnit(b, Position{X: 2, Y: 2})
+	rebuilt.AddUnit(enemy, Position{X: 2, Y: 7})
+	for unit := range rebuilt.Units() {
+		rebuilt.Set(unit.ID, CounterHealth, 3)
+		rebuilt.Set(unit.ID, CounterRoundActions, 1)
+	}
+	rebuilt.DelAll(CounterRoundActions)
+	rebuilt.Set(a.ID, CounterRoundActions, 1)
+	rebuilt.Set(b.ID, CounterRoundActions, 1)
+	rebuilt.CurrentPlayerID = PlayerOne
+
+	if rebuilt.Hash() != ab.Hash() {
+		t.Error("expected the incremental hash to match the rebuilt state's")
+	}
+
+	// Santé, scores et main comptent.
+	hurt := ab.Copy()
+	hurt.Inc(enemy.ID, CounterHealth, -1)
+	scored := ab.Copy()
+	scored.ControlPoints[PlayerOne]++
+	passed := ab.Copy()
+	passed.CurrentPlayerID = PlayerTwo
+
+	for name, other := range map[string]GameState{"health": hurt, "control points": scored, "current player": passed} {
+		if other.Hash() == ab.Hash() {
+			t.Errorf("%s: expected a different hash", name)
+		}
+	}
+
+	killed := ab.Copy().Kill(enemy.ID)
+	if killed.Hash() == ab.Hash() {
+		t.Error("kill: expected a different hash")
+	}
+}
+
+func TestTranspositionTable(t *testing.T) {
+	game := NewGame(recordTestSquad(), recordTestSquad(),
+		WithSeed(7),
+		WithPlayerStrategy(PlayerOne, SearchStrategy(2, 300)),
+		WithPlayerStrategy(PlayerTwo, SearchStrategy(2, 300)),
+	)
+	for range game.Run() {
+		if game.Turn() >= 4 {
+			break
+		}
+	}
+	state := game.State()
+
+	search := func(table *transpositionTable) (float64, int) {
+		s := &searcher{budget: math.MaxInt, table: table}
+		var score float64
+		for depth := 2; depth <= 4; depth += 2 {
+			if table != nil {
+				table.iteration++
+			}
+			_, score, _ = s.alphabeta(state, state.CurrentPlayerID, state.ActionsLeft+1, depth, -math.MaxFloat64, math.MaxFloat64)
+		}
+		return score, s.nodes
+	}
+
+	plainScore, plainNodes := search(nil)
+	tableScore, tableNodes := search(newTranspositionTable(1 << 16))
+
+	if plainScore != tableScore {
+		t.Errorf("expected the same score with and without the table: %.2f != %.2f", plainScore, tableScore)
+	}
+	if tableNodes >= plainNodes {
+		t.Errorf("expected the table to save nodes: %d >= %d", tableNodes, plainNodes)
+	}
+	t.Logf("nodes: %d without table, %d with", plainNodes, tableNodes)
+}